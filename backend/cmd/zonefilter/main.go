@@ -0,0 +1,250 @@
+// zonefilter 从 CZDS 区域文件构建和刷新离线预筛用的布隆过滤器
+//
+//	# 从已下载的区域文件构建
+//	go run ./cmd/zonefilter build -out data/zones.bloom com.zone.gz net.zone.gz
+//
+//	# 从 CZDS 下载最新的区域文件并重新构建（需要 CZDS_USERNAME / CZDS_PASSWORD）
+//	go run ./cmd/zonefilter refresh -tlds com,net -dir data/zones -out data/zones.bloom
+//
+//	# 查看过滤器信息、查询域名
+//	go run ./cmd/zonefilter info data/zones.bloom
+//	go run ./cmd/zonefilter query data/zones.bloom google.com kitleaf.com
+//
+// 构建好的过滤器通过 ZONE_FILTER 环境变量交给服务端，文件被替换后服务端会自动重新加载。
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"domain-agent/backend/internal/zone"
+)
+
+const (
+	czdsAuthURL  = "https://account-api.icann.org/api/authenticate"
+	czdsLinksURL = "https://czds-api.icann.org/czds/downloads/links"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "build":
+		err = runBuild(os.Args[2:])
+	case "refresh":
+		err = runRefresh(os.Args[2:])
+	case "info":
+		err = runInfo(os.Args[2:])
+	case "query":
+		err = runQuery(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "zonefilter:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: zonefilter build|refresh|info|query [flags] [args]")
+	os.Exit(2)
+}
+
+func runBuild(args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	out := fs.String("out", "zones.bloom", "输出的过滤器文件")
+	rate := fs.Float64("fp", zone.DefaultFalsePositiveRate, "目标误判率")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("build: no zone files given")
+	}
+	return build(fs.Args(), *out, *rate)
+}
+
+// build 两遍读取区域文件：先计数确定过滤器大小，再写入
+func build(files []string, out string, rate float64) error {
+	start := time.Now()
+	var n uint64
+	for _, f := range files {
+		if err := zone.EachDelegation(f, func(string) { n++ }); err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+	}
+
+	bloom := zone.NewBloom(n, rate)
+	for _, f := range files {
+		if err := zone.EachDelegation(f, bloom.Add); err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+	}
+	if err := bloom.WriteFile(out); err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote %s: %d domains, suffixes %s, %.1f MB, estimated false positive rate %.2g (%s)\n",
+		out, bloom.Len(), strings.Join(bloom.Suffixes(), ","), float64(bloom.SizeBytes())/1e6,
+		bloom.FalsePositiveRate(), time.Since(start).Round(time.Second))
+	return nil
+}
+
+func runRefresh(args []string) error {
+	fs := flag.NewFlagSet("refresh", flag.ExitOnError)
+	tlds := fs.String("tlds", "com,net", "要下载的后缀，逗号分隔")
+	dir := fs.String("dir", "zones", "区域文件的保存目录")
+	out := fs.String("out", "zones.bloom", "输出的过滤器文件")
+	rate := fs.Float64("fp", zone.DefaultFalsePositiveRate, "目标误判率")
+	fs.Parse(args)
+
+	username, password := os.Getenv("CZDS_USERNAME"), os.Getenv("CZDS_PASSWORD")
+	if username == "" || password == "" {
+		return fmt.Errorf("refresh: CZDS_USERNAME and CZDS_PASSWORD must be set")
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+
+	token, err := czdsAuthenticate(username, password)
+	if err != nil {
+		return err
+	}
+	links, err := czdsLinks(token)
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	for _, tld := range strings.Split(*tlds, ",") {
+		tld = strings.TrimPrefix(strings.TrimSpace(tld), ".")
+		link, ok := links[tld]
+		if !ok {
+			return fmt.Errorf("refresh: no CZDS access to .%s (request it at czds.icann.org)", tld)
+		}
+		file := filepath.Join(*dir, tld+".zone.gz")
+		fmt.Printf("downloading .%s zone...\n", tld)
+		if err := czdsDownload(token, link, file); err != nil {
+			return fmt.Errorf("download .%s: %w", tld, err)
+		}
+		files = append(files, file)
+	}
+
+	return build(files, *out, *rate)
+}
+
+func runInfo(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("info: expected one filter file")
+	}
+	bloom, err := zone.ReadBloom(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("domains:  %d\nsuffixes: %s\nsize:     %.1f MB\nfp rate:  %.2g\n",
+		bloom.Len(), strings.Join(bloom.Suffixes(), ","), float64(bloom.SizeBytes())/1e6, bloom.FalsePositiveRate())
+	return nil
+}
+
+func runQuery(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("query: expected a filter file and at least one domain")
+	}
+	set, err := zone.Open(args[0])
+	if err != nil {
+		return err
+	}
+	for _, d := range args[1:] {
+		status := "not in zone (needs live check)"
+		if zone.Registered(set, d) {
+			status = "registered"
+		}
+		fmt.Printf("%s\t%s\n", d, status)
+	}
+	return nil
+}
+
+// czdsAuthenticate 用 ICANN 账号换取访问令牌
+func czdsAuthenticate(username, password string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"username": username, "password": password})
+	resp, err := http.Post(czdsAuthURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("CZDS authentication failed: HTTP %d", resp.StatusCode)
+	}
+
+	var result struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode CZDS token: %w", err)
+	}
+	return result.AccessToken, nil
+}
+
+// czdsLinks 获取有权限下载的区域文件链接，按后缀索引
+func czdsLinks(token string) (map[string]string, error) {
+	req, _ := http.NewRequest(http.MethodGet, czdsLinksURL, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list CZDS zones: HTTP %d", resp.StatusCode)
+	}
+
+	var urls []string
+	if err := json.NewDecoder(resp.Body).Decode(&urls); err != nil {
+		return nil, fmt.Errorf("decode CZDS links: %w", err)
+	}
+	links := map[string]string{}
+	for _, u := range urls {
+		// 链接形如 https://czds-api.icann.org/czds/downloads/com.zone
+		links[strings.TrimSuffix(path.Base(u), ".zone")] = u
+	}
+	return links, nil
+}
+
+// czdsDownload 下载区域文件（gzip 压缩）到本地，先写临时文件再改名
+func czdsDownload(token, url, file string) error {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	tmp := file + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
@@ -0,0 +1,274 @@
+package aftermarket
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"domain-agent/backend/internal/types"
+)
+
+// maxPageSize 挂牌页面的读取上限
+const maxPageSize = 512 << 10
+
+// landingPage 通过平台的域名挂牌页判断：404 或跳走为未挂牌，有结构化价格或购买、报价按钮的
+// 页面为挂牌，价格从页面中提取
+//
+// Dan.com、Afternic 这类平台没有公开的查询接口，但每个挂牌域名都有固定地址的落地页。
+// 页面能打开但既没有未挂牌的文字、也没有挂牌特征时（如改版或只有脚本的空壳）无法判断。
+type landingPage struct {
+	name     string
+	baseURL  string
+	path     string   // 带 %s 占位符的路径
+	listed   []string // 页面上表示挂牌的文字（购买、报价按钮）
+	unlisted []string // 页面上表示未挂牌的文字
+	client   *http.Client
+}
+
+func (m *landingPage) Name() string { return m.name }
+
+func (m *landingPage) Lookup(ctx context.Context, domain string) (types.Listing, error) {
+	pageURL := m.baseURL + fmt.Sprintf(m.path, url.PathEscape(domain))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return types.Listing{}, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; domain-agent/1.0)")
+	req.Header.Set("Accept", "text/html,application/json;q=0.9")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return types.Listing{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return types.Listing{Status: StatusNotListed}, nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return types.Listing{}, fmt.Errorf("%s returned HTTP %d", m.name, resp.StatusCode)
+	}
+	// 平台对未挂牌的域名常跳回首页或搜索页
+	if !strings.Contains(strings.ToLower(resp.Request.URL.Path), strings.ToLower(domain)) {
+		return types.Listing{Status: StatusNotListed}, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return types.Listing{}, err
+	}
+	page := string(body)
+	lower := strings.ToLower(page)
+	for _, marker := range m.unlisted {
+		if strings.Contains(lower, marker) {
+			return types.Listing{Status: StatusNotListed}, nil
+		}
+	}
+
+	listed := structuredPrice(page)
+	for _, marker := range m.listed {
+		listed = listed || strings.Contains(lower, marker)
+	}
+	if !listed {
+		return types.Listing{}, fmt.Errorf("%s page for %s has no listing marker", m.name, domain)
+	}
+
+	listing := types.Listing{Status: StatusListed, URL: resp.Request.URL.String()}
+	listing.Price, listing.Currency = extractPrice(page)
+	if listing.Price == 0 {
+		listing.MakeOffer = strings.Contains(lower, "make an offer") || strings.Contains(lower, "make offer")
+	}
+	return listing, nil
+}
+
+// 挂牌页上的购买和报价按钮
+var listedMarkers = []string{"buy now", "make an offer", "make offer", "add to cart"}
+
+func newLandingPage(name, baseURL, defaultURL, path string, listed, unlisted []string) *landingPage {
+	if baseURL == "" {
+		baseURL = defaultURL
+	}
+	return &landingPage{
+		name:     name,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		path:     path,
+		listed:   listed,
+		unlisted: unlisted,
+		client:   &http.Client{Timeout: lookupTimeout},
+	}
+}
+
+// NewDan Dan.com 的挂牌页 /buy-domain/<域名>
+func NewDan(baseURL string) Marketplace {
+	return newLandingPage("dan", baseURL, "https://dan.com", "/buy-domain/%s",
+		append([]string{"lease to own"}, listedMarkers...),
+		[]string{"is not for sale", "isn't for sale", "not listed"})
+}
+
+// NewAfternic Afternic 的挂牌页 /forsale/<域名>
+func NewAfternic(baseURL string) Marketplace {
+	return newLandingPage("afternic", baseURL, "https://www.afternic.com", "/forsale/%s",
+		listedMarkers,
+		[]string{"is not for sale", "not available for purchase", "not listed"})
+}
+
+var (
+	// JSON-LD 或内嵌数据中的 "price": "2400" 和 "priceCurrency": "USD"
+	jsonPricePattern    = regexp.MustCompile(`"price"\s*:\s*"?([0-9][0-9,]*(?:\.[0-9]+)?)"?`)
+	jsonCurrencyPattern = regexp.MustCompile(`"priceCurrency"\s*:\s*"([A-Za-z]{3})"`)
+	// <meta property="product:price:amount" content="2400">
+	metaPricePattern    = regexp.MustCompile(`(?i)<meta[^>]+property=["']product:price:amount["'][^>]+content=["']([0-9][0-9,]*(?:\.[0-9]+)?)["']`)
+	metaCurrencyPattern = regexp.MustCompile(`(?i)<meta[^>]+property=["']product:price:currency["'][^>]+content=["']([A-Za-z]{3})["']`)
+	// 页面文字中的 $2,400、€ 1.500、USD 2,400
+	textPricePattern = regexp.MustCompile(`(\$|€|£|USD|EUR|GBP)\s?([0-9]{1,3}(?:[,.][0-9]{3})*(?:\.[0-9]{2})?)`)
+)
+
+var symbolCurrencies = map[string]string{"$": "USD", "€": "EUR", "£": "GBP"}
+
+// structuredPrice 页面的结构化数据（JSON-LD、商品 meta 标签）中有价格
+func structuredPrice(page string) bool {
+	return jsonPricePattern.MatchString(page) || metaPricePattern.MatchString(page)
+}
+
+// extractPrice 从挂牌页中提取价格，优先使用结构化数据
+func extractPrice(page string) (float64, string) {
+	if m := jsonPricePattern.FindStringSubmatch(page); m != nil {
+		if price := parseAmount(m[1]); price > 0 {
+			currency := "USD"
+			if c := jsonCurrencyPattern.FindStringSubmatch(page); c != nil {
+				currency = strings.ToUpper(c[1])
+			}
+			return price, currency
+		}
+	}
+	if m := metaPricePattern.FindStringSubmatch(page); m != nil {
+		if price := parseAmount(m[1]); price > 0 {
+			currency := "USD"
+			if c := metaCurrencyPattern.FindStringSubmatch(page); c != nil {
+				currency = strings.ToUpper(c[1])
+			}
+			return price, currency
+		}
+	}
+	if m := textPricePattern.FindStringSubmatch(html.UnescapeString(page)); m != nil {
+		currency := m[1]
+		if c, ok := symbolCurrencies[currency]; ok {
+			currency = c
+		}
+		amount := m[2]
+		// 欧元金额常用 . 作千位分隔符
+		if currency == "EUR" && strings.Count(amount, ".") > 0 && !strings.Contains(amount, ",") && len(amount)-strings.LastIndex(amount, ".") == 4 {
+			amount = strings.ReplaceAll(amount, ".", "")
+		}
+		return parseAmount(amount), currency
+	}
+	return 0, ""
+}
+
+func parseAmount(s string) float64 {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// sedo 通过 Sedo 合作伙伴接口 DomainStatus 查询，需要 partnerid 和 signkey
+type sedo struct {
+	baseURL   string
+	partnerID string
+	signKey   string
+	client    *http.Client
+}
+
+// Sedo 接口中的货币代码
+var sedoCurrencies = map[string]string{"0": "EUR", "1": "USD", "2": "GBP"}
+
+// NewSedo 创建 Sedo 平台，baseURL 为空时使用正式接口地址
+func NewSedo(baseURL, partnerID, signKey string) Marketplace {
+	if baseURL == "" {
+		baseURL = "https://api.sedo.com"
+	}
+	return &sedo{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		partnerID: partnerID,
+		signKey:   signKey,
+		client:    &http.Client{Timeout: lookupTimeout},
+	}
+}
+
+// NewSedoFromEnv 按 SEDO_PARTNER_ID 和 SEDO_SIGN_KEY 创建，未配置时返回 nil
+func NewSedoFromEnv() Marketplace {
+	partnerID, signKey := os.Getenv("SEDO_PARTNER_ID"), os.Getenv("SEDO_SIGN_KEY")
+	if partnerID == "" || signKey == "" {
+		return nil
+	}
+	return NewSedo("", partnerID, signKey)
+}
+
+func (s *sedo) Name() string { return "sedo" }
+
+func (s *sedo) Lookup(ctx context.Context, domain string) (types.Listing, error) {
+	q := url.Values{
+		"partnerid":  {s.partnerID},
+		"signkey":    {s.signKey},
+		"domainlist": {domain},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/api/v1/DomainStatus?"+q.Encode(), nil)
+	if err != nil {
+		return types.Listing{}, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return types.Listing{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return types.Listing{}, fmt.Errorf("sedo returned HTTP %d", resp.StatusCode)
+	}
+
+	// <SEDOLIST><item><domain>..</domain><forsale>1</forsale><price>2400</price><currency>1</currency></item></SEDOLIST>
+	// 出错时为 <SEDOFAULT><faultcode>..</faultcode><faultstring>..</faultstring></SEDOFAULT>
+	var doc struct {
+		XMLName xml.Name
+		Items   []struct {
+			Domain   string `xml:"domain"`
+			ForSale  string `xml:"forsale"`
+			Price    string `xml:"price"`
+			Currency string `xml:"currency"`
+		} `xml:"item"`
+		FaultString string `xml:"faultstring"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxPageSize)).Decode(&doc); err != nil {
+		return types.Listing{}, fmt.Errorf("sedo: %w", err)
+	}
+	if doc.XMLName.Local == "SEDOFAULT" {
+		return types.Listing{}, fmt.Errorf("sedo: %s", doc.FaultString)
+	}
+
+	for _, item := range doc.Items {
+		if !strings.EqualFold(strings.TrimSpace(item.Domain), domain) {
+			continue
+		}
+		if strings.TrimSpace(item.ForSale) != "1" {
+			return types.Listing{Status: StatusNotListed}, nil
+		}
+		listing := types.Listing{
+			Status: StatusListed,
+			URL:    "https://sedo.com/search/details/?domain=" + url.QueryEscape(domain),
+			Price:  parseAmount(strings.TrimSpace(item.Price)),
+		}
+		listing.Currency = sedoCurrencies[strings.TrimSpace(item.Currency)]
+		listing.MakeOffer = listing.Price == 0
+		return listing, nil
+	}
+	return types.Listing{Status: StatusNotListed}, nil
+}
@@ -0,0 +1,234 @@
+// Package aftermarket 检查已注册的域名是否在二级市场出售
+//
+// 每个交易平台是一个 Marketplace，通过 Register 注册；平台地址可以替换为本地
+// 假平台（见包测试）。除了平台挂牌，WHOIS 中的出售字样或域名
+// 投资商持有者、首页是售卖落地页，也说明域名可能在出售。
+package aftermarket
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"domain-agent/backend/internal/types"
+)
+
+// 挂牌状态
+const (
+	StatusListed    = "listed"
+	StatusNotListed = "not_listed"
+	StatusUnknown   = "unknown" // 请求失败或被限流，无法判断
+)
+
+// lookupTimeout 单个平台查询的超时
+const lookupTimeout = 10 * time.Second
+
+// Marketplace 域名交易平台
+type Marketplace interface {
+	Name() string
+	// Lookup 查询域名的挂牌信息，Status 为 listed 或 not_listed；无法判断时返回错误
+	Lookup(ctx context.Context, domain string) (types.Listing, error)
+}
+
+var (
+	marketplaces     = map[string]Marketplace{}
+	marketplaceOrder []string
+	marketplacesMu   sync.RWMutex
+)
+
+func init() {
+	Register(NewDan(""))
+	Register(NewAfternic(""))
+	if sedo := NewSedoFromEnv(); sedo != nil {
+		Register(sedo)
+	}
+}
+
+// Register 注册平台，同名平台会被覆盖（可用于替换为假平台）
+func Register(m Marketplace) {
+	marketplacesMu.Lock()
+	defer marketplacesMu.Unlock()
+	if _, exists := marketplaces[m.Name()]; !exists {
+		marketplaceOrder = append(marketplaceOrder, m.Name())
+	}
+	marketplaces[m.Name()] = m
+}
+
+// Names 已注册的平台，按注册顺序
+func Names() []string {
+	marketplacesMu.RLock()
+	defer marketplacesMu.RUnlock()
+	return append([]string(nil), marketplaceOrder...)
+}
+
+// Validate 检查平台名称是否都已注册
+func Validate(names []string) error {
+	marketplacesMu.RLock()
+	defer marketplacesMu.RUnlock()
+	var unknown []string
+	for _, name := range names {
+		if _, exists := marketplaces[name]; !exists {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown marketplaces: %s (available: %s)", strings.Join(unknown, ", "), strings.Join(marketplaceOrder, ", "))
+	}
+	return nil
+}
+
+// Lookup 并发查询各平台，names 为空时查询全部平台
+//
+// 结果按平台的注册顺序排列；单个平台失败时状态为 unknown，不影响其他平台。
+func Lookup(ctx context.Context, domain string, names []string) []types.Listing {
+	marketplacesMu.RLock()
+	var selected []Marketplace
+	if len(names) == 0 {
+		names = marketplaceOrder
+	}
+	for _, n := range names {
+		if m, exists := marketplaces[n]; exists {
+			selected = append(selected, m)
+		}
+	}
+	marketplacesMu.RUnlock()
+
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	listings := make([]types.Listing, len(selected))
+	var wg sync.WaitGroup
+	for i, m := range selected {
+		wg.Add(1)
+		go func(i int, m Marketplace) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+			defer cancel()
+
+			listing, err := m.Lookup(ctx, domain)
+			if err != nil {
+				listing = types.Listing{Status: StatusUnknown, Error: err.Error()}
+			}
+			listing.Venue = m.Name()
+			listings[i] = listing
+		}(i, m)
+	}
+	wg.Wait()
+	return listings
+}
+
+// Inspect 查询平台挂牌，并与 WHOIS 和首页的出售信号汇总，见 Summarize
+func Inspect(ctx context.Context, domain string, names []string, signals []string, site *types.WebsiteInfo) *types.AftermarketInfo {
+	return Summarize(Lookup(ctx, domain, names), signals, site)
+}
+
+// Summarize 汇总已查到的平台挂牌、WHOIS 和首页的出售信号，挂牌查询和首页探测可以同时进行
+//
+// signals 为已有的信号（如 WHOISSignals 的结果），site 为首页探测结果，可以为空。
+// 有标价的挂牌中价格最低的一个作为结果的价格。
+func Summarize(listings []types.Listing, signals []string, site *types.WebsiteInfo) *types.AftermarketInfo {
+	info := &types.AftermarketInfo{Signals: append([]string(nil), signals...)}
+	if site != nil {
+		switch site.Status {
+		case "for_sale":
+			info.Signals = append(info.Signals, "landing page: for sale"+providerSuffix(site.Provider))
+		case "parked":
+			info.Signals = append(info.Signals, "landing page: parked"+providerSuffix(site.Provider))
+		}
+	}
+
+	info.Listings = listings
+	var best *types.Listing
+	for i := range info.Listings {
+		l := &info.Listings[i]
+		if l.Status != StatusListed {
+			continue
+		}
+		info.ForSale = true
+		if best == nil || (l.Price > 0 && (best.Price == 0 || l.Price < best.Price)) {
+			best = l
+		}
+	}
+	if best != nil {
+		info.Venue, info.Price, info.Currency, info.URL = best.Venue, best.Price, best.Currency, best.URL
+	}
+
+	// 停放页本身不说明在出售，其余信号都算
+	for _, s := range info.Signals {
+		if !strings.HasPrefix(s, "landing page: parked") {
+			info.ForSale = true
+		}
+	}
+	return info
+}
+
+func providerSuffix(provider string) string {
+	if provider == "" {
+		return ""
+	}
+	return " (" + provider + ")"
+}
+
+// WHOIS 中说明域名在出售的字样，以及常见的域名投资商持有者（只列持有者，不列注册商）
+var (
+	whoisForSaleMarkers = []string{
+		"this domain is for sale", "domain is for sale", "domain may be for sale", "buy this domain",
+		"make an offer", "domain for sale",
+	}
+	investorRegistrants = []string{
+		"hugedomains", "buydomains", "domainmarket", "namefind", "sedo.com", "sedo gmbh", "undeveloped",
+		"dan.com", "domain capital",
+	}
+)
+
+// WHOISSignals WHOIS 持有者字段（名称、组织等）中的出售信号
+//
+// 只传持有者字段：注册商名称和注册局的声明里常有投资商或"for sale"字样，会误报。
+func WHOISSignals(registrant string) []string {
+	lower := strings.ToLower(registrant)
+	var signals []string
+	for _, m := range whoisForSaleMarkers {
+		if strings.Contains(lower, m) {
+			signals = append(signals, "whois: "+m)
+			break
+		}
+	}
+	for _, r := range investorRegistrants {
+		if strings.Contains(lower, r) {
+			signals = append(signals, "whois: held by domain investor ("+r+")")
+			break
+		}
+	}
+	return signals
+}
+
+// currencySymbols 常见货币的符号
+var currencySymbols = map[string]string{"USD": "$", "EUR": "€", "GBP": "£", "CNY": "¥", "JPY": "¥"}
+
+// FormatPrice 格式化价格，如 $2,400、€1,500.50、2,400 CAD
+func FormatPrice(price float64, currency string) string {
+	whole := int64(math.Floor(price))
+	digits := strconv.FormatInt(whole, 10)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	amount := b.String()
+	if cents := math.Round((price - float64(whole)) * 100); cents > 0 {
+		amount += fmt.Sprintf(".%02d", int(cents))
+	}
+
+	currency = strings.ToUpper(currency)
+	if symbol, ok := currencySymbols[currency]; ok {
+		return symbol + amount
+	}
+	if currency == "" {
+		return amount
+	}
+	return amount + " " + currency
+}
@@ -0,0 +1,188 @@
+package aftermarket
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"domain-agent/backend/internal/types"
+)
+
+// danPages Dan.com 挂牌页，未列出的域名跳回首页
+var danPages = map[string]string{
+	"priced.test": `<html><head><script type="application/ld+json">{"@type":"Product","offers":{"price":"2400","priceCurrency":"USD"}}</script></head><body>Buy priced.test</body></html>`,
+	"offer.test":  `<html><body><h1>offer.test</h1><button>Make an offer</button></body></html>`,
+	"cheap.test":  `<html><body><h1>cheap.test</h1><p>Buy now for $4,500</p></body></html>`,
+	"gone.test":   `<html><body>This domain is not for sale.</body></html>`,
+	// 只有脚本的空壳，页面上的价格来自推荐的其它域名
+	"shell.test": `<html><body><div id="app"></div><p>Similar names from $99</p></body></html>`,
+}
+
+// afternicPages Afternic 挂牌页，未列出的域名返回 404
+var afternicPages = map[string]string{
+	"cheap.test": `<html><head><meta property="product:price:amount" content="1,999"><meta property="product:price:currency" content="EUR"></head><body>cheap.test</body></html>`,
+}
+
+// sedoListings Sedo 接口中的挂牌：价格和货币代码（0 EUR、1 USD、2 GBP）
+var sedoListings = map[string][2]string{
+	"sedo.test":  {"950", "2"},
+	"cheap.test": {"3000", "1"},
+}
+
+// standIn 启动模拟 Dan.com 挂牌页、Afternic 挂牌页和 Sedo DomainStatus 接口的本地服务，
+// 把三个平台都注册为指向它的实例，测试结束时恢复原来的平台
+func standIn(t *testing.T) string {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/buy-domain/", func(w http.ResponseWriter, r *http.Request) {
+		page, ok := danPages[strings.TrimPrefix(r.URL.Path, "/buy-domain/")]
+		if !ok {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, page)
+	})
+	mux.HandleFunc("/forsale/", func(w http.ResponseWriter, r *http.Request) {
+		page, ok := afternicPages[strings.TrimPrefix(r.URL.Path, "/forsale/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, page)
+	})
+	mux.HandleFunc("/api/v1/DomainStatus", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("signkey") != "secret" {
+			fmt.Fprint(w, `<SEDOFAULT><faultcode>E0100</faultcode><faultstring>Invalid signkey</faultstring></SEDOFAULT>`)
+			return
+		}
+		domain := r.URL.Query().Get("domainlist")
+		forSale, price, currency := "0", "0", "0"
+		if l, ok := sedoListings[domain]; ok {
+			forSale, price, currency = "1", l[0], l[1]
+		}
+		fmt.Fprintf(w, `<SEDOLIST><item><domain>%s</domain><type>D</type><forsale>%s</forsale><price>%s</price><currency>%s</currency></item></SEDOLIST>`,
+			domain, forSale, price, currency)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body>Find your perfect domain</body></html>`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	marketplacesMu.Lock()
+	saved, savedOrder := maps.Clone(marketplaces), slices.Clone(marketplaceOrder)
+	marketplacesMu.Unlock()
+	t.Cleanup(func() {
+		marketplacesMu.Lock()
+		marketplaces, marketplaceOrder = saved, savedOrder
+		marketplacesMu.Unlock()
+	})
+
+	Register(NewDan(srv.URL))
+	Register(NewAfternic(srv.URL))
+	Register(NewSedo(srv.URL, "1", "secret"))
+	return srv.URL
+}
+
+func TestInspect(t *testing.T) {
+	standIn(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		domain   string
+		forSale  bool
+		venue    string
+		price    float64
+		currency string
+	}{
+		{"priced.test", true, "dan", 2400, "USD"},
+		{"offer.test", true, "dan", 0, ""},
+		{"cheap.test", true, "afternic", 1999, "EUR"}, // 三个平台都挂牌，取最低价
+		{"sedo.test", true, "sedo", 950, "GBP"},
+		{"gone.test", false, "", 0, ""},
+		{"shell.test", false, "", 0, ""},
+		{"unlisted.test", false, "", 0, ""},
+	}
+	for _, tt := range tests {
+		info := Inspect(ctx, tt.domain, nil, nil, nil)
+		if info.ForSale != tt.forSale || info.Venue != tt.venue || info.Price != tt.price || info.Currency != tt.currency {
+			t.Errorf("%s: for_sale=%v venue=%q price=%v %s listings=%+v", tt.domain, info.ForSale, info.Venue, info.Price, info.Currency, info.Listings)
+		}
+	}
+
+	if info := Inspect(ctx, "offer.test", []string{"dan"}, nil, nil); len(info.Listings) != 1 || !info.Listings[0].MakeOffer {
+		t.Errorf("offer.test: listings %+v, want make offer", info.Listings)
+	}
+	// 页面既没有未挂牌的文字也没有挂牌特征：无法判断，而不是挂牌
+	if info := Inspect(ctx, "shell.test", []string{"dan"}, nil, nil); info.Listings[0].Status != StatusUnknown {
+		t.Errorf("shell.test: listing %+v, want unknown", info.Listings[0])
+	}
+
+	info := Inspect(ctx, "unlisted.test", []string{"dan"}, nil, &types.WebsiteInfo{Status: "parked", Provider: "Sedo"})
+	if info.ForSale || len(info.Signals) != 1 {
+		t.Errorf("parked page only: for_sale=%v signals=%v", info.ForSale, info.Signals)
+	}
+	info = Inspect(ctx, "unlisted.test", []string{"dan"}, nil, &types.WebsiteInfo{Status: "for_sale"})
+	if !info.ForSale || info.Venue != "" {
+		t.Errorf("for-sale page: for_sale=%v venue=%q", info.ForSale, info.Venue)
+	}
+}
+
+func TestSedoFault(t *testing.T) {
+	url := standIn(t)
+	Register(NewSedo(url, "1", "wrong"))
+	info := Inspect(context.Background(), "sedo.test", []string{"sedo"}, nil, nil)
+	if info.ForSale || info.Listings[0].Status != StatusUnknown || !strings.Contains(info.Listings[0].Error, "Invalid signkey") {
+		t.Errorf("sedo fault: for_sale=%v listing=%+v", info.ForSale, info.Listings[0])
+	}
+}
+
+func TestWHOISSignals(t *testing.T) {
+	tests := []struct {
+		registrant string
+		signals    int
+	}{
+		{"HugeDomains.com", 1},
+		{"This domain is for sale\nBuyDomains.com", 2},
+		{"Acme Tools Inc.", 0},
+		// 注册商不是持有者
+		{"Epik Holdings Inc.", 0},
+	}
+	for _, tt := range tests {
+		if got := WHOISSignals(tt.registrant); len(got) != tt.signals {
+			t.Errorf("WHOISSignals(%q) = %v, want %d signals", tt.registrant, got, tt.signals)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	standIn(t)
+	if err := Validate([]string{"dan", "sedo"}); err != nil {
+		t.Errorf("Validate(dan, sedo) = %v", err)
+	}
+	if err := Validate([]string{"dan", "nope"}); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("Validate(dan, nope) = %v, want unknown nope", err)
+	}
+}
+
+func TestFormatPrice(t *testing.T) {
+	tests := []struct {
+		price    float64
+		currency string
+		want     string
+	}{
+		{2400, "USD", "$2,400"},
+		{1500.5, "EUR", "€1,500.50"},
+		{999, "GBP", "£999"},
+		{1234567, "CAD", "1,234,567 CAD"},
+	}
+	for _, tt := range tests {
+		if got := FormatPrice(tt.price, tt.currency); got != tt.want {
+			t.Errorf("FormatPrice(%v, %s) = %s, want %s", tt.price, tt.currency, got, tt.want)
+		}
+	}
+}
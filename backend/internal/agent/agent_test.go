@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"domain-agent/backend/internal/llm"
+	"domain-agent/backend/internal/store"
+	"domain-agent/backend/internal/types"
+)
+
+// useSlowLLM 把 LLM 换成固定延迟的本地替身，返回调用次数的计数器
+func useSlowLLM(t testing.TB, latency time.Duration) *atomic.Int64 {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(latency)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(llm.ChatResponse{
+			Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: `{"intent":"general","confidence":0.9}`}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Setenv("VIBECODING_API_KEY", "test")
+	t.Setenv("VIBECODING_BASE_URL", srv.URL)
+	previous := llmClient
+	SetLLMClient(llm.NewClient())
+	t.Cleanup(func() { SetLLMClient(previous) })
+	return &calls
+}
+
+// TestSessionsRunInParallel 不同会话的消息并行处理：总耗时接近单条消息，而不是随会话数增长
+func TestSessionsRunInParallel(t *testing.T) {
+	const sessions = 20
+	useSlowLLM(t, 100*time.Millisecond)
+
+	start := time.Now()
+	if _, err := ProcessMessage(types.ChatRequest{SessionID: "parallel-baseline", Message: "message baseline"}); err != nil {
+		t.Fatal(err)
+	}
+	single := time.Since(start)
+
+	var wg sync.WaitGroup
+	start = time.Now()
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			if _, err := ProcessMessage(types.ChatRequest{SessionID: fmt.Sprintf("parallel-%d", id), Message: "message"}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > 3*single {
+		t.Errorf("%d sessions took %v, single message %v: sessions are serialized", sessions, elapsed, single)
+	}
+}
+
+// TestSameSessionSerialized 同一会话的消息按顺序处理，不会丢失
+func TestSameSessionSerialized(t *testing.T) {
+	useSlowLLM(t, 10*time.Millisecond)
+
+	const messages = 5
+	var wg sync.WaitGroup
+	for i := 0; i < messages; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := ProcessMessage(types.ChatRequest{SessionID: "serial", Message: fmt.Sprintf("message %d", i)}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	session, err := sessions.Get("serial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := 0
+	for _, m := range session.Messages {
+		if m.Role == "user" {
+			users++
+		}
+	}
+	if users != messages {
+		t.Errorf("session has %d user message(s), want %d", users, messages)
+	}
+	if n := len(locks.locks); n != 0 {
+		t.Errorf("%d session lock(s) left after all messages finished", n)
+	}
+}
+
+// BenchmarkProcessMessage 多个会话同时发送消息，LLM 替身每次调用延迟 20ms
+//
+//	go test ./internal/agent -run '^$' -bench ProcessMessage -cpu 1,8,50
+func BenchmarkProcessMessage(b *testing.B) {
+	calls := useSlowLLM(b, 20*time.Millisecond)
+
+	var next atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		id := fmt.Sprintf("bench-%d", next.Add(1))
+		for pb.Next() {
+			if _, err := ProcessMessage(types.ChatRequest{SessionID: id, Message: "message"}); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+	b.ReportMetric(float64(calls.Load())/float64(b.N), "llm-calls/op")
+}
+
+func TestOpenStore(t *testing.T) {
+	useSlowLLM(t, 0)
+	t.Cleanup(func() { SetStore(store.NewMemoryStore(sessionTTL)) })
+
+	// 配置的存储打不开时返回错误，保留原来的存储而不是悄悄换掉
+	previous := sessions
+	if err := OpenStore("mysql://localhost/db"); err == nil || sessions != previous {
+		t.Errorf("OpenStore(mysql) = %v, store replaced: %v", err, sessions != previous)
+	}
+	if err := OpenStore("sqlite://" + filepath.Join(t.TempDir(), "sessions.db")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ProcessMessage(types.ChatRequest{SessionID: "sqlite", Message: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Get("sqlite"); err != nil {
+		t.Errorf("session not saved in the opened store: %v", err)
+	}
+}
@@ -0,0 +1,789 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"domain-agent/backend/internal/aftermarket"
+	"domain-agent/backend/internal/certs"
+	"domain-agent/backend/internal/ecosystems"
+	handlecheck "domain-agent/backend/internal/handles"
+	"domain-agent/backend/internal/intent"
+	"domain-agent/backend/internal/llm"
+	"domain-agent/backend/internal/namecheck"
+	"domain-agent/backend/internal/scanner"
+	"domain-agent/backend/internal/types"
+	"domain-agent/backend/internal/website"
+)
+
+// 各意图 Data 的结构，仅用于生成模式
+type (
+	checkData struct {
+		Domains      []string                             `json:"domains"`
+		Results      []types.DomainResult                 `json:"results,omitempty"`
+		Alternatives map[string][]types.AlternativeDomain `json:"alternatives,omitempty"`
+	}
+	suggestionsData struct {
+		Domains  []string `json:"domains,omitempty"` // LLM 失败时可能为空
+		Keywords []string `json:"keywords"`
+	}
+	compareData struct {
+		Domains []string             `json:"domains" jsonschema:"minItems=2"`
+		Results []types.DomainResult `json:"results"`
+	}
+	registrationData struct {
+		Registrations []types.Registration `json:"registrations" jsonschema:"minItems=1"`
+	}
+	alternativesData struct {
+		Domain       string                    `json:"domain"`
+		Domains      []string                  `json:"domains"`
+		Alternatives []types.AlternativeDomain `json:"alternatives"`
+	}
+	handlesData struct {
+		Handles   []string                        `json:"handles" jsonschema:"minItems=1"`
+		Platforms []string                        `json:"platforms"`
+		Results   map[string][]types.HandleResult `json:"results"`
+	}
+	packagesData struct {
+		Names      []string                           `json:"names" jsonschema:"minItems=1"`
+		Registries []string                           `json:"registries"`
+		Results    map[string][]types.EcosystemResult `json:"results"`
+	}
+)
+
+// variantNames 替代域名变体类型的中文说明
+var variantNames = map[string]string{
+	scanner.VariantTLDSwap: "换后缀",
+	scanner.VariantPrefix:  "加前缀",
+	scanner.VariantSuffix:  "加后缀词",
+	scanner.VariantPlural:  "单复数",
+	scanner.VariantHyphen:  "连字符",
+	scanner.VariantSynonym: "近义词",
+}
+
+// checkStatusNames 账号和包名检查状态的中文说明，两类检查共用 namecheck 的状态
+var checkStatusNames = map[string]string{
+	namecheck.StatusAvailable: "✅ 可用",
+	namecheck.StatusTaken:     "❌ 已被占用",
+	namecheck.StatusInvalid:   "⚠️ 不符合该平台的命名规则",
+	namecheck.StatusUnknown:   "❓ 查询失败，请稍后再试",
+}
+
+func init() {
+	RegisterIntent(&IntentHandler{
+		Name:        intent.CheckSpecific,
+		Action:      "check_domains",
+		Description: "用户提供了具体的域名（如 google.com, abc.cn），想查询这些域名是否可用",
+		Schema:      llm.SchemaFor(checkData{}),
+		Handle:      handleCheckSpecific,
+	})
+	RegisterIntent(&IntentHandler{
+		Name:        intent.GenerateIdeas,
+		Action:      "generate_suggestions",
+		Description: "用户想要域名创意建议，或者想要生成/推荐相关的域名",
+		Schema:      llm.SchemaFor(suggestionsData{}),
+		Suggests:    true,
+		Handle:      handleGenerateIdeas,
+	})
+	RegisterIntent(&IntentHandler{
+		Name:        intent.Greeting,
+		Action:      "none",
+		Description: "问候语",
+		Handle:      handleGreeting,
+	})
+	RegisterIntent(&IntentHandler{
+		Name:        intent.General,
+		Action:      "clarify",
+		Description: "一般咨询",
+		Handle:      handleGeneral,
+	})
+	RegisterIntent(&IntentHandler{
+		Name:        intent.Compare,
+		Action:      "compare_domains",
+		Description: "用户想比较两个或多个域名的优劣",
+		Examples: []string{
+			"比较一下这两个域名", "哪个更好", "这两个选哪个", "第一个和第二个哪个好",
+			"compare these two names", "which one is better", "compare the first and the second",
+			"foo.com vs foo.io", "a 和 b 哪个更好记",
+		},
+		Schema: llm.SchemaFor(compareData{}),
+		Handle: handleCompare,
+	})
+	RegisterIntent(&IntentHandler{
+		Name:        intent.ExplainTaken,
+		Action:      "explain_registration",
+		Description: "用户想知道某个域名为什么被占用：谁注册的、什么时候注册、何时到期",
+		Examples: []string{
+			"为什么这个域名被注册了", "谁注册了这个域名", "这个域名什么时候到期", "这个域名的持有者是谁",
+			"why is it taken", "who owns this domain", "when does it expire", "who registered it",
+			"查一下注册人和到期时间",
+		},
+		Schema: llm.SchemaFor(registrationData{}),
+		Handle: handleExplainTaken,
+	})
+	RegisterIntent(&IntentHandler{
+		Name:        intent.Refine,
+		Action:      "refine_suggestions",
+		Description: "用户想调整上一轮的域名建议（更短、换风格、只要某个后缀等）",
+		Examples: []string{
+			"再短一点", "短一些", "换个风格", "只要 .ai 的", "去掉带数字的", "更有科技感一点",
+			"make them shorter", "shorter please", "only .io ones", "more playful", "without hyphens",
+		},
+		Schema:   llm.SchemaFor(suggestionsData{}),
+		Suggests: true,
+		Handle:   handleRefine,
+	})
+	RegisterIntent(&IntentHandler{
+		Name:        intent.Alternatives,
+		Action:      "suggest_alternatives",
+		Description: "用户想要的域名已被注册，希望找到相近的替代域名",
+		Examples: []string{
+			"这个被注册了，有没有替代的", "换个类似的", "有没有相近的域名", "找找替代方案",
+			"it is taken, any alternatives", "find alternatives", "something similar to this",
+			"similar available names",
+		},
+		Schema:   llm.SchemaFor(alternativesData{}),
+		Suggests: true,
+		Handle:   handleAlternatives,
+	})
+	RegisterIntent(&IntentHandler{
+		Name:        intent.SocialHandles,
+		Action:      "check_handles",
+		Description: "用户想查询同名的社交账号（GitHub、X、Instagram）是否可用",
+		Examples: []string{
+			"这个名字在 github 上有人用吗", "查一下社交账号", "推特和 ins 的用户名还在吗",
+			"check social handles", "is the twitter handle free", "check github and instagram",
+		},
+		Schema: llm.SchemaFor(handlesData{}),
+		Handle: handleSocialHandles,
+	})
+	RegisterIntent(&IntentHandler{
+		Name:        intent.PackageNames,
+		Action:      "check_packages",
+		Description: "用户想查询同名的包名或镜像名（npm、PyPI、crates.io、Go 模块、Docker Hub）是否已被使用，常见于开发者工具起名",
+		Examples: []string{
+			"这个名字在 crates.io 上有人用吗", "docker hub 上有没有同名镜像", "go 模块名会不会冲突", "查一下各个包仓库",
+			"开发者工具的名字要在包仓库里也能用", "pypi 和 crates 上有没有", "npm 包名被占了吗",
+			"is the crate name taken", "check package registries", "is it free on docker hub", "any go module with this name",
+			"is the npm name taken",
+		},
+		Schema: llm.SchemaFor(packagesData{}),
+		Handle: handlePackageNames,
+	})
+}
+
+// domains 本轮涉及的域名：消息中明确给出的优先，其次是指代
+func (t *turn) domains() []string {
+	if domains := extractDomains(t.message); len(domains) > 0 {
+		return domains
+	}
+	return t.references
+}
+
+// reply 调用 LLM 生成自然语言回复，失败时使用 fallback
+func (t *turn) reply(prompt, fallback string) string {
+	llmResponse, err := llmClient.GenerateResponse(prompt, t.history)
+	if err != nil {
+		return fallback
+	}
+	return llmResponse
+}
+
+func handleGreeting(t *turn, response *types.ChatResponse) {
+	// 使用 AI 生成友好的问候响应
+	response.Message = t.reply(t.message, "你好！我是 Domain Agent，专业的域名查询助手。我可以帮你查询域名和生成创意域名建议。")
+}
+
+func handleGeneral(t *turn, response *types.ChatResponse) {
+	// 使用 AI 生成通用响应
+	response.Message = t.reply(annotateReferences(t.message, t.references), "我理解你想查询域名。你可以直接告诉我域名，或描述你的需求。")
+}
+
+// autoAlternatives 查询结果中最多为几个已注册的域名自动寻找替代，每个替代都要检查可用性
+const autoAlternatives = 2
+
+// autoAlternativeCount 自动寻找替代时每个域名给出的数量
+const autoAlternativeCount = 5
+
+// autoAlternativesTimeout 自动寻找替代最多等待的时间，超时的域名不再等待，先返回查询结果
+var autoAlternativesTimeout = 8 * time.Second
+
+// findAlternatives 寻找替代域名，测试可以替换
+var findAlternatives = scanner.FindAlternatives
+
+// findAutoAlternatives 为最多 autoAlternatives 个已注册的域名并发寻找替代
+//
+// 处理消息时持有会话锁，所以最多等待 autoAlternativesTimeout；还没查完的域名在
+// pending 中返回，它们的查询在后台结束后丢弃。
+func findAutoAlternatives(results []types.DomainResult, tlds []string) (found map[string][]types.AlternativeDomain, pending []string) {
+	var taken []string
+	for _, r := range results {
+		if !r.Available && len(taken) < autoAlternatives {
+			taken = append(taken, r.Domain)
+		}
+	}
+
+	type outcome struct {
+		domain string
+		found  []types.AlternativeDomain
+		err    error
+	}
+	// 带缓冲：超时后才结束的查询不会阻塞
+	outcomes := make(chan outcome, len(taken))
+	tlds, find := slices.Clone(tlds), findAlternatives
+	for _, domain := range taken {
+		go func(domain string) {
+			alternatives, err := find(types.AlternativesRequest{Domain: domain, TLDs: tlds, Count: autoAlternativeCount})
+			outcomes <- outcome{domain, alternatives, err}
+		}(domain)
+	}
+
+	found = map[string][]types.AlternativeDomain{}
+	done := map[string]bool{}
+	timeout := time.NewTimer(autoAlternativesTimeout)
+	defer timeout.Stop()
+	for range taken {
+		select {
+		case o := <-outcomes:
+			done[o.domain] = true
+			if o.err != nil {
+				fmt.Printf("Alternatives for %s failed: %v\n", o.domain, o.err)
+				continue
+			}
+			found[o.domain] = o.found
+		case <-timeout.C:
+			for _, domain := range taken {
+				if !done[domain] {
+					pending = append(pending, domain)
+				}
+			}
+			return found, pending
+		}
+	}
+	return found, nil
+}
+
+func handleCheckSpecific(t *turn, response *types.ChatResponse) {
+	domains := t.domains()
+	response.Data["domains"] = domains
+	if len(domains) == 0 {
+		response.Action = "clarify"
+		response.Message = "你想查询哪个域名？请告诉我完整域名，例如 example.com。"
+		return
+	}
+
+	results, err := scanner.CheckDomains(domains)
+	if err != nil {
+		response.Action = "clarify"
+		response.Message = fmt.Sprintf("查询域名时出错：%v", err)
+		return
+	}
+	results = orderResults(domains, results)
+	response.Data["results"] = results
+
+	// 已注册的域名自动寻找可注册的替代（替代模式），与 suggest_alternatives 使用同一流程
+	alternatives, pending := findAutoAlternatives(results, t.session.State.PreferredTLDs)
+	if len(alternatives) > 0 {
+		response.Data["alternatives"] = alternatives
+	}
+	if len(pending) > 0 {
+		response.Data["alternatives_pending"] = pending
+	}
+
+	var facts strings.Builder
+	for _, r := range results {
+		if r.Available {
+			facts.WriteString(fmt.Sprintf("- **%s**：✅ 可注册（置信度 %.0f%%）\n", r.Domain, r.Confidence*100))
+			continue
+		}
+		facts.WriteString(fmt.Sprintf("- **%s**：❌ 已注册（置信度 %.0f%%）\n", r.Domain, r.Confidence*100))
+		found, searched := alternatives[r.Domain]
+		switch {
+		case slices.Contains(pending, r.Domain):
+			facts.WriteString(fmt.Sprintf("  相近的域名还没查完，可以稍后说“帮我找 %s 的替代”。\n", r.Domain))
+		case !searched:
+		case len(found) == 0:
+			facts.WriteString("  暂时没有找到可注册的相近域名。\n")
+		default:
+			var names []string
+			for _, a := range found {
+				names = append(names, fmt.Sprintf("%s（%s）", a.Domain, variantNames[a.Variant]))
+			}
+			facts.WriteString("  可注册的替代：" + strings.Join(names, "、") + "\n")
+		}
+	}
+
+	response.Message = t.reply(
+		"用户查询了这些域名的可用性，请根据下面的查询结果回复；已注册的域名请推荐给出的替代：\n"+facts.String(),
+		"查询结果：\n\n"+facts.String(),
+	)
+}
+
+func handleGenerateIdeas(t *turn, response *types.ChatResponse) {
+	source, keywords := extractKeywords(t.message)
+	response.Data["keywords"] = keywords
+
+	// 使用 LLM 生成结构化的域名创意，附上翻译和扩展后的关键词供参考
+	input := annotateReferences(t.message, t.references)
+	if len(keywords) > 0 {
+		input += fmt.Sprintf("\n（可参考的英文关键词：%s）", strings.Join(keywords, ", "))
+	}
+	ideas, err := llmClient.GenerateDomainIdeas(input, t.history)
+	if err != nil && len(source) > 0 {
+		// LLM 不可用时用本地策略从关键词生成
+		fmt.Printf("LLM domain ideas failed, using local suggestions: %v\n", err)
+		writeLocalSuggestions(response, source)
+		return
+	}
+	writeIdeas(response, ideas, err)
+}
+
+// writeLocalSuggestions 用本地生成器从关键词生成建议（不检查可用性）
+//
+// keywords 是原文中的关键词，由生成器翻译和扩展；拼音策略需要中文原词。
+func writeLocalSuggestions(response *types.ChatResponse, keywords []string) {
+	suggestions := scanner.GenerateSuggestions(types.SuggestDomainsRequest{
+		Keywords: keywords,
+		TLDs:     []string{".com", ".io", ".ai"},
+		Count:    10,
+		Expand:   true,
+	})
+
+	var domains []string
+	var domainReasons []map[string]string
+	for _, s := range suggestions {
+		domains = append(domains, s.Domain)
+		domainReasons = append(domainReasons, map[string]string{
+			"domain": s.Domain,
+			"reason": s.Reason,
+		})
+	}
+
+	response.Message = fmt.Sprintf("根据关键词 %s，我生成了这些域名：\n\n%s", strings.Join(keywords, ", "), numberedList(domains))
+	response.Data["domains"] = domains
+	response.Data["domainReasons"] = domainReasons
+}
+
+func handleRefine(t *turn, response *types.ChatResponse) {
+	previous := t.session.State.LastSuggestions
+	if len(previous) == 0 {
+		// 没有可调整的建议，按生成新建议处理
+		response.Intent = intent.GenerateIdeas
+		response.Action = intentHandlers[intent.GenerateIdeas].Action
+		handleGenerateIdeas(t, response)
+		return
+	}
+
+	prompt := fmt.Sprintf("之前给出的域名建议：%s\n用户希望这样调整：%s\n请按要求给出调整后的新建议。",
+		strings.Join(previous, ", "), t.message)
+	ideas, err := llmClient.GenerateDomainIdeas(prompt, t.history)
+	if err != nil {
+		// LLM 不可用时在本地调整：要求“短”就按长度排序取较短的一半
+		fmt.Printf("LLM refine failed: %v\n", err)
+		refined := append([]string(nil), previous...)
+		lower := strings.ToLower(t.message)
+		if strings.Contains(lower, "短") || strings.Contains(lower, "short") {
+			sort.SliceStable(refined, func(i, j int) bool {
+				return len(domainLabel(refined[i])) < len(domainLabel(refined[j]))
+			})
+			refined = refined[:(len(refined)+1)/2]
+		}
+		if len(refined) < len(previous) {
+			response.Message = "根据你的要求，从之前的建议中筛选出：\n\n" + numberedList(refined)
+		} else {
+			response.Message = "暂时无法按这个要求调整，没有做任何筛选，之前的建议是：\n\n" + numberedList(refined)
+		}
+		response.Data["domains"] = refined
+	} else {
+		writeIdeas(response, ideas, nil)
+	}
+
+	response.Data["previous"] = previous
+	response.Data["keywords"] = t.session.State.Keywords
+}
+
+func handleCompare(t *turn, response *types.ChatResponse) {
+	domains := t.domains()
+	if len(domains) < 2 {
+		response.Action = "clarify"
+		response.Message = "请告诉我要比较的两个（或更多）域名，例如“比较 foo.com 和 foo.io”。"
+		return
+	}
+
+	results, err := scanner.CheckDomains(domains)
+	if err != nil {
+		response.Action = "clarify"
+		response.Message = fmt.Sprintf("查询域名时出错：%v", err)
+		return
+	}
+	results = orderResults(domains, results)
+
+	var facts strings.Builder
+	for _, r := range results {
+		status := "已注册"
+		if r.Available {
+			status = "可注册"
+		}
+		facts.WriteString(fmt.Sprintf("- **%s**：%s（置信度 %.0f%%），评分 %.0f，长度 %d%s\n", r.Domain, status, r.Confidence*100, r.Score, len(domainLabel(r.Domain)), describeValue(r.EstimatedValue)))
+	}
+
+	response.Data["domains"] = domains
+	response.Data["results"] = results
+	response.Message = t.reply(
+		"请比较下面这些域名，结合可用性、长度、易记程度给出推荐：\n"+facts.String(),
+		"比较结果：\n\n"+facts.String(),
+	)
+}
+
+func handleExplainTaken(t *turn, response *types.ChatResponse) {
+	domains := t.domains()
+	if len(domains) == 0 {
+		response.Action = "clarify"
+		response.Message = "你想了解哪个域名的注册信息？请告诉我完整域名，例如 example.com。"
+		return
+	}
+	if len(domains) > 3 {
+		domains = domains[:3]
+	}
+
+	// 各域名并行查询，已注册域名的 CT、首页和挂牌查询也同时进行，共用一个请求时限
+	ctx, cancel := context.WithTimeout(context.Background(), explainTakenTimeout)
+	defer cancel()
+	explained := make([]takenExplanation, len(domains))
+	var wg sync.WaitGroup
+	for i, domain := range domains {
+		wg.Add(1)
+		go func(e *takenExplanation, domain string) {
+			defer wg.Done()
+			explainTaken(ctx, e, domain)
+		}(&explained[i], domain)
+	}
+	wg.Wait()
+
+	var registrations []types.Registration
+	ctSummaries := map[string]*types.CTSummary{}
+	websites := map[string]*types.WebsiteInfo{}
+	listings := map[string]*types.AftermarketInfo{}
+	var output strings.Builder
+	for i, e := range explained {
+		domain := domains[i]
+		if e.err != nil {
+			output.WriteString(fmt.Sprintf("- **%s**：WHOIS 查询失败（%v）\n", domain, e.err))
+			continue
+		}
+		registrations = append(registrations, *e.reg)
+		output.WriteString(describeRegistration(e.reg))
+		if !e.reg.Registered {
+			continue
+		}
+		// 证书透明度日志说明域名何时开始、以及多活跃地被使用；查询失败时不影响回答
+		if e.ct != nil {
+			ctSummaries[domain] = e.ct
+			output.WriteString(describeCT(e.ct))
+		}
+		// 首页是停放页或售卖页时，建议直接联系持有者
+		websites[domain] = e.site
+		output.WriteString(describeWebsite(e.site))
+		// 交易平台上有标价时直接给出价格和平台
+		listings[domain] = e.listing
+		output.WriteString(describeAftermarket(domain, e.listing))
+	}
+
+	response.Data["registrations"] = registrations
+	if len(ctSummaries) > 0 {
+		response.Data["ct"] = ctSummaries
+	}
+	if len(websites) > 0 {
+		response.Data["websites"] = websites
+	}
+	if len(listings) > 0 {
+		response.Data["aftermarket"] = listings
+	}
+	response.Message = output.String()
+}
+
+// explainTakenTimeout 解释已注册域名时整个请求的时限，包括 CT、首页和挂牌查询
+const explainTakenTimeout = 25 * time.Second
+
+// takenExplanation 一个域名的注册信息和附加查询结果
+type takenExplanation struct {
+	reg     *types.Registration
+	err     error
+	ct      *types.CTSummary // 查询失败时为空
+	site    *types.WebsiteInfo
+	listing *types.AftermarketInfo
+}
+
+// explainTaken 查询注册信息，已注册时同时查询 CT、探测首页和查询交易平台
+func explainTaken(ctx context.Context, e *takenExplanation, domain string) {
+	e.reg, e.err = scanner.LookupRegistration(domain)
+	if e.err != nil || !e.reg.Registered {
+		return
+	}
+
+	var wg sync.WaitGroup
+	var found []types.Listing
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		if summary, err := certs.DefaultCT().Search(ctx, domain); err == nil {
+			e.ct = summary
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		e.site = website.Default().Probe(ctx, domain, e.reg.NameServers)
+	}()
+	go func() {
+		defer wg.Done()
+		found = aftermarket.Lookup(ctx, domain, nil)
+	}()
+	wg.Wait()
+
+	signals := aftermarket.WHOISSignals(e.reg.Registrant)
+	e.listing = aftermarket.Summarize(found, signals, e.site)
+}
+
+func handleAlternatives(t *turn, response *types.ChatResponse) {
+	domains := t.domains()
+	if len(domains) == 0 {
+		response.Action = "clarify"
+		response.Message = "你想为哪个域名找替代？请告诉我完整域名，例如 example.com。"
+		return
+	}
+
+	original := domains[0]
+	alternatives, err := scanner.FindAlternatives(types.AlternativesRequest{Domain: original, TLDs: t.session.State.PreferredTLDs})
+	if err != nil {
+		response.Action = "clarify"
+		response.Message = fmt.Sprintf("查询替代域名时出错：%v", err)
+		return
+	}
+
+	var available []string
+	var output strings.Builder
+	for i, a := range alternatives {
+		available = append(available, a.Domain)
+		output.WriteString(fmt.Sprintf("%d. **%s** - %s，相似度 %.0f%%\n", i+1, a.Domain, variantNames[a.Variant], a.Similarity*100))
+	}
+
+	response.Data["domain"] = original
+	response.Data["domains"] = available
+	response.Data["alternatives"] = alternatives
+	if len(alternatives) == 0 {
+		response.Message = fmt.Sprintf("暂时没有找到 **%s** 的可注册替代，可以换个关键词试试。", original)
+		return
+	}
+	response.Message = fmt.Sprintf("**%s** 已被占用，这些相近的域名可以注册：\n\n%s", original, output.String())
+}
+
+func handleSocialHandles(t *turn, response *types.ChatResponse) {
+	var handles []string
+	for _, d := range t.domains() {
+		handles = append(handles, domainLabel(d))
+	}
+	if len(handles) == 0 {
+		handles = t.session.State.Keywords
+	}
+	if len(handles) == 0 {
+		response.Action = "clarify"
+		response.Message = "你想查询哪个名字的社交账号？例如“查一下 kitleaf 的 GitHub 和 X 账号”。"
+		return
+	}
+
+	results := handlecheck.CheckAll(handles, nil)
+
+	var facts strings.Builder
+	for _, h := range handles {
+		facts.WriteString(fmt.Sprintf("**%s**\n", h))
+		for _, r := range results[strings.ToLower(h)] {
+			facts.WriteString(fmt.Sprintf("- %s：%s\n", r.Platform, checkStatusNames[r.Status]))
+		}
+	}
+
+	response.Data["handles"] = handles
+	response.Data["platforms"] = handlecheck.Platforms()
+	response.Data["results"] = results
+	response.Message = "社交账号的查询结果：\n\n" + facts.String()
+}
+
+func handlePackageNames(t *turn, response *types.ChatResponse) {
+	var names []string
+	for _, d := range t.domains() {
+		names = append(names, domainLabel(d))
+	}
+	if len(names) == 0 {
+		names = t.session.State.Keywords
+	}
+	if len(names) == 0 {
+		response.Action = "clarify"
+		response.Message = "你想查询哪个名字的包名？例如“查一下 kitleaf 在 npm 和 crates.io 上能不能用”。"
+		return
+	}
+
+	results := ecosystems.CheckAll(names, nil)
+
+	var facts strings.Builder
+	for _, n := range names {
+		facts.WriteString(fmt.Sprintf("**%s**\n", n))
+		for _, r := range results[strings.ToLower(n)] {
+			facts.WriteString(fmt.Sprintf("- %s：%s\n", r.Registry, checkStatusNames[r.Status]))
+		}
+	}
+
+	response.Data["names"] = names
+	response.Data["registries"] = ecosystems.Names()
+	response.Data["results"] = results
+	response.Message = "包仓库名称的查询结果：\n\n" + facts.String()
+}
+
+// writeIdeas 把 LLM 生成的创意写入响应
+func writeIdeas(response *types.ChatResponse, ideas *llm.DomainIdeas, err error) {
+	if err != nil {
+		// 结构化输出失败，有原始文本时直接返回，否则给出默认提示
+		fmt.Printf("LLM domain ideas failed: %v\n", err)
+		response.Message = llm.RawFromError(err)
+		if response.Message == "" {
+			response.Message = "我来为你生成一些创意域名..."
+		}
+		return
+	}
+
+	var domains []string
+	var domainReasons []map[string]string
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("🤖 根据你的需求，我为你生成了 %d 个创意域名：\n\n", len(ideas.Suggestions)))
+
+	for i, s := range ideas.Suggestions {
+		output.WriteString(fmt.Sprintf("%d. **%s** - %s\n", i+1, s.Domain, s.Reason))
+		domains = append(domains, s.Domain)
+		domainReasons = append(domainReasons, map[string]string{
+			"domain": s.Domain,
+			"reason": s.Reason,
+		})
+	}
+
+	if ideas.Summary != "" {
+		output.WriteString(fmt.Sprintf("\n💡 **总结**: %s", ideas.Summary))
+	}
+
+	response.Message = output.String()
+	response.Data["domains"] = domains
+	response.Data["domainReasons"] = domainReasons
+}
+
+// describeRegistration 用一段话描述注册信息
+func describeRegistration(reg *types.Registration) string {
+	if !reg.Registered {
+		return fmt.Sprintf("- **%s**：WHOIS 显示尚未注册，可以尝试购买。\n", reg.Domain)
+	}
+
+	var details []string
+	if reg.Registrant != "" {
+		details = append(details, "持有者 "+reg.Registrant)
+	}
+	if reg.Registrar != "" {
+		details = append(details, "注册商 "+reg.Registrar)
+	}
+	if reg.CreatedAt != "" {
+		details = append(details, "注册于 "+reg.CreatedAt)
+	}
+	if reg.ExpiresAt != "" {
+		details = append(details, "到期 "+reg.ExpiresAt)
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("- **%s**：已被注册，但 WHOIS 未公开详细信息。\n", reg.Domain)
+	}
+	return fmt.Sprintf("- **%s**：已被注册，%s。\n", reg.Domain, strings.Join(details, "，"))
+}
+
+// describeCT 用一句话描述证书透明度日志中的使用情况
+func describeCT(summary *types.CTSummary) string {
+	if summary.Certificates == 0 {
+		return "  证书透明度日志中没有证书记录，域名可能没有在使用 HTTPS。\n"
+	}
+	details := []string{fmt.Sprintf("共 %d 张证书", summary.Certificates)}
+	if summary.FirstSeen != "" {
+		details = append(details, "最早 "+summary.FirstSeen[:10], "最近签发 "+summary.LastIssued[:10])
+	}
+	details = append(details, fmt.Sprintf("近 90 天签发 %d 张", summary.Last90Days))
+	if len(summary.Issuers) > 0 {
+		details = append(details, "签发者 "+strings.Join(summary.Issuers, "、"))
+	}
+	return "  证书透明度日志：" + strings.Join(details, "，") + "。\n"
+}
+
+// describeWebsite 描述首页的分类，停放和售卖时建议联系持有者
+func describeWebsite(site *types.WebsiteInfo) string {
+	provider := ""
+	if site.Provider != "" {
+		provider = "（" + site.Provider + "）"
+	}
+	switch site.Status {
+	case website.StatusForSale:
+		return "  首页是售卖落地页" + provider + "，持有者在出售这个域名，可以通过页面上的报价入口或交易平台联系询价。\n"
+	case website.StatusParked:
+		return "  首页是停放页" + provider + "，域名目前没有实际使用，持有者可能愿意出售，可以通过 WHOIS 中的联系方式或域名经纪服务询问。\n"
+	case website.StatusRedirect:
+		return "  首页跳转到 " + site.URL + "，域名被用作其它网站的入口。\n"
+	case website.StatusActive:
+		if site.Title != "" {
+			return "  首页是正常运营的网站（" + site.Title + "），购买的可能性较低。\n"
+		}
+		return "  首页是正常运营的网站，购买的可能性较低。\n"
+	}
+	return "  首页无法访问。\n"
+}
+
+// describeValue 估价区间，如"，估价 $1,200–$3,400"
+func describeValue(v *types.Valuation) string {
+	if v == nil {
+		return ""
+	}
+	return "，估价 " + aftermarket.FormatPrice(v.Low, v.Currency) + "–" + aftermarket.FormatPrice(v.High, v.Currency)
+}
+
+// describeAftermarket 描述二级市场的挂牌，如"已被注册，但在 dan 标价 $2,400 出售"
+func describeAftermarket(domain string, info *types.AftermarketInfo) string {
+	switch {
+	case info.Venue != "" && info.Price > 0:
+		return fmt.Sprintf("  **%s** 已被注册，但在 %s 标价 %s 出售：%s\n", domain, info.Venue, aftermarket.FormatPrice(info.Price, info.Currency), info.URL)
+	case info.Venue != "":
+		return fmt.Sprintf("  **%s** 已被注册，但在 %s 挂牌出售（未标价，可以报价）：%s\n", domain, info.Venue, info.URL)
+	case info.ForSale:
+		return "  交易平台上没有找到挂牌，但有出售迹象：" + strings.Join(info.Signals, "；") + "。\n"
+	}
+	return ""
+}
+
+// orderResults 按输入顺序排列检查结果
+func orderResults(domains []string, results []types.DomainResult) []types.DomainResult {
+	index := make(map[string]int, len(domains))
+	for i, d := range domains {
+		index[d] = i
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return index[results[i].Domain] < index[results[j].Domain]
+	})
+	return results
+}
+
+// numberedList 生成带序号的域名列表
+func numberedList(domains []string) string {
+	var b strings.Builder
+	for i, d := range domains {
+		b.WriteString(fmt.Sprintf("%d. **%s**\n", i+1, d))
+	}
+	return b.String()
+}
+
+// domainLabel 取域名的第一段（不含后缀）
+func domainLabel(domain string) string {
+	return strings.Split(domain, ".")[0]
+}
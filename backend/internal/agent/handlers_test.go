@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"domain-agent/backend/internal/handles"
+	"domain-agent/backend/internal/llm"
+	"domain-agent/backend/internal/namecheck"
+	"domain-agent/backend/internal/scanner/scannertest"
+	"domain-agent/backend/internal/types"
+)
+
+// useOfflineLLM 换成没有 API key 的客户端，所有 LLM 调用立即失败，走本地兜底
+func useOfflineLLM(t *testing.T) {
+	t.Setenv("VIBECODING_API_KEY", "")
+	previous := llmClient
+	SetLLMClient(llm.NewClient())
+	t.Cleanup(func() { SetLLMClient(previous) })
+}
+
+func newTurn(message string, state types.SessionState) *turn {
+	return &turn{message: message, session: &types.Session{ID: "test", State: state}}
+}
+
+func TestHandleRefineFallback(t *testing.T) {
+	useOfflineLLM(t)
+	state := types.SessionState{LastSuggestions: []string{"kitleaf.com", "kit.io", "sproutly.ai", "mint.cn"}}
+
+	response := generateResponse("refine", newTurn("再短一点", state))
+	domains, _ := response.Data["domains"].([]string)
+	if want := []string{"kit.io", "mint.cn"}; !reflect.DeepEqual(domains, want) {
+		t.Errorf("domains = %v, want %v", domains, want)
+	}
+	if !strings.Contains(response.Message, "筛选出") {
+		t.Errorf("message = %q", response.Message)
+	}
+
+	// 无法在本地执行的要求不能说成已经筛选
+	response = generateResponse("refine", newTurn("换个更科技的风格", state))
+	domains, _ = response.Data["domains"].([]string)
+	if !reflect.DeepEqual(domains, state.LastSuggestions) {
+		t.Errorf("domains = %v, want the previous list", domains)
+	}
+	if strings.Contains(response.Message, "筛选出") || !strings.Contains(response.Message, "没有做任何筛选") {
+		t.Errorf("message = %q, want it to say no filter was applied", response.Message)
+	}
+}
+
+// stubChecker 不发请求的账号检查器，记录被查询的名称
+type stubChecker struct {
+	platform string
+	taken    map[string]bool
+	checked  []string
+}
+
+func (c *stubChecker) Name() string { return c.platform }
+
+func (c *stubChecker) Check(_ context.Context, handle string) (string, error) {
+	c.checked = append(c.checked, handle)
+	if c.taken[handle] {
+		return namecheck.StatusTaken, nil
+	}
+	return namecheck.StatusAvailable, nil
+}
+
+func TestHandleSocialHandlesRunsChecks(t *testing.T) {
+	stubs := map[string]*stubChecker{}
+	for _, p := range handles.Platforms() {
+		stubs[p] = &stubChecker{platform: p, taken: map[string]bool{"kitleaf": p == "github"}}
+		handles.Register(stubs[p])
+	}
+
+	response := generateResponse("social_handles", newTurn("kitleaf.com 的社交账号还在吗", types.SessionState{}))
+	if response.Action != "check_handles" {
+		t.Fatalf("action = %s, message %q", response.Action, response.Message)
+	}
+	for p, s := range stubs {
+		if len(s.checked) != 1 || s.checked[0] != "kitleaf" {
+			t.Errorf("%s checked %v, want [kitleaf]", p, s.checked)
+		}
+	}
+	results, _ := response.Data["results"].(map[string][]types.HandleResult)
+	if len(results["kitleaf"]) != len(stubs) {
+		t.Fatalf("results = %+v", results)
+	}
+	if !strings.Contains(response.Message, "github："+checkStatusNames[namecheck.StatusTaken]) {
+		t.Errorf("message = %q", response.Message)
+	}
+}
+
+func TestHandleCheckSpecificOffersAlternatives(t *testing.T) {
+	useOfflineLLM(t)
+	scannertest.Start(t, map[string]scannertest.Domain{
+		"kitleaf.test": {NameServers: []string{"ns1.example.net"}},
+	})
+	state := types.SessionState{PreferredTLDs: []string{".test"}}
+
+	response := generateResponse("check_specific", newTurn("kitleaf.test 和 mintleaf.test 能注册吗", state))
+	results, _ := response.Data["results"].([]types.DomainResult)
+	if len(results) != 2 || results[0].Domain != "kitleaf.test" || results[0].Available || !results[1].Available {
+		t.Fatalf("results = %+v", results)
+	}
+	alternatives, _ := response.Data["alternatives"].(map[string][]types.AlternativeDomain)
+	found := alternatives["kitleaf.test"]
+	if len(found) == 0 || len(found) > autoAlternativeCount {
+		t.Fatalf("alternatives = %+v", alternatives)
+	}
+	if _, ok := alternatives["mintleaf.test"]; ok {
+		t.Errorf("alternatives searched for an available domain")
+	}
+	if !strings.Contains(response.Message, "可注册的替代："+found[0].Domain) {
+		t.Errorf("message = %q", response.Message)
+	}
+}
+
+// TestAutoAlternativesTimeout 寻找替代太慢时不再等待，先返回查询结果
+func TestAutoAlternativesTimeout(t *testing.T) {
+	useOfflineLLM(t)
+	scannertest.Start(t, map[string]scannertest.Domain{
+		"kitleaf.test": {NameServers: []string{"ns1.example.net"}},
+	})
+	release := make(chan struct{})
+	previous, previousTimeout := findAlternatives, autoAlternativesTimeout
+	findAlternatives = func(types.AlternativesRequest) ([]types.AlternativeDomain, error) {
+		<-release
+		return nil, nil
+	}
+	autoAlternativesTimeout = 50 * time.Millisecond
+	t.Cleanup(func() {
+		close(release)
+		findAlternatives, autoAlternativesTimeout = previous, previousTimeout
+	})
+
+	start := time.Now()
+	response := generateResponse("check_specific", newTurn("kitleaf.test 能注册吗", types.SessionState{}))
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("check took %v, want it to stop waiting for alternatives", elapsed)
+	}
+	if results, _ := response.Data["results"].([]types.DomainResult); len(results) != 1 || results[0].Available {
+		t.Fatalf("results = %+v", results)
+	}
+	if pending, _ := response.Data["alternatives_pending"].([]string); !reflect.DeepEqual(pending, []string{"kitleaf.test"}) {
+		t.Errorf("alternatives_pending = %v", pending)
+	}
+	if !strings.Contains(response.Message, "帮我找 kitleaf.test 的替代") {
+		t.Errorf("message = %q", response.Message)
+	}
+}
+
+// TestLocalSuggestionsUsePinyin LLM 不可用时本地生成，中文关键词要原样交给生成器，拼音策略才能运行
+func TestLocalSuggestionsUsePinyin(t *testing.T) {
+	useOfflineLLM(t)
+
+	response := generateResponse("generate_ideas", newTurn("帮我想几个阿里巴巴的域名", types.SessionState{}))
+	reasons, _ := response.Data["domainReasons"].([]map[string]string)
+	pinyin := 0
+	for _, r := range reasons {
+		if strings.HasPrefix(r["reason"], "'阿里巴巴' 的") {
+			pinyin++
+		}
+	}
+	if pinyin == 0 {
+		t.Errorf("no pinyin suggestions for a Han keyword: %v", reasons)
+	}
+}
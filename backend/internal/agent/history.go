@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"domain-agent/backend/internal/llm"
+	"domain-agent/backend/internal/types"
+)
+
+// defaultHistoryTokens 发送给 LLM 的对话历史的默认 token 预算
+const defaultHistoryTokens = 1500
+
+// historyTokenBudget 读取 AGENT_HISTORY_TOKENS，未配置时使用默认值
+func historyTokenBudget() int {
+	if v, err := strconv.Atoi(os.Getenv("AGENT_HISTORY_TOKENS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultHistoryTokens
+}
+
+// buildHistory 构建发送给 LLM 的对话历史
+//
+// 从最近的消息向前取，直到用完 token 预算；被截掉的早期对话用会话状态
+// 生成一条摘要放在最前面，保证关键词、偏好后缀和上一轮建议不会丢失。
+// 会话最后一条（即本次用户输入）不包含在内，由调用方单独传入。
+func buildHistory(session *types.Session, budget int) []llm.Message {
+	messages := session.Messages
+	if n := len(messages); n > 0 && messages[n-1].Role == "user" {
+		messages = messages[:n-1]
+	}
+
+	summary := summarizeState(session.State)
+	remaining := budget
+	if summary != "" {
+		remaining -= estimateTokens(summary)
+	}
+
+	start := len(messages)
+	for start > 0 {
+		cost := estimateTokens(messages[start-1].Content)
+		if cost > remaining {
+			break
+		}
+		remaining -= cost
+		start--
+	}
+
+	history := make([]llm.Message, 0, len(messages)-start+1)
+	if start > 0 && summary != "" {
+		history = append(history, llm.Message{Role: "system", Content: summary})
+	}
+	for _, m := range messages[start:] {
+		history = append(history, llm.Message{Role: m.Role, Content: m.Content})
+	}
+
+	return history
+}
+
+// summarizeState 将会话状态压缩成一段摘要
+func summarizeState(state types.SessionState) string {
+	var parts []string
+	if len(state.Keywords) > 0 {
+		parts = append(parts, "关键词："+strings.Join(state.Keywords, "、"))
+	}
+	if len(state.PreferredTLDs) > 0 {
+		parts = append(parts, "偏好后缀："+strings.Join(state.PreferredTLDs, "、"))
+	}
+	if len(state.LastSuggestions) > 0 {
+		var list []string
+		for i, d := range state.LastSuggestions {
+			list = append(list, fmt.Sprintf("%d. %s", i+1, d))
+		}
+		parts = append(parts, "上一轮建议的域名："+strings.Join(list, " "))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "早先对话摘要——" + strings.Join(parts, "；")
+}
+
+// estimateTokens 粗略估算 token 数：中日韩字符按 1 个计，其余按 4 个字符 1 个计
+func estimateTokens(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	// 每条消息另有约 4 个 token 的格式开销
+	return cjk + (other+3)/4 + 4
+}
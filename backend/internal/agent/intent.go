@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"domain-agent/backend/internal/intent"
+	"domain-agent/backend/internal/llm"
+)
+
+// defaultLLMThreshold 本地分类置信度低于该值时才调用 LLM
+const defaultLLMThreshold = 0.7
+
+var classifier = intent.NewClassifier()
+
+// llmThreshold 读取 INTENT_LLM_THRESHOLD，未配置时使用默认值
+func llmThreshold() float64 {
+	if v, err := strconv.ParseFloat(os.Getenv("INTENT_LLM_THRESHOLD"), 64); err == nil {
+		return v
+	}
+	return defaultLLMThreshold
+}
+
+// classifyIntent 意图识别流水线
+//
+// 先用本地分类器（正则规则 + 朴素贝叶斯）判断；置信度足够时直接返回，
+// 否则调用一次 LLM。LLM 失败或返回未知意图时沿用本地结果，不再重复请求。
+// input 是附加了指代解析结果的消息，只用于 LLM。
+func classifyIntent(message, input string, history []llm.Message, references []string) intent.Result {
+	local := classifier.Classify(message, len(references) > 0)
+	if local.Confidence >= llmThreshold() {
+		return local
+	}
+
+	analysis, err := llmClient.AnalyzeUserIntent(input, intentOptions(), history)
+	if err != nil {
+		fmt.Printf("LLM intent analysis failed, using local classifier (%s %.2f): %v\n", local.Intent, local.Confidence, err)
+		return local
+	}
+
+	if _, exists := intentHandlers[analysis.Intent]; !exists {
+		return local
+	}
+
+	confidence := analysis.Confidence
+	if confidence <= 0 || confidence > 1 {
+		confidence = local.Confidence
+	}
+
+	return intent.Result{
+		Intent:     analysis.Intent,
+		Confidence: confidence,
+		Scores:     local.Scores,
+		Source:     intent.SourceLLM,
+	}
+}
@@ -0,0 +1,43 @@
+package agent
+
+import "sync"
+
+// sessionLocks 按会话加锁：不同会话并行处理，同一会话内的消息按到达顺序串行
+type sessionLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sessionLock
+}
+
+type sessionLock struct {
+	mu   sync.Mutex
+	refs int // 持有或等待该锁的请求数，为 0 时从表中移除
+}
+
+func newSessionLocks() *sessionLocks {
+	return &sessionLocks{locks: make(map[string]*sessionLock)}
+}
+
+// Lock 锁定会话，返回解锁函数
+func (l *sessionLocks) Lock(id string) func() {
+	l.mu.Lock()
+	lock, exists := l.locks[id]
+	if !exists {
+		lock = &sessionLock{}
+		l.locks[id] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.mu.Lock()
+
+	return func() {
+		lock.mu.Unlock()
+
+		l.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, id)
+		}
+		l.mu.Unlock()
+	}
+}
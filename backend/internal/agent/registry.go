@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"domain-agent/backend/internal/intent"
+	"domain-agent/backend/internal/llm"
+	"domain-agent/backend/internal/types"
+)
+
+// IntentHandler 意图定义：处理函数、默认动作和 Data 的模式
+//
+// 新意图通过 RegisterIntent 注册后，会同时出现在 LLM 的候选列表中，
+// Examples 会追加到本地分类器的训练数据。
+type IntentHandler struct {
+	Name        string      // 意图名称
+	Action      string      // 返回给前端的默认动作
+	Description string      // 供 LLM 判断意图的说明
+	Examples    []string    // 本地分类器训练样例
+	Schema      *llm.Schema // ChatResponse.Data 的模式，为空时不校验
+	Suggests    bool        // Data["domains"] 是否为新的建议列表（用于“第三个”等指代）
+	Handle      func(t *turn, response *types.ChatResponse)
+}
+
+// turn 一轮对话的上下文
+type turn struct {
+	message    string
+	session    *types.Session
+	history    []llm.Message
+	references []string
+}
+
+var (
+	intentHandlers = map[string]*IntentHandler{}
+	intentOrder    []string
+)
+
+// RegisterIntent 注册意图，同名意图会被覆盖；应在 init 中调用
+func RegisterIntent(h *IntentHandler) {
+	if _, exists := intentHandlers[h.Name]; !exists {
+		intentOrder = append(intentOrder, h.Name)
+	}
+	intentHandlers[h.Name] = h
+
+	for _, example := range h.Examples {
+		classifier.Train(h.Name, example)
+	}
+}
+
+// intentOptions 返回供 LLM 选择的意图列表
+func intentOptions() []llm.IntentOption {
+	options := make([]llm.IntentOption, 0, len(intentOrder))
+	for _, name := range intentOrder {
+		options = append(options, llm.IntentOption{Name: name, Description: intentHandlers[name].Description})
+	}
+	return options
+}
+
+// generateResponse 按意图分发到对应的处理函数，未知意图按 general 处理
+func generateResponse(intentName string, t *turn) *types.ChatResponse {
+	h, exists := intentHandlers[intentName]
+	if !exists {
+		intentName = intent.General
+		h = intentHandlers[intentName]
+	}
+
+	response := &types.ChatResponse{
+		SessionID: t.session.ID,
+		Intent:    intentName,
+		Action:    h.Action,
+		Timestamp: time.Now(),
+		Data:      make(map[string]interface{}),
+	}
+
+	h.Handle(t, response)
+
+	if h.Schema != nil && response.Action == h.Action {
+		if err := validateData(h.Schema, response.Data); err != nil {
+			fmt.Printf("Intent %s returned data not matching its schema: %v\n", intentName, err)
+		}
+	}
+
+	return response
+}
+
+// validateData 按模式校验响应数据
+func validateData(schema *llm.Schema, data map[string]interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return schema.Validate(raw)
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"domain-agent/backend/internal/types"
+)
+
+var (
+	tldPattern = regexp.MustCompile(`(?i)(?:^|[\s,，、])(\.[a-z]{2,12})\b`)
+
+	// 序数指代：第三个 / 第3个 / #3 / the third one / 3rd option
+	//
+	// 序数后必须跟量词或名词，"第一次"、"first of all" 等普通说法不是指代。
+	ordinalZhPattern  = regexp.MustCompile(`第\s*([0-9一二三四五六七八九十]+)\s*(?:个|条|项|款|域名)`)
+	ordinalNumPattern = regexp.MustCompile(`(?i)(?:#|\bno\.\s*)([0-9]+)|\b([0-9]+)(?:st|nd|rd|th)\s+(?:one|option|domain|name|suggestion)s?\b`)
+	ordinalEnPattern  = regexp.MustCompile(`(?i)\b(first|second|third|fourth|fifth|sixth|seventh|eighth|ninth|tenth)\s+(?:one|option|domain|name|suggestion)s?\b`)
+	lastPattern       = regexp.MustCompile(`(?i)最后一个|\blast one\b`)
+	allPattern        = regexp.MustCompile(`(?i)这些|它们|都查|全部|\ball of them\b|\bthem all\b|\bthese\b`)
+)
+
+var englishOrdinals = map[string]int{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5,
+	"sixth": 6, "seventh": 7, "eighth": 8, "ninth": 9, "tenth": 10,
+}
+
+var chineseDigits = map[rune]int{
+	'一': 1, '二': 2, '三': 3, '四': 4, '五': 5,
+	'六': 6, '七': 7, '八': 8, '九': 9, '十': 10,
+}
+
+// updateState 根据本轮对话更新会话状态
+func updateState(session *types.Session, message string, response *types.ChatResponse) {
+	state := &session.State
+
+	if tlds := extractTLDs(message); len(tlds) > 0 {
+		state.PreferredTLDs = tlds
+	}
+
+	if keywords, ok := response.Data["keywords"].([]string); ok && len(keywords) > 0 {
+		state.Keywords = keywords
+	}
+
+	if h, exists := intentHandlers[response.Intent]; exists && h.Suggests && response.Action == h.Action {
+		if domains, ok := response.Data["domains"].([]string); ok && len(domains) > 0 {
+			state.LastSuggestions = domains
+		}
+	}
+}
+
+// extractTLDs 提取消息中提到的后缀（如 “.ai 和 .io”）
+func extractTLDs(message string) []string {
+	var tlds []string
+	seen := map[string]bool{}
+	for _, m := range tldPattern.FindAllStringSubmatch(message, -1) {
+		tld := strings.ToLower(m[1])
+		if !seen[tld] {
+			seen[tld] = true
+			tlds = append(tlds, tld)
+		}
+	}
+	return tlds
+}
+
+// resolveReferences 把“第三个”“最后一个”“这些”等指代解析为上一轮建议中的域名
+func resolveReferences(message string, state types.SessionState) []string {
+	list := state.LastSuggestions
+	if len(list) == 0 {
+		return nil
+	}
+
+	var indexes []int
+	for _, m := range ordinalZhPattern.FindAllStringSubmatch(message, -1) {
+		indexes = append(indexes, parseOrdinal(m[1]))
+	}
+	for _, m := range ordinalNumPattern.FindAllStringSubmatch(message, -1) {
+		n, _ := strconv.Atoi(m[1] + m[2])
+		indexes = append(indexes, n)
+	}
+	for _, m := range ordinalEnPattern.FindAllStringSubmatch(message, -1) {
+		indexes = append(indexes, englishOrdinals[strings.ToLower(m[1])])
+	}
+	if lastPattern.MatchString(message) {
+		indexes = append(indexes, len(list))
+	}
+
+	var domains []string
+	seen := map[string]bool{}
+	for _, i := range indexes {
+		if i >= 1 && i <= len(list) && !seen[list[i-1]] {
+			seen[list[i-1]] = true
+			domains = append(domains, list[i-1])
+		}
+	}
+
+	if len(domains) == 0 && allPattern.MatchString(message) {
+		domains = append(domains, list...)
+	}
+
+	return domains
+}
+
+// parseOrdinal 解析阿拉伯数字或简单中文数字（一到九十九）
+func parseOrdinal(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+
+	runes := []rune(s)
+	switch {
+	case len(runes) == 1:
+		return chineseDigits[runes[0]]
+	case len(runes) == 2 && runes[0] == '十':
+		return 10 + chineseDigits[runes[1]]
+	case len(runes) == 2 && runes[1] == '十':
+		return chineseDigits[runes[0]] * 10
+	case len(runes) == 3 && runes[1] == '十':
+		return chineseDigits[runes[0]]*10 + chineseDigits[runes[2]]
+	}
+	return 0
+}
+
+// annotateReferences 在发给 LLM 的输入后补充指代解析结果
+func annotateReferences(message string, domains []string) string {
+	if len(domains) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s\n（此处指代的域名：%s）", message, strings.Join(domains, ", "))
+}
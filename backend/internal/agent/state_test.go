@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"domain-agent/backend/internal/types"
+)
+
+func TestResolveReferences(t *testing.T) {
+	state := types.SessionState{LastSuggestions: []string{"kit.com", "leaf.io", "mint.ai", "sprout.cn"}}
+	tests := []struct {
+		message string
+		want    []string
+	}{
+		{"第三个怎么样", []string{"mint.ai"}},
+		{"查一下第2个和第 4 个", []string{"leaf.io", "sprout.cn"}},
+		{"第一个域名还能注册吗", []string{"kit.com"}},
+		{"看看 #2", []string{"leaf.io"}},
+		{"I like the second one", []string{"leaf.io"}},
+		{"the 3rd option please", []string{"mint.ai"}},
+		{"最后一个呢", []string{"sprout.cn"}},
+		{"这些都查一下", []string{"kit.com", "leaf.io", "mint.ai", "sprout.cn"}},
+		{"第十个", nil},
+		// 普通说法里的序数词不是指代
+		{"我第一次给公司起名", nil},
+		{"first, I want something short", nil},
+		{"the 3rd party tools", nil},
+		{"第二次换个风格", nil},
+	}
+	for _, tt := range tests {
+		if got := resolveReferences(tt.message, state); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("resolveReferences(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestParseOrdinal(t *testing.T) {
+	for s, want := range map[string]int{"3": 3, "三": 3, "十": 10, "十二": 12, "二十": 20, "三十五": 35, "百": 0} {
+		if got := parseOrdinal(s); got != want {
+			t.Errorf("parseOrdinal(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestExtractTLDs(t *testing.T) {
+	got := extractTLDs("只要 .AI 和 .io，.ai 也行")
+	if want := []string{".ai", ".io"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("extractTLDs = %v, want %v", got, want)
+	}
+}
+
+func TestBuildHistory(t *testing.T) {
+	session := &types.Session{
+		State: types.SessionState{Keywords: []string{"咖啡"}, LastSuggestions: []string{"kit.com"}},
+	}
+	for i := 0; i < 20; i++ {
+		session.Messages = append(session.Messages,
+			types.Message{Role: "user", Content: strings.Repeat("起名", 20)},
+			types.Message{Role: "assistant", Content: strings.Repeat("建议", 20)})
+	}
+	session.Messages = append(session.Messages, types.Message{Role: "user", Content: "本次输入"})
+
+	history := buildHistory(session, 200)
+	if len(history) < 2 || history[0].Role != "system" || !strings.Contains(history[0].Content, "kit.com") {
+		t.Fatalf("history = %+v, want state summary first", history)
+	}
+	total := 0
+	for _, m := range history {
+		total += estimateTokens(m.Content)
+		if m.Content == "本次输入" {
+			t.Error("history includes the current message")
+		}
+	}
+	if total > 200 {
+		t.Errorf("history uses %d tokens, budget 200", total)
+	}
+	if last := history[len(history)-1]; last.Role != "assistant" {
+		t.Errorf("last message = %+v, want the latest reply", last)
+	}
+
+	// 全部放得下时不需要摘要
+	short := &types.Session{State: session.State, Messages: session.Messages[len(session.Messages)-3:]}
+	if h := buildHistory(short, 1500); len(h) != 2 || h[0].Role == "system" {
+		t.Errorf("short history = %+v", h)
+	}
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"domain-agent/backend/internal/agent"
+	"domain-agent/backend/internal/store"
 	"domain-agent/backend/internal/types"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -12,7 +14,9 @@ func RegisterAgentRoutes(r *gin.RouterGroup) {
 	agentGroup := r.Group("/agent")
 	{
 		agentGroup.POST("/chat", handleChat)
+		agentGroup.GET("/sessions", handleListSessions)
 		agentGroup.GET("/session/:id", handleGetSession)
+		agentGroup.DELETE("/session/:id", handleDeleteSession)
 		agentGroup.GET("/stream", handleStream)
 	}
 }
@@ -45,14 +49,49 @@ func handleGetSession(c *gin.Context) {
 	sessionID := c.Param("id")
 	
 	session, err := agent.GetSession(sessionID)
-	if err != nil {
+	if errors.Is(err, store.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, session)
 }
 
+// handleListSessions 列出会话
+func handleListSessions(c *gin.Context) {
+	sessions, err := agent.ListSessions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"total":    len(sessions),
+	})
+}
+
+// handleDeleteSession 删除会话
+func handleDeleteSession(c *gin.Context) {
+	sessionID := c.Param("id")
+
+	err := agent.DeleteSession(sessionID)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": sessionID})
+}
+
 // handleStream WebSocket 流式响应
 func handleStream(c *gin.Context) {
 	agent.HandleWebSocket(c.Writer, c.Request)
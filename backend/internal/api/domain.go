@@ -1,7 +1,12 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"domain-agent/backend/internal/aftermarket"
+	"domain-agent/backend/internal/ecosystems"
+	"domain-agent/backend/internal/handles"
+	"domain-agent/backend/internal/history"
 	"domain-agent/backend/internal/scanner"
 	"domain-agent/backend/internal/types"
 	"github.com/gin-gonic/gin"
@@ -12,6 +17,15 @@ func RegisterDomainRoutes(r *gin.RouterGroup) {
 	{
 		domainGroup.POST("/check", handleCheckDomains)
 		domainGroup.POST("/suggest", handleSuggestDomains)
+		domainGroup.GET("/strategies", handleListStrategies)
+		domainGroup.POST("/alternatives", handleAlternatives)
+		domainGroup.GET("/platforms", handleListPlatforms)
+		domainGroup.GET("/registries", handleListRegistries)
+		domainGroup.GET("/marketplaces", handleListMarketplaces)
+		domainGroup.GET("/:domain/history", handleDomainHistory)
+		domainGroup.GET("/:domain/whois", handleDomainWHOIS)
+		domainGroup.GET("/:domain/rdap", handleDomainRDAP)
+		domainGroup.GET("/:domain/dns", handleDomainDNS)
 	}
 }
 
@@ -23,9 +37,34 @@ func handleCheckDomains(c *gin.Context) {
 		return
 	}
 
-	results, err := scanner.CheckDomains(req.Domains)
+	if err := handles.ValidatePlatforms(req.Platforms); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ecosystems.Validate(req.Registries); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := aftermarket.Validate(req.Marketplaces); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := scanner.CheckDomainsWith(req.Domains, scanner.CheckOptions{
+		Handles:    req.Handles,
+		Platforms:  req.Platforms,
+		Ecosystems: req.Ecosystems,
+		Registries: req.Registries,
+		CT:         req.CT,
+		Website:    req.Website,
+
+		Aftermarket:  req.Aftermarket,
+		Marketplaces: req.Marketplaces,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(checkError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -43,6 +82,24 @@ func handleSuggestDomains(c *gin.Context) {
 		return
 	}
 
+	if err := scanner.ValidateStrategies(req.Strategies); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.OnlyAvailable {
+		suggestions, err := scanner.FindAvailableSuggestions(req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"suggestions": suggestions,
+			"count":       len(suggestions),
+		})
+		return
+	}
+
 	suggestions := scanner.GenerateSuggestions(req)
 
 	c.JSON(http.StatusOK, gin.H{
@@ -50,3 +107,126 @@ func handleSuggestDomains(c *gin.Context) {
 		"count":       len(suggestions),
 	})
 }
+
+// handleListStrategies 列出可用的域名生成策略
+func handleListStrategies(c *gin.Context) {
+	strategies := []gin.H{}
+	for _, s := range scanner.Strategies() {
+		strategies = append(strategies, gin.H{
+			"name":        s.Name(),
+			"description": s.Description(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"strategies": strategies})
+}
+
+// handleListPlatforms 列出可检查账号的平台
+func handleListPlatforms(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"platforms": handles.Platforms()})
+}
+
+// handleListRegistries 列出可检查名称冲突的包仓库
+func handleListRegistries(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"registries": ecosystems.Names()})
+}
+
+// handleListMarketplaces 列出可查询挂牌的域名交易平台
+func handleListMarketplaces(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"marketplaces": aftermarket.Names()})
+}
+
+// handleDomainHistory 域名注册信息的历史快照，以及注册商、名称服务器、到期时间等变化
+//
+// 先为还没有快照或快照已过期的来源查询 RDAP 和 WHOIS，refresh=true 时两者都重新查询。
+func handleDomainHistory(c *gin.Context) {
+	domain, ok := domainParam(c)
+	if !ok {
+		return
+	}
+
+	response := gin.H{}
+	if err := scanner.RecordRegistration(domain, c.Query("refresh") == "true"); err != nil {
+		response["refresh_error"] = err.Error()
+	}
+	snapshots, changes, err := history.Default().History(domain)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response["domain"] = domain
+	response["snapshots"] = snapshots
+	response["changes"] = changes
+	c.JSON(http.StatusOK, response)
+}
+
+// handleDomainWHOIS WHOIS 原始文本，以及检查域名时对它的判断和解析结果
+//
+// 结果会缓存一段时间，refresh=true 时重新查询。下面的 RDAP 和 DNS 接口相同。
+func handleDomainWHOIS(c *gin.Context) {
+	domain, ok := domainParam(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, scanner.LookupWHOIS(domain, c.Query("refresh") == "true"))
+}
+
+// handleDomainRDAP RDAP 原始响应，以及检查域名时对它的判断和解析结果
+func handleDomainRDAP(c *gin.Context) {
+	domain, ok := domainParam(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, scanner.LookupRDAP(domain, c.Query("refresh") == "true"))
+}
+
+// handleDomainDNS 后缀服务器的委派回答、上游解析器的记录和对应的证据
+func handleDomainDNS(c *gin.Context) {
+	domain, ok := domainParam(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, scanner.LookupDNS(domain, c.Query("refresh") == "true"))
+}
+
+// domainParam 读取并规范化路径中的域名，不是有效主机名时返回 400
+//
+// 域名会原样写进 WHOIS 查询行和 RDAP 地址，必须在任何查询之前拒绝换行、空格等字符。
+func domainParam(c *gin.Context) (string, bool) {
+	domain, err := scanner.Hostname(c.Param("domain"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return domain, true
+}
+
+// checkError 检查出错时的状态码：请求中的域名无效为 400，其余为 500
+func checkError(err error) int {
+	if errors.Is(err, scanner.ErrInvalidDomain) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// handleAlternatives 为已注册的域名寻找可注册的替代
+func handleAlternatives(c *gin.Context) {
+	var req types.AlternativesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alternatives, err := scanner.FindAlternatives(req)
+	if err != nil {
+		c.JSON(checkError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"domain":       req.Domain,
+		"alternatives": alternatives,
+		"count":        len(alternatives),
+	})
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"domain-agent/backend/internal/evidence"
+	"domain-agent/backend/internal/history"
+	"domain-agent/backend/internal/scanner/scannertest"
+	"domain-agent/backend/internal/store"
+	"domain-agent/backend/internal/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newEngine 只注册域名路由的 gin 引擎
+func newEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	RegisterDomainRoutes(engine.Group("/api"))
+	return engine
+}
+
+// serve 发送请求，成功时解码响应
+func serve[T any](t *testing.T, engine *gin.Engine, req *http.Request) (T, int) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	var v T
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+			t.Fatalf("%s %s: %v", req.Method, req.URL, err)
+		}
+	}
+	return v, rec.Code
+}
+
+// get 请求接口并解码响应
+func get[T any](t *testing.T, engine *gin.Engine, path string) (T, int) {
+	t.Helper()
+	return serve[T](t, engine, httptest.NewRequest(http.MethodGet, path, nil))
+}
+
+// post 以 JSON 提交请求并解码响应
+func post[T any](t *testing.T, engine *gin.Engine, path string, body any) (T, int) {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	return serve[T](t, engine, req)
+}
+
+func TestDomainHistory(t *testing.T) {
+	tld := scannertest.Start(t, map[string]scannertest.Domain{
+		"taken.test": {NameServers: []string{"ns1.example.net"}, Registrar: "Example Registrar"},
+	})
+	previous := history.Default()
+	history.SetDefault(history.New(store.NewMemoryHistory()))
+	t.Cleanup(func() { history.SetDefault(previous) })
+	engine := newEngine()
+
+	type historyBody struct {
+		Domain    string                       `json:"domain"`
+		Snapshots []types.RegistrationSnapshot `json:"snapshots"`
+		Changes   []types.RegistrationChange   `json:"changes"`
+		Error     string                       `json:"refresh_error"`
+	}
+	// 还没有快照：查询 RDAP 和 WHOIS 各记录一份
+	if body, code := get[historyBody](t, engine, "/api/domains/Taken.Test/history"); code != http.StatusOK || body.Domain != "taken.test" ||
+		len(body.Snapshots) != 2 || body.Snapshots[0].Registrar != "Example Registrar" || body.Error != "" {
+		t.Fatalf("first GET: HTTP %d, %+v", code, body)
+	}
+
+	// 快照新鲜时不查询网络，refresh=true 时重新查询
+	for _, tt := range []struct {
+		path    string
+		queries int
+	}{
+		{"/api/domains/taken.test/history", 1},
+		{"/api/domains/taken.test/history?refresh=true", 2},
+	} {
+		if _, code := get[gin.H](t, engine, tt.path); code != http.StatusOK {
+			t.Errorf("GET %s: HTTP %d", tt.path, code)
+		}
+		for _, source := range []string{scannertest.SourceRDAP, scannertest.SourceWHOIS} {
+			if n := tld.Queries(source, "taken.test"); n != tt.queries {
+				t.Errorf("GET %s: %d %s queries, want %d", tt.path, n, source, tt.queries)
+			}
+		}
+	}
+
+	type platformsBody struct {
+		Platforms []string `json:"platforms"`
+	}
+	if platforms, code := get[platformsBody](t, engine, "/api/domains/platforms"); code != http.StatusOK || len(platforms.Platforms) == 0 {
+		t.Errorf("GET platforms: HTTP %d, %+v", code, platforms)
+	}
+	if _, code := get[gin.H](t, engine, "/api/domains/localhost/history"); code != http.StatusBadRequest {
+		t.Errorf("GET localhost/history: HTTP %d, want 400", code)
+	}
+}
+
+func TestRawLookups(t *testing.T) {
+	tld := scannertest.Start(t, map[string]scannertest.Domain{
+		"taken.test": {NameServers: []string{"ns1.hosting.net", "ns2.hosting.net"}, Addresses: []string{"192.0.2.10"}, MX: []string{"mail.taken.test"},
+			Registrar: "Example Registrar, Inc.", Registrant: "This domain is for sale", Status: []string{"client transfer prohibited"}},
+		"busy.test": {NameServers: []string{"ns1.hosting.net"}, RateLimited: true},
+	})
+	engine := newEngine()
+
+	t.Run("whois", func(t *testing.T) {
+		w, code := get[types.WHOISLookup](t, engine, "/api/domains/Taken.Test./whois")
+		reg := w.Registration
+		if code != http.StatusOK || w.Domain != "taken.test" || !strings.Contains(w.Raw, "Registry Expiry Date") ||
+			w.Evidence.Indicates != evidence.Taken || w.Evidence.Weight >= 0 || w.Cached ||
+			reg == nil || reg.Registrar != "Example Registrar, Inc." || len(reg.NameServers) != 2 || reg.Status[0] != "clienttransferprohibited" {
+			t.Errorf("taken.test: HTTP %d evidence=%+v registration=%+v", code, w.Evidence, reg)
+		}
+		if len(w.AftermarketSignals) == 0 {
+			t.Error("taken.test: no aftermarket signals for a for-sale registrant")
+		}
+
+		w, _ = get[types.WHOISLookup](t, engine, "/api/domains/taken.test/whois")
+		if !w.Cached || tld.Queries(scannertest.SourceWHOIS, "taken.test") != 1 {
+			t.Errorf("second query: cached=%v queries=%d, want cached", w.Cached, tld.Queries(scannertest.SourceWHOIS, "taken.test"))
+		}
+		w, _ = get[types.WHOISLookup](t, engine, "/api/domains/taken.test/whois?refresh=true")
+		if w.Cached || tld.Queries(scannertest.SourceWHOIS, "taken.test") != 2 {
+			t.Errorf("refresh: cached=%v queries=%d", w.Cached, tld.Queries(scannertest.SourceWHOIS, "taken.test"))
+		}
+
+		w, _ = get[types.WHOISLookup](t, engine, "/api/domains/free.test/whois")
+		if w.Evidence.Indicates != evidence.Available || w.Evidence.Weight <= 0 || w.Registration == nil || w.Registration.Registered {
+			t.Errorf("free.test: evidence=%+v registration=%+v", w.Evidence, w.Registration)
+		}
+
+		// 无法判断的结果不缓存
+		w, _ = get[types.WHOISLookup](t, engine, "/api/domains/busy.test/whois")
+		w, _ = get[types.WHOISLookup](t, engine, "/api/domains/busy.test/whois")
+		if w.Evidence.Indicates != evidence.Unknown || w.Cached || tld.Queries(scannertest.SourceWHOIS, "busy.test") != 2 {
+			t.Errorf("busy.test: evidence=%+v cached=%v queries=%d", w.Evidence, w.Cached, tld.Queries(scannertest.SourceWHOIS, "busy.test"))
+		}
+	})
+
+	t.Run("rdap", func(t *testing.T) {
+		r, code := get[types.RDAPLookup](t, engine, "/api/domains/taken.test/rdap")
+		var raw struct {
+			ObjectClassName string `json:"objectClassName"`
+		}
+		json.Unmarshal(r.Raw, &raw)
+		if code != http.StatusOK || raw.ObjectClassName != "domain" || !strings.HasSuffix(r.Server, "/rdap/") ||
+			r.Evidence.Indicates != evidence.Taken || r.Registration == nil || r.Registration.Registrar != "Example Registrar, Inc." ||
+			r.Registration.ExpiresAt != "2030-01-01T00:00:00Z" {
+			t.Errorf("taken.test: HTTP %d server=%s evidence=%+v registration=%+v", code, r.Server, r.Evidence, r.Registration)
+		}
+		r, _ = get[types.RDAPLookup](t, engine, "/api/domains/taken.test/rdap")
+		if !r.Cached || tld.Queries(scannertest.SourceRDAP, "taken.test") != 1 {
+			t.Errorf("second query: cached=%v queries=%d", r.Cached, tld.Queries(scannertest.SourceRDAP, "taken.test"))
+		}
+
+		r, _ = get[types.RDAPLookup](t, engine, "/api/domains/free.test/rdap")
+		if r.Evidence.Indicates != evidence.Available || r.Raw != nil || r.Registration != nil {
+			t.Errorf("free.test: evidence=%+v", r.Evidence)
+		}
+		r, _ = get[types.RDAPLookup](t, engine, "/api/domains/busy.test/rdap")
+		r, _ = get[types.RDAPLookup](t, engine, "/api/domains/busy.test/rdap")
+		if r.Evidence.Indicates != evidence.Unknown || !strings.Contains(r.Evidence.Detail, "429") || tld.Queries(scannertest.SourceRDAP, "busy.test") != 2 {
+			t.Errorf("busy.test: evidence=%+v queries=%d", r.Evidence, tld.Queries(scannertest.SourceRDAP, "busy.test"))
+		}
+	})
+
+	t.Run("dns", func(t *testing.T) {
+		d, code := get[types.DNSLookup](t, engine, "/api/domains/taken.test/dns")
+		if code != http.StatusOK || d.Delegation == nil || !d.Delegation.Referral || len(d.Delegation.NameServers) != 2 {
+			t.Errorf("taken.test: HTTP %d delegation=%+v", code, d.Delegation)
+		}
+		answers := map[string]int{}
+		for _, a := range d.Answers {
+			answers[a.Type] = len(a.Records)
+		}
+		if answers["NS"] != 2 || answers["A"] != 1 || answers["AAAA"] != 0 || answers["MX"] != 1 {
+			t.Errorf("taken.test: answers %v", answers)
+		}
+		signals := map[string]string{}
+		for _, ev := range d.Evidence {
+			signals[ev.Signal] = ev.Indicates
+		}
+		for _, signal := range []string{evidence.SignalDelegation, evidence.SignalDNSNS, evidence.SignalDNSA, evidence.SignalDNSMX} {
+			if signals[signal] != evidence.Taken {
+				t.Errorf("taken.test: %s = %q, want taken", signal, signals[signal])
+			}
+		}
+		if d, _ = get[types.DNSLookup](t, engine, "/api/domains/taken.test/dns"); !d.Cached {
+			t.Error("taken.test: second query not cached")
+		}
+
+		d, _ = get[types.DNSLookup](t, engine, "/api/domains/free.test/dns")
+		if d.Delegation == nil || d.Delegation.Rcode != "NXDOMAIN" || len(d.Evidence) != 1 || d.Evidence[0].Indicates != evidence.Available ||
+			d.Answers[0].Rcode != "NXDOMAIN" {
+			t.Errorf("free.test: delegation=%+v evidence=%+v", d.Delegation, d.Evidence)
+		}
+	})
+
+	// 查询结果同样记入注册信息历史
+	snapshots, _, _ := history.Default().History("taken.test")
+	sources := map[string]bool{}
+	for _, s := range snapshots {
+		sources[s.Source] = true
+	}
+	if !sources[history.SourceWHOIS] || !sources[history.SourceRDAP] || !sources[history.SourceDelegation] {
+		t.Errorf("snapshots %+v, want whois, rdap and delegation", snapshots)
+	}
+}
+
+func TestDomainParam(t *testing.T) {
+	tld := scannertest.Start(t, map[string]scannertest.Domain{
+		"taken.test": {NameServers: []string{"ns1.hosting.net"}},
+	})
+	engine := newEngine()
+
+	// 换行会把第二行带进 WHOIS 查询：在任何查询之前拒绝
+	for _, path := range []string{
+		"/api/domains/taken.test%0d%0aevil.test/whois",
+		"/api/domains/taken.test%20evil.test/rdap",
+		"/api/domains/localhost/whois",
+		"/api/domains/-taken.test/dns",
+		"/api/domains/taken..test/history",
+		"/api/domains/under_score.test/whois",
+	} {
+		if _, code := get[gin.H](t, engine, path); code != http.StatusBadRequest {
+			t.Errorf("GET %s: HTTP %d, want 400", path, code)
+		}
+	}
+
+	// 批量检查和寻找替代同样在查询之前拒绝
+	for _, tt := range []struct {
+		path string
+		body any
+	}{
+		{"/api/domains/check", types.CheckDomainsRequest{Domains: []string{"free.test", "taken.test\r\nevil.test"}}},
+		{"/api/domains/check", types.CheckDomainsRequest{Domains: []string{"taken.test/../evil"}}},
+		{"/api/domains/alternatives", types.AlternativesRequest{Domain: "taken.test\r\nevil.test"}},
+		{"/api/domains/alternatives", types.AlternativesRequest{Domain: "a/b.test"}},
+	} {
+		if _, code := post[gin.H](t, engine, tt.path, tt.body); code != http.StatusBadRequest {
+			t.Errorf("POST %s %+v: HTTP %d, want 400", tt.path, tt.body, code)
+		}
+	}
+	for _, source := range []string{scannertest.SourceWHOIS, scannertest.SourceRDAP, scannertest.SourceDelegation, scannertest.SourceDNS} {
+		for _, domain := range []string{"taken.test", "free.test"} {
+			if n := tld.Queries(source, domain); n != 0 {
+				t.Errorf("invalid requests reached %s for %s (%d queries)", source, domain, n)
+			}
+		}
+	}
+}
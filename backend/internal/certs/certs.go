@@ -0,0 +1,88 @@
+// Package certs 读取域名的 TLS 证书并查询证书透明度（CT）日志
+//
+// 探测时先不校验证书完成握手（自签名、过期的证书同样说明域名在使用），
+// 再分别验证证书链和主机名，记录是否受信任、是否适用于该域名以及失败原因。
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"time"
+
+	"domain-agent/backend/internal/types"
+)
+
+// dialTimeout TLS 握手超时
+const dialTimeout = 5 * time.Second
+
+// Inspect 连接 addr（host:port）完成 TLS 握手，返回 host 的证书信息
+//
+// roots 为空时使用系统根证书。
+func Inspect(ctx context.Context, host, addr string, roots *x509.CertPool) (*types.CertificateInfo, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: dialTimeout},
+		Config: &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: true, // 证书在下面单独验证
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, nil
+	}
+	return Describe(host, state.PeerCertificates, roots), nil
+}
+
+// Describe 描述证书链中的叶子证书，并验证它能否链到受信任的根证书
+//
+// 证书链的验证不带主机名：是否适用于 host 只记在 Matches 中，共享主机上其它域名的
+// 有效证书仍是受信任的。
+func Describe(host string, chain []*x509.Certificate, roots *x509.CertPool) *types.CertificateInfo {
+	leaf := chain[0]
+	info := &types.CertificateInfo{
+		Subject:   leaf.Subject.CommonName,
+		SANs:      leaf.DNSNames,
+		Issuer:    issuerName(leaf),
+		NotBefore: leaf.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:  leaf.NotAfter.UTC().Format(time.RFC3339),
+		Serial:    leaf.SerialNumber.Text(16),
+		Matches:   leaf.VerifyHostname(host) == nil,
+	}
+	for _, ip := range leaf.IPAddresses {
+		info.SANs = append(info.SANs, ip.String())
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		info.VerifyError = err.Error()
+	} else {
+		info.Trusted = true
+	}
+	return info
+}
+
+// issuerName 签发者的组织名称，没有时用通用名称
+func issuerName(cert *x509.Certificate) string {
+	if len(cert.Issuer.Organization) > 0 {
+		if cert.Issuer.CommonName != "" {
+			return cert.Issuer.Organization[0] + " (" + cert.Issuer.CommonName + ")"
+		}
+		return cert.Issuer.Organization[0]
+	}
+	return cert.Issuer.CommonName
+}
@@ -0,0 +1,130 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newCA 生成本地根证书
+func newCA(t *testing.T) (*ecdsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key := mustKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Stand-in Root CA", Organization: []string{"Stand-in Trust"}},
+		NotBefore:             time.Now().Add(-24 * time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse CA: %v", err)
+	}
+	return key, cert
+}
+
+// newLeaf 用根证书签发叶子证书
+func newLeaf(t *testing.T, caKey *ecdsa.PrivateKey, ca *x509.Certificate, serial int64, names []string, notBefore, notAfter time.Time) tls.Certificate {
+	t.Helper()
+	key := mustKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create leaf: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func mustKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+func TestInspect(t *testing.T) {
+	caKey, ca := newCA(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	now := time.Now()
+	valid := newLeaf(t, caKey, ca, 2, []string{"taken.test", "www.taken.test"}, now.Add(-time.Hour), now.Add(90*24*time.Hour))
+	expired := newLeaf(t, caKey, ca, 3, []string{"old.test"}, now.Add(-400*24*time.Hour), now.Add(-10*24*time.Hour))
+
+	tests := []struct {
+		name, host string
+		cert       tls.Certificate
+		roots      *x509.CertPool
+		matches    bool
+		trusted    bool
+	}{
+		{"valid cert", "taken.test", valid, roots, true, true},
+		// 共享主机上其它域名的有效证书：链可信，名称不匹配
+		{"other host", "other.test", valid, roots, false, true},
+		{"unknown authority", "taken.test", valid, nil, true, false},
+		{"expired cert", "old.test", expired, roots, true, false},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+		srv.TLS = &tls.Config{Certificates: []tls.Certificate{tt.cert}}
+		srv.Config.ErrorLog = log.New(io.Discard, "", 0) // 探测握手后立即断开，不记录
+		srv.StartTLS()
+		info, err := Inspect(context.Background(), tt.host, srv.Listener.Addr().String(), tt.roots)
+		srv.Close()
+		if err != nil || info == nil {
+			t.Errorf("%s: Inspect = %+v, %v", tt.name, info, err)
+			continue
+		}
+		if info.Matches != tt.matches || info.Trusted != tt.trusted || info.Issuer != "Stand-in Trust (Stand-in Root CA)" ||
+			info.Trusted == (info.VerifyError != "") {
+			t.Errorf("%s: matches=%v trusted=%v issuer=%q err=%q, want matches=%v trusted=%v",
+				tt.name, info.Matches, info.Trusted, info.Issuer, info.VerifyError, tt.matches, tt.trusted)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	caKey, ca := newCA(t)
+	leaf := newLeaf(t, caKey, ca, 0x2a, []string{"taken.test", "*.taken.test"}, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Now().Add(time.Hour))
+	cert, err := x509.ParseCertificate(leaf.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	info := Describe("api.taken.test", []*x509.Certificate{cert}, roots)
+	if info.Subject != "taken.test" || len(info.SANs) != 2 || info.Serial != "2a" || info.NotBefore != "2025-01-01T00:00:00Z" ||
+		!info.Matches || !info.Trusted {
+		t.Errorf("Describe(api.taken.test) = %+v", info)
+	}
+	if info := Describe("deep.api.taken.test", []*x509.Certificate{cert}, roots); info.Matches || !info.Trusted {
+		t.Errorf("Describe(deep.api.taken.test): matches=%v trusted=%v, want false/true", info.Matches, info.Trusted)
+	}
+}
@@ -0,0 +1,228 @@
+package certs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"domain-agent/backend/internal/types"
+)
+
+// DefaultCTSearchURL 默认的 CT 日志搜索服务（crt.sh 的 JSON 接口）
+//
+// CT 日志本身（RFC 6962）只能按序号读取，不能按域名查询，需要借助聚合了各日志的搜索服务。
+const DefaultCTSearchURL = "https://crt.sh/"
+
+// maxCTEntries 汇总的记录数上限；热门域名的结果可能有数十 MB，逐条解码，到上限后不再读取
+var maxCTEntries = 10000
+
+// 摘要中列出的名称和签发者数量上限
+const (
+	maxCTNames   = 20
+	maxCTIssuers = 5
+)
+
+// CTClient CT 日志搜索客户端
+type CTClient struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// NewCTClient 创建客户端，baseURL 为空时使用 crt.sh
+func NewCTClient(baseURL string) *CTClient {
+	if baseURL == "" {
+		baseURL = DefaultCTSearchURL
+	}
+	return &CTClient{BaseURL: baseURL, HTTP: &http.Client{Timeout: 20 * time.Second}}
+}
+
+var (
+	defaultCT     *CTClient
+	defaultCTOnce sync.Once
+	defaultCTMu   sync.RWMutex
+)
+
+// DefaultCT 默认客户端，搜索服务地址可以用 CT_SEARCH_URL 覆盖
+func DefaultCT() *CTClient {
+	defaultCTOnce.Do(func() {
+		c := NewCTClient(os.Getenv("CT_SEARCH_URL"))
+		defaultCTMu.Lock()
+		if defaultCT == nil {
+			defaultCT = c
+		}
+		defaultCTMu.Unlock()
+	})
+	defaultCTMu.RLock()
+	defer defaultCTMu.RUnlock()
+	return defaultCT
+}
+
+// SetDefaultCT 替换默认客户端，仅应在启动时、处理请求之前调用
+func SetDefaultCT(c *CTClient) {
+	defaultCTMu.Lock()
+	defaultCT = c
+	defaultCTMu.Unlock()
+}
+
+// ctEntry crt.sh 的一条记录；预证书和正式证书各占一条，序列号相同
+type ctEntry struct {
+	IssuerName string `json:"issuer_name"`
+	CommonName string `json:"common_name"`
+	NameValue  string `json:"name_value"` // 换行分隔的全部名称
+	NotBefore  string `json:"not_before"`
+	NotAfter   string `json:"not_after"`
+	Serial     string `json:"serial_number"`
+}
+
+// ctTimeLayout crt.sh 的时间格式（UTC，小数秒可省略）
+const ctTimeLayout = "2006-01-02T15:04:05.999999999"
+
+// Search 查询域名及其子域名的证书记录，汇总首次出现、最近签发和签发频率
+func (c *CTClient) Search(ctx context.Context, domain string) (*types.CTSummary, error) {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	q := url.Values{"q": {"%." + domain}, "output": {"json"}}
+	endpoint := strings.TrimSuffix(c.BaseURL, "/") + "/?" + q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ct search: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ct search: HTTP %d", resp.StatusCode)
+	}
+
+	entries, truncated, err := decodeEntries(resp.Body, maxCTEntries)
+	if err != nil {
+		return nil, fmt.Errorf("ct search: %w", err)
+	}
+	summary := summarize(domain, entries, time.Now())
+	summary.Truncated = truncated
+	return summary, nil
+}
+
+// decodeEntries 逐条解码 JSON 数组，最多 limit 条；还有剩余记录时 truncated 为 true。
+// 空响应视为没有记录。
+func decodeEntries(r io.Reader, limit int) (entries []ctEntry, truncated bool, err error) {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, false, fmt.Errorf("unexpected %v, want an array", tok)
+	}
+	for dec.More() {
+		if len(entries) >= limit {
+			return entries, true, nil
+		}
+		var e ctEntry
+		if err := dec.Decode(&e); err != nil {
+			return nil, false, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, false, nil
+}
+
+// summarize 按序列号去重后汇总
+func summarize(domain string, entries []ctEntry, now time.Time) *types.CTSummary {
+	summary := &types.CTSummary{}
+	seen := map[string]bool{}
+	names := map[string]bool{}
+	issuers := map[string]int{}
+	var first, last time.Time
+
+	for _, e := range entries {
+		key := e.Serial
+		if key == "" {
+			key = e.NotBefore + "|" + e.NameValue
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		summary.Certificates++
+
+		if issued, err := time.Parse(ctTimeLayout, e.NotBefore); err == nil {
+			if first.IsZero() || issued.Before(first) {
+				first = issued
+			}
+			if issued.After(last) {
+				last = issued
+			}
+			if now.Sub(issued) <= 90*24*time.Hour {
+				summary.Last90Days++
+			}
+			if now.Sub(issued) <= 365*24*time.Hour {
+				summary.LastYear++
+			}
+		}
+		if expires, err := time.Parse(ctTimeLayout, e.NotAfter); err == nil && expires.After(now) {
+			summary.Active++
+		}
+
+		for _, name := range strings.Split(e.NameValue+"\n"+e.CommonName, "\n") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == domain || strings.HasSuffix(name, "."+domain) {
+				names[name] = true
+			}
+		}
+		issuers[issuerOrg(e.IssuerName)]++
+	}
+
+	if !first.IsZero() {
+		summary.FirstSeen = first.UTC().Format(time.RFC3339)
+		summary.LastIssued = last.UTC().Format(time.RFC3339)
+	}
+	for name := range names {
+		summary.Names = append(summary.Names, name)
+	}
+	sort.Strings(summary.Names)
+	if len(summary.Names) > maxCTNames {
+		summary.Names = summary.Names[:maxCTNames]
+	}
+
+	for issuer := range issuers {
+		if issuer != "" {
+			summary.Issuers = append(summary.Issuers, issuer)
+		}
+	}
+	sort.Slice(summary.Issuers, func(i, j int) bool {
+		a, b := summary.Issuers[i], summary.Issuers[j]
+		if issuers[a] != issuers[b] {
+			return issuers[a] > issuers[b]
+		}
+		return a < b
+	})
+	if len(summary.Issuers) > maxCTIssuers {
+		summary.Issuers = summary.Issuers[:maxCTIssuers]
+	}
+	return summary
+}
+
+// issuerOrg 从 "C=US, O=Let's Encrypt, CN=R3" 中取组织名称，没有时返回原文
+func issuerOrg(dn string) string {
+	for _, part := range strings.Split(dn, ",") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(part), "="); ok && k == "O" {
+			return strings.Trim(v, `"`)
+		}
+	}
+	return strings.TrimSpace(dn)
+}
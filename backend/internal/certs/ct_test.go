@@ -0,0 +1,98 @@
+package certs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ctStandIn crt.sh 格式的 CT 搜索替身，返回替身和最近一次查询的 q 参数
+func ctStandIn(t *testing.T, now time.Time) (*CTClient, *string) {
+	day := func(d int) string {
+		return now.Add(time.Duration(d) * 24 * time.Hour).UTC().Format("2006-01-02T15:04:05")
+	}
+	// 同一序列号的预证书和正式证书各一条
+	entries := fmt.Sprintf(`[
+		{"issuer_name": "C=US, O=Let's Encrypt, CN=R3", "common_name": "taken.test", "name_value": "taken.test\nwww.taken.test", "not_before": "%s", "not_after": "%s", "serial_number": "a1"},
+		{"issuer_name": "C=US, O=Let's Encrypt, CN=R3", "common_name": "taken.test", "name_value": "taken.test\nwww.taken.test", "not_before": "%s", "not_after": "%s", "serial_number": "a1"},
+		{"issuer_name": "C=US, O=Let's Encrypt, CN=R10", "common_name": "api.taken.test", "name_value": "api.taken.test", "not_before": "%s.123", "not_after": "%s", "serial_number": "a2"},
+		{"issuer_name": "C=BE, O=GlobalSign nv-sa, CN=GlobalSign RSA OV SSL CA 2018", "common_name": "taken.test", "name_value": "taken.test\nmail.taken.test\nunrelated.example", "not_before": "%s", "not_after": "%s", "serial_number": "b7"}
+	]`, day(-30), day(60), day(-30), day(60), day(-200), day(-110), day(-1500), day(-1100))
+
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("q")
+		switch query {
+		case "%.taken.test":
+			fmt.Fprint(w, entries)
+		case "%.busy.test":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "%.empty.test":
+		case "%.popular.test":
+			// 远超上限的结果，最后一条不完整：到上限后不再读取，不会因此解码失败
+			fmt.Fprint(w, "[")
+			for i := 0; i < 50; i++ {
+				fmt.Fprintf(w, `{"common_name": "popular.test", "name_value": "popular.test", "not_before": "%s", "not_after": "%s", "serial_number": "%x"},`, day(-i), day(90-i), i)
+			}
+			fmt.Fprint(w, `{"common_name": "popular.te`)
+		default:
+			fmt.Fprint(w, "[]")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return NewCTClient(srv.URL), &query
+}
+
+func TestCTSearch(t *testing.T) {
+	now := time.Now()
+	client, query := ctStandIn(t, now)
+	date := func(d int) string { return now.Add(time.Duration(d) * 24 * time.Hour).UTC().Format("2006-01-02") }
+
+	s, err := client.Search(context.Background(), "Taken.Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *query != "%.taken.test" {
+		t.Errorf("query = %q", *query)
+	}
+	if s.Certificates != 3 || s.Active != 1 || s.Truncated {
+		t.Errorf("certificates=%d active=%d truncated=%v, want 3, 1, false", s.Certificates, s.Active, s.Truncated)
+	}
+	if s.Last90Days != 1 || s.LastYear != 2 || !strings.HasPrefix(s.FirstSeen, date(-1500)) || !strings.HasPrefix(s.LastIssued, date(-30)) {
+		t.Errorf("90d=%d year=%d first=%s last=%s", s.Last90Days, s.LastYear, s.FirstSeen, s.LastIssued)
+	}
+	if strings.Join(s.Issuers, ",") != "Let's Encrypt,GlobalSign nv-sa" {
+		t.Errorf("issuers = %v", s.Issuers)
+	}
+	if strings.Join(s.Names, ",") != "api.taken.test,mail.taken.test,taken.test,www.taken.test" {
+		t.Errorf("names = %v", s.Names)
+	}
+
+	for _, domain := range []string{"quiet.test", "empty.test"} {
+		if s, err := client.Search(context.Background(), domain); err != nil || s.Certificates != 0 || s.FirstSeen != "" {
+			t.Errorf("%s: Search = %+v, %v", domain, s, err)
+		}
+	}
+	if _, err := client.Search(context.Background(), "busy.test"); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("busy.test: error = %v, want HTTP 503", err)
+	}
+}
+
+func TestCTSearchStopsAtLimit(t *testing.T) {
+	limit := maxCTEntries
+	maxCTEntries = 10
+	t.Cleanup(func() { maxCTEntries = limit })
+
+	client, _ := ctStandIn(t, time.Now())
+	s, err := client.Search(context.Background(), "popular.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Certificates != 10 || !s.Truncated {
+		t.Errorf("certificates=%d truncated=%v, want 10 and truncated", s.Certificates, s.Truncated)
+	}
+}
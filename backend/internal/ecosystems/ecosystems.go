@@ -0,0 +1,87 @@
+// Package ecosystems 包仓库的名称冲突检查
+//
+// 开发者工具的名字除了域名，还需要在 npm、PyPI、crates.io、Go 模块路径和
+// Docker Hub 上可用。每个仓库是一个 namecheck.Checker，通过 Register 注册；
+// 仓库地址可以替换为本地假仓库（见 ecosystems_test.go）。
+package ecosystems
+
+import (
+	"net/http"
+
+	"domain-agent/backend/internal/namecheck"
+	"domain-agent/backend/internal/types"
+)
+
+// registries 包仓库的检查器
+var registries = namecheck.NewRegistry("registries")
+
+func init() {
+	Register(NewNPM(""))
+	Register(NewPyPI(""))
+	Register(NewCrates(""))
+	Register(NewGo(""))
+	Register(NewDockerHub(""))
+}
+
+// Register 注册仓库，同名仓库会被覆盖（可用于替换为假仓库）
+func Register(c namecheck.Checker) {
+	registries.Register(c)
+}
+
+// Names 已注册的仓库，按注册顺序
+func Names() []string {
+	return registries.Names()
+}
+
+// Validate 检查仓库名称是否都已注册
+func Validate(names []string) error {
+	return registries.Validate(names)
+}
+
+// Check 并发检查一个名称在各仓库的可用性，selected 为空时检查全部仓库
+func Check(label string, selected []string) []types.EcosystemResult {
+	return ecosystemResults(registries.Check(label, selected))
+}
+
+// CheckAll 检查多个名称，相同的名称只检查一次
+func CheckAll(labels []string, selected []string) map[string][]types.EcosystemResult {
+	results := map[string][]types.EcosystemResult{}
+	for label, r := range registries.CheckAll(labels, selected) {
+		results[label] = ecosystemResults(r)
+	}
+	return results
+}
+
+func ecosystemResults(results []namecheck.Result) []types.EcosystemResult {
+	list := make([]types.EcosystemResult, len(results))
+	for i, r := range results {
+		list[i] = types.EcosystemResult{Registry: r.Checker, Name: r.Name, Status: r.Status, Available: r.Available(), Error: r.Error}
+	}
+	return list
+}
+
+// NewNPM npm 包名：小写字母数字和 - . _，不能以 . 或 _ 开头，不超过 214 个字符
+func NewNPM(baseURL string) namecheck.Checker {
+	return namecheck.NewHTTPChecker("npm", baseURL, "https://registry.npmjs.org", "/%s", `^[a-z0-9-][a-z0-9._-]{0,213}$`)
+}
+
+// NewPyPI PyPI 包名：字母数字和 - . _，首尾必须是字母或数字
+func NewPyPI(baseURL string) namecheck.Checker {
+	return namecheck.NewHTTPChecker("pypi", baseURL, "https://pypi.org", "/pypi/%s/json", `^[a-z0-9](?:[a-z0-9._-]*[a-z0-9])?$`)
+}
+
+// NewCrates crates.io 包名：字母开头，字母数字和 - _，不超过 64 个字符
+func NewCrates(baseURL string) namecheck.Checker {
+	return namecheck.NewHTTPChecker("crates", baseURL, "https://crates.io", "/api/v1/crates/%s", `^[a-z][a-z0-9_-]{0,63}$`)
+}
+
+// NewGo Go 模块路径 github.com/<name>/<name>，通过模块代理查询；代理对不存在的模块返回 404 或 410
+func NewGo(baseURL string) namecheck.Checker {
+	return namecheck.NewHTTPChecker("go", baseURL, "https://proxy.golang.org", "/github.com/%[1]s/%[1]s/@v/list",
+		`^[a-z0-9](?:[a-z0-9]|-[a-z0-9]){0,38}$`, http.StatusNotFound, http.StatusGone)
+}
+
+// NewDockerHub Docker Hub 命名空间（用户或组织名）：小写字母数字，4-30 个字符
+func NewDockerHub(baseURL string) namecheck.Checker {
+	return namecheck.NewHTTPChecker("dockerhub", baseURL, "https://hub.docker.com", "/v2/users/%s/", `^[a-z0-9]{4,30}$`)
+}
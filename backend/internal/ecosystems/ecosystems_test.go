@@ -0,0 +1,120 @@
+package ecosystems
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"domain-agent/backend/internal/namecheck"
+)
+
+// published 假仓库中已存在的名称
+var published = map[string]bool{"react": true, "serde": true, "cobra": true, "nginx": true}
+
+// useFakeRegistries 启动模拟各仓库接口的本地服务，把全部仓库换成指向它的实例，测试结束时恢复
+func useFakeRegistries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if strings.Contains(path, "ratelimited") {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		// 从各仓库的路径中取出名称
+		var name string
+		switch {
+		case strings.HasPrefix(path, "/pypi/"):
+			name = strings.TrimSuffix(strings.TrimPrefix(path, "/pypi/"), "/json")
+		case strings.HasPrefix(path, "/api/v1/crates/"):
+			name = strings.TrimPrefix(path, "/api/v1/crates/")
+		case strings.HasPrefix(path, "/github.com/"):
+			name = strings.Split(strings.TrimPrefix(path, "/github.com/"), "/")[0]
+			if !published[name] {
+				// 模块代理对不存在的模块返回 410
+				w.WriteHeader(http.StatusGone)
+				return
+			}
+		case strings.HasPrefix(path, "/v2/users/"):
+			name = strings.Trim(strings.TrimPrefix(path, "/v2/users/"), "/")
+		default:
+			name = strings.Trim(path, "/")
+		}
+
+		if published[name] {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+
+	previous := registries
+	registries = namecheck.NewRegistry("registries")
+	t.Cleanup(func() { registries = previous })
+
+	Register(NewNPM(server.URL))
+	Register(NewPyPI(server.URL))
+	Register(NewCrates(server.URL))
+	Register(NewGo(server.URL))
+	Register(NewDockerHub(server.URL))
+}
+
+// all 所有仓库都是同一个状态
+func all(status string) map[string]string {
+	want := map[string]string{}
+	for _, r := range Names() {
+		want[r] = status
+	}
+	return want
+}
+
+func TestCheckAll(t *testing.T) {
+	useFakeRegistries(t)
+
+	tests := []struct {
+		name string
+		want map[string]string
+	}{
+		{"serde", all(namecheck.StatusTaken)},
+		{"kitleaf", all(namecheck.StatusAvailable)},
+		{"ratelimited", all(namecheck.StatusUnknown)},
+		{"my-tool", map[string]string{
+			"npm": namecheck.StatusAvailable, "pypi": namecheck.StatusAvailable, "crates": namecheck.StatusAvailable,
+			"go": namecheck.StatusAvailable, "dockerhub": namecheck.StatusInvalid,
+		}},
+		{"3d", map[string]string{
+			"npm": namecheck.StatusAvailable, "pypi": namecheck.StatusAvailable, "crates": namecheck.StatusInvalid,
+			"go": namecheck.StatusAvailable, "dockerhub": namecheck.StatusInvalid,
+		}},
+	}
+
+	var names []string
+	for _, tt := range tests {
+		names = append(names, tt.name)
+	}
+	results := CheckAll(names, nil)
+
+	for _, tt := range tests {
+		if len(results[tt.name]) != len(tt.want) {
+			t.Errorf("%s: got %d registries, want %d", tt.name, len(results[tt.name]), len(tt.want))
+		}
+		for _, r := range results[tt.name] {
+			if r.Status != tt.want[r.Registry] || r.Available != (r.Status == namecheck.StatusAvailable) || r.Name != tt.name {
+				t.Errorf("%s on %s: %+v, want status %s", tt.name, r.Registry, r, tt.want[r.Registry])
+			}
+		}
+	}
+}
+
+func TestCheckSelected(t *testing.T) {
+	useFakeRegistries(t)
+
+	results := Check("cobra", []string{"go", "crates"})
+	if len(results) != 2 || results[0].Registry != "go" || results[1].Registry != "crates" || results[0].Status != namecheck.StatusTaken {
+		t.Errorf("Check = %+v", results)
+	}
+	if err := Validate([]string{"npm", "maven"}); err == nil || !strings.Contains(err.Error(), "unknown registries: maven") {
+		t.Errorf("Validate = %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+// Package evidence 把各项检查的结果合并为域名可注册的概率
+//
+// 每个信号（区域数据、后缀委派、RDAP 及其状态、DNS 记录、TLS 证书、WHOIS 文本）给出"已注册"、
+// "可注册"或"无法判断"，按权重累加对数几率（log-odds）后换算为概率。权重表示
+// 该信号出现时几率比的自然对数，例如 RDAP 返回 404 的权重 5 表示可注册的几率乘以 e^5。
+// 不同注册局的可靠程度不同，权重可以按后缀覆盖。
+package evidence
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+
+	"domain-agent/backend/internal/types"
+)
+
+// 信号名称
+const (
+	SignalZone       = "zone"       // 区域文件或布隆过滤器
+	SignalDelegation = "delegation" // 后缀权威服务器的委派
+	SignalRDAP       = "rdap"
+	SignalRDAPStatus = "rdap_status" // RDAP 状态为赎回期或待删除：仍不可注册，但即将释放
+	SignalDNSNS      = "dns_ns"
+	SignalDNSA       = "dns_a"
+	SignalDNSMX      = "dns_mx"
+	SignalTLS        = "tls"
+	SignalWHOIS      = "whois"
+)
+
+// 信号指向的结论
+const (
+	Taken     = "taken"
+	Available = "available"
+	Unknown   = "unknown"
+)
+
+// Weight 信号分别指向已注册和可注册时的权重（对数几率，非负）
+type Weight struct {
+	Taken     float64 `json:"taken"`
+	Available float64 `json:"available"`
+}
+
+// Weights 权重配置
+type Weights struct {
+	// Prior 没有任何证据时可注册的对数几率，负值表示默认倾向已注册
+	Prior   float64           `json:"prior"`
+	Signals map[string]Weight `json:"signals"`
+	// TLDs 按后缀（不带点，如 de、com.cn）覆盖部分信号的权重
+	TLDs map[string]map[string]Weight `json:"tlds"`
+}
+
+// DefaultWeights 默认权重
+//
+// 委派和 RDAP 是注册局实时给出的结构化结果，权重最高；区域数据是过去某一时刻的快照，
+// 布隆过滤器还可能误判，只作为先验，单独的 RDAP 404 即可推翻；递归 DNS 记录只能
+// 证明已注册；没有委派（NXDOMAIN）只是较弱的可注册证据，因为已注册但未委派的域名
+// （如 serverHold）同样不在区域中；赎回期和待删除的域名仍不可注册，只抵消一部分
+// RDAP 的已注册权重，降低置信度；WHOIS 文本依赖关键字匹配，权重较低。
+func DefaultWeights() *Weights {
+	return &Weights{
+		Prior: -0.5,
+		Signals: map[string]Weight{
+			SignalZone:       {Taken: 3, Available: 1},
+			SignalDelegation: {Taken: 6, Available: 2},
+			SignalRDAP:       {Taken: 6, Available: 5},
+			SignalRDAPStatus: {Available: 2},
+			SignalDNSNS:      {Taken: 4},
+			SignalDNSA:       {Taken: 2},
+			SignalDNSMX:      {Taken: 2},
+			SignalTLS:        {Taken: 2},
+			SignalWHOIS:      {Taken: 3, Available: 2.5},
+		},
+		TLDs: map[string]map[string]Weight{},
+	}
+}
+
+// LoadWeights 读取 JSON 权重文件，未给出的信号沿用默认值
+//
+//	{"prior": -0.5, "signals": {"whois": {"taken": 3, "available": 2}},
+//	 "tlds": {"de": {"whois": {"taken": 4, "available": 4}}}}
+func LoadWeights(path string) (*Weights, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Prior   *float64                     `json:"prior"`
+		Signals map[string]Weight            `json:"signals"`
+		TLDs    map[string]map[string]Weight `json:"tlds"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	w := DefaultWeights()
+	if file.Prior != nil {
+		w.Prior = *file.Prior
+	}
+	for signal, weight := range file.Signals {
+		w.Signals[signal] = weight
+	}
+	for tld, signals := range file.TLDs {
+		w.TLDs[strings.ToLower(strings.Trim(tld, "."))] = signals
+	}
+	return w, nil
+}
+
+var (
+	defaultWeights *Weights
+	defaultOnce    sync.Once
+	defaultMu      sync.RWMutex
+)
+
+// Default 默认权重，配置了 CONFIDENCE_WEIGHTS 时从该文件读取
+func Default() *Weights {
+	defaultOnce.Do(func() {
+		w := DefaultWeights()
+		if path := os.Getenv("CONFIDENCE_WEIGHTS"); path != "" {
+			if loaded, err := LoadWeights(path); err != nil {
+				fmt.Printf("Confidence weights unavailable, using defaults: %v\n", err)
+			} else {
+				w = loaded
+			}
+		}
+		defaultMu.Lock()
+		if defaultWeights == nil {
+			defaultWeights = w
+		}
+		defaultMu.Unlock()
+	})
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultWeights
+}
+
+// SetDefault 替换默认权重，仅应在启动时、处理请求之前调用
+func SetDefault(w *Weights) {
+	defaultMu.Lock()
+	defaultWeights = w
+	defaultMu.Unlock()
+}
+
+// For 后缀上信号的权重：先找完整后缀（com.cn），再找顶级后缀（cn），最后用全局值
+func (w *Weights) For(tld, signal string) Weight {
+	tld = strings.ToLower(strings.Trim(tld, "."))
+	for t := tld; t != ""; {
+		if weight, ok := w.TLDs[t][signal]; ok {
+			return weight
+		}
+		i := strings.IndexByte(t, '.')
+		if i < 0 {
+			break
+		}
+		t = t[i+1:]
+	}
+	return w.Signals[signal]
+}
+
+// Combine 按后缀的权重合并证据，返回是否判为可注册和该结论的置信度（0.5–1）
+//
+// 会把每条证据实际使用的权重写回 ev：指向已注册的为负值，无法判断的为 0。
+func (w *Weights) Combine(tld string, ev []types.Evidence) (bool, float64) {
+	logit := w.Prior
+	for i := range ev {
+		weight := w.For(tld, ev[i].Signal)
+		switch ev[i].Indicates {
+		case Taken:
+			ev[i].Weight = -weight.Taken
+		case Available:
+			ev[i].Weight = weight.Available
+		default:
+			ev[i].Weight = 0
+		}
+		logit += ev[i].Weight
+	}
+
+	p := 1 / (1 + math.Exp(-logit))
+	available := p >= 0.5
+	if !available {
+		p = 1 - p
+	}
+	return available, math.Round(p*1000) / 1000
+}
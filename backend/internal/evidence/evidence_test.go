@@ -0,0 +1,103 @@
+package evidence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"domain-agent/backend/internal/types"
+)
+
+func ev(signal, indicates string) types.Evidence {
+	return types.Evidence{Signal: signal, Indicates: indicates}
+}
+
+func TestCombine(t *testing.T) {
+	tests := []struct {
+		name      string
+		evidence  []types.Evidence
+		available bool
+		min, max  float64 // 结论的置信度范围
+	}{
+		{"no evidence", nil, false, 0.6, 0.7},
+		{"rdap 404 + nxdomain + whois free", []types.Evidence{
+			ev(SignalDelegation, Available), ev(SignalRDAP, Available), ev(SignalWHOIS, Available)}, true, 0.999, 1},
+		{"whois rate limited + nxdomain", []types.Evidence{
+			ev(SignalDelegation, Available), ev(SignalRDAP, Unknown), ev(SignalWHOIS, Unknown)}, true, 0.8, 0.9},
+		{"registered but undelegated", []types.Evidence{
+			ev(SignalDelegation, Available), ev(SignalRDAP, Taken), ev(SignalWHOIS, Taken)}, false, 0.99, 1},
+		{"whois free contradicted by A/MX", []types.Evidence{
+			ev(SignalWHOIS, Available), ev(SignalDNSA, Taken), ev(SignalDNSMX, Taken)}, false, 0.8, 1},
+		{"referral", []types.Evidence{ev(SignalDelegation, Taken)}, false, 0.99, 1},
+		// 区域快照命中只是先验，RDAP 404 即可推翻
+		{"zone hit + rdap 404", []types.Evidence{ev(SignalZone, Taken), ev(SignalRDAP, Available)}, true, 0.8, 0.9},
+		// 待删除：仍判为已注册，但置信度低于正常注册的域名
+		{"rdap taken + pending delete", []types.Evidence{ev(SignalRDAP, Taken), ev(SignalRDAPStatus, Available)}, false, 0.98, 0.99},
+		{"rdap taken", []types.Evidence{ev(SignalRDAP, Taken)}, false, 0.998, 1},
+	}
+
+	w := DefaultWeights()
+	for _, tt := range tests {
+		available, confidence := w.Combine("com", tt.evidence)
+		if available != tt.available || confidence < tt.min || confidence > tt.max {
+			t.Errorf("%s: available=%v confidence=%.3f, want %v in [%.3f, %.3f]", tt.name, available, confidence, tt.available, tt.min, tt.max)
+		}
+	}
+}
+
+func TestCombineWritesWeights(t *testing.T) {
+	found := []types.Evidence{ev(SignalRDAP, Taken), ev(SignalWHOIS, Available), ev(SignalTLS, Unknown)}
+	DefaultWeights().Combine("com", found)
+	for i, want := range []float64{-6, 2.5, 0} {
+		if found[i].Weight != want {
+			t.Errorf("%s: weight %v, want %v", found[i].Signal, found[i].Weight, want)
+		}
+	}
+}
+
+func TestLoadWeights(t *testing.T) {
+	// .weak 的 WHOIS 不可信，.com.cn 的配置优先于 .cn
+	path := filepath.Join(t.TempDir(), "weights.json")
+	err := os.WriteFile(path, []byte(`{"prior": -1, "tlds": {"weak": {"whois": {"taken": 0.5, "available": 0.2}}, "CN": {"rdap": {"taken": 1, "available": 1}}, ".com.cn": {"rdap": {"taken": 7, "available": 7}}}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := LoadWeights(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Prior != -1 || w.Signals[SignalRDAP] != DefaultWeights().Signals[SignalRDAP] {
+		t.Errorf("prior %v, rdap %v: want -1 and the default rdap weight", w.Prior, w.Signals[SignalRDAP])
+	}
+
+	whoisOnly := []types.Evidence{ev(SignalWHOIS, Available)}
+	if available, _ := w.Combine("com", whoisOnly); !available {
+		t.Error("whois only on .com: want available")
+	}
+	if available, _ := w.Combine(".weak", whoisOnly); available {
+		t.Error("whois only on .weak: want taken")
+	}
+	if whoisOnly[0].Weight != 0.2 {
+		t.Errorf("weight written back = %v, want 0.2", whoisOnly[0].Weight)
+	}
+
+	tests := []struct {
+		tld  string
+		want float64
+	}{
+		{"com.cn", 7},
+		{"cn", 1},
+		{"net.cn", 1},
+		{"com", DefaultWeights().Signals[SignalRDAP].Available},
+	}
+	for _, tt := range tests {
+		if got := w.For(tt.tld, SignalRDAP).Available; got != tt.want {
+			t.Errorf("For(%s, rdap) = %v, want %v", tt.tld, got, tt.want)
+		}
+	}
+
+	if _, err := LoadWeights(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadWeights accepted a missing file")
+	}
+}
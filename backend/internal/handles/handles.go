@@ -0,0 +1,123 @@
+// Package handles 社交账号的可用性检查
+//
+// 每个平台是一个 namecheck.Checker，通过 Register 注册；默认注册 GitHub、X 和 Instagram。
+// 包名（npm、PyPI 等）的检查见 internal/ecosystems。检查器的 BaseURL 可以替换为本地替身
+// （见 handles_test.go）。
+package handles
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"domain-agent/backend/internal/namecheck"
+	"domain-agent/backend/internal/types"
+)
+
+// platforms 社交平台的检查器
+var platforms = namecheck.NewRegistry("platforms")
+
+func init() {
+	Register(NewGitHub(""))
+	Register(NewX(""))
+	Register(NewInstagram(""))
+}
+
+// Register 注册检查器，同一平台的检查器会被覆盖（可用于替换为替身）
+func Register(c namecheck.Checker) {
+	platforms.Register(c)
+}
+
+// Platforms 已注册的平台，按注册顺序
+func Platforms() []string {
+	return platforms.Names()
+}
+
+// ValidatePlatforms 检查平台名称是否都已注册
+func ValidatePlatforms(names []string) error {
+	return platforms.Validate(names)
+}
+
+// Check 并发检查一个名称在各平台的可用性，selected 为空时检查全部平台
+func Check(handle string, selected []string) []types.HandleResult {
+	return handleResults(platforms.Check(handle, selected))
+}
+
+// CheckAll 检查多个名称，相同的名称只检查一次
+func CheckAll(handles []string, selected []string) map[string][]types.HandleResult {
+	results := map[string][]types.HandleResult{}
+	for name, r := range platforms.CheckAll(handles, selected) {
+		results[name] = handleResults(r)
+	}
+	return results
+}
+
+func handleResults(results []namecheck.Result) []types.HandleResult {
+	list := make([]types.HandleResult, len(results))
+	for i, r := range results {
+		list[i] = types.HandleResult{Platform: r.Checker, Handle: r.Name, Status: r.Status, Available: r.Available(), Error: r.Error}
+	}
+	return list
+}
+
+// NewGitHub GitHub 用户名：字母数字和单个连字符，不超过 39 个字符
+//
+// 配置 GITHUB_TOKEN 时带上认证，未认证的请求每小时只有 60 次配额。
+func NewGitHub(baseURL string) namecheck.Checker {
+	c := namecheck.NewHTTPChecker("github", baseURL, "https://api.github.com", "/users/%s", `^[a-z0-9](?:[a-z0-9]|-[a-z0-9]){0,38}$`)
+	c.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		c.Header.Set("Authorization", "Bearer "+token)
+	}
+	return c
+}
+
+// NewX X（Twitter）用户名：字母数字和下划线，不超过 15 个字符
+//
+// X 的资料页对不存在的用户也返回 200，因此改用注册页使用的用户名可用性接口。
+func NewX(baseURL string) namecheck.Checker {
+	return &xChecker{namecheck.NewHTTPChecker("x", baseURL, "https://api.x.com", "/i/users/username_available.json?username=%s", `^[a-z0-9_]{1,15}$`)}
+}
+
+// xChecker 解析用户名可用性接口的 JSON 响应
+type xChecker struct {
+	*namecheck.HTTPChecker
+}
+
+func (c *xChecker) Check(ctx context.Context, handle string) (string, error) {
+	if !c.Pattern.MatchString(handle) {
+		return namecheck.StatusInvalid, nil
+	}
+
+	resp, err := c.Get(ctx, handle)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("x returned HTTP %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Valid  bool   `json:"valid"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode x response: %w", err)
+	}
+	switch {
+	case body.Valid:
+		return namecheck.StatusAvailable, nil
+	case body.Reason == "taken":
+		return namecheck.StatusTaken, nil
+	default:
+		return namecheck.StatusInvalid, nil
+	}
+}
+
+// NewInstagram Instagram 用户名：字母数字、下划线和点，不超过 30 个字符
+func NewInstagram(baseURL string) namecheck.Checker {
+	return namecheck.NewHTTPChecker("instagram", baseURL, "https://www.instagram.com", "/%s/", `^[a-z0-9_](?:[a-z0-9_.]{0,28}[a-z0-9_])?$`)
+}
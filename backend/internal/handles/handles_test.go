@@ -0,0 +1,119 @@
+package handles
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"domain-agent/backend/internal/namecheck"
+)
+
+// taken 替身中已被占用的名称
+var taken = map[string]bool{"google": true, "react": true}
+
+// useStandIn 启动模拟各平台接口的本地服务，把全部检查器换成指向它的实例，测试结束时恢复
+func useStandIn(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 模拟限流
+		if strings.Contains(r.URL.RawQuery+r.URL.Path, "ratelimited") {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		if r.URL.Path == "/i/users/username_available.json" {
+			name := r.URL.Query().Get("username")
+			w.Header().Set("Content-Type", "application/json")
+			if taken[name] {
+				json.NewEncoder(w).Encode(map[string]any{"valid": false, "reason": "taken"})
+			} else {
+				json.NewEncoder(w).Encode(map[string]any{"valid": true, "reason": "available"})
+			}
+			return
+		}
+
+		// /users/{name}、/{name}/
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/users"), "/")
+		if taken[name] {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+
+	previous := platforms
+	platforms = namecheck.NewRegistry("platforms")
+	t.Cleanup(func() { platforms = previous })
+
+	Register(NewGitHub(server.URL))
+	Register(NewX(server.URL))
+	Register(NewInstagram(server.URL))
+}
+
+// all 所有平台都是同一个状态
+func all(status string) map[string]string {
+	want := map[string]string{}
+	for _, p := range Platforms() {
+		want[p] = status
+	}
+	return want
+}
+
+func TestCheckAll(t *testing.T) {
+	useStandIn(t)
+
+	tests := []struct {
+		name string
+		want map[string]string
+	}{
+		{"google", all(namecheck.StatusTaken)},
+		{"kitleaf", all(namecheck.StatusAvailable)},
+		{"ratelimited", all(namecheck.StatusUnknown)},
+		{"my-brand", map[string]string{"github": namecheck.StatusAvailable, "x": namecheck.StatusInvalid, "instagram": namecheck.StatusInvalid}},
+		{"averyveryverylongname", map[string]string{"github": namecheck.StatusAvailable, "x": namecheck.StatusInvalid, "instagram": namecheck.StatusAvailable}},
+	}
+
+	names := []string{" Google ", "google"}
+	for _, tt := range tests {
+		names = append(names, tt.name)
+	}
+	results := CheckAll(names, nil)
+	if len(results) != len(tests) {
+		t.Errorf("CheckAll returned %d names, want %d (duplicates checked once)", len(results), len(tests))
+	}
+
+	for _, tt := range tests {
+		if len(results[tt.name]) != len(tt.want) {
+			t.Errorf("%s: got %d platforms, want %d", tt.name, len(results[tt.name]), len(tt.want))
+		}
+		for i, r := range results[tt.name] {
+			if r.Platform != Platforms()[i] {
+				t.Errorf("%s: result %d is %s, want registration order", tt.name, i, r.Platform)
+			}
+			if r.Status != tt.want[r.Platform] || r.Available != (r.Status == namecheck.StatusAvailable) {
+				t.Errorf("%s on %s: status %s, want %s", tt.name, r.Platform, r.Status, tt.want[r.Platform])
+			}
+			if r.Status == namecheck.StatusUnknown && r.Error == "" {
+				t.Errorf("%s on %s: unknown status without error", tt.name, r.Platform)
+			}
+		}
+	}
+}
+
+func TestCheckPlatforms(t *testing.T) {
+	useStandIn(t)
+
+	results := Check("google", []string{"x", "github", "myspace"})
+	if len(results) != 2 || results[0].Platform != "x" || results[1].Platform != "github" {
+		t.Fatalf("Check = %+v, want x and github in request order", results)
+	}
+
+	if err := ValidatePlatforms([]string{"github", "myspace"}); err == nil || !strings.Contains(err.Error(), "myspace") {
+		t.Errorf("ValidatePlatforms = %v", err)
+	}
+	if err := ValidatePlatforms(Platforms()); err != nil {
+		t.Errorf("ValidatePlatforms(all) = %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package history
+
+import (
+	"strings"
+	"time"
+
+	"domain-agent/backend/internal/types"
+)
+
+// 变化类型
+const (
+	ChangeRegistered   = "registered"          // 从未注册变为已注册
+	ChangeReleased     = "released"            // 从已注册变为未注册（过期删除）
+	ChangeRegistrar    = "registrar_changed"   // 转移注册商
+	ChangeRegistrant   = "registrant_changed"  // 持有者变化
+	ChangeNameServers  = "nameservers_changed" // 名称服务器变化
+	ChangeExpiryExtend = "expiry_extended"     // 续费
+	ChangeExpiry       = "expiry_changed"      // 到期时间提前（通常是转移或删除后重新注册）
+	ChangeStatus       = "status_changed"      // EPP 状态变化
+)
+
+// Diff 比较同一来源的相邻快照，按时间顺序列出变化
+//
+// 不同来源的格式和字段不同（如 WHOIS 常隐藏持有者），跨来源比较会产生虚假的变化。
+func Diff(snapshots []types.RegistrationSnapshot) []types.RegistrationChange {
+	changes := []types.RegistrationChange{}
+	last := map[string]types.RegistrationSnapshot{}
+	for _, s := range snapshots {
+		prev, ok := last[s.Source]
+		last[s.Source] = s
+		if !ok {
+			continue
+		}
+		add := func(kind, from, to string) {
+			changes = append(changes, types.RegistrationChange{At: s.CapturedAt, Source: s.Source, Kind: kind, From: from, To: to})
+		}
+
+		switch {
+		case !prev.Registered && s.Registered:
+			add(ChangeRegistered, "", s.Registrar)
+		case prev.Registered && !s.Registered:
+			add(ChangeReleased, prev.Registrar, "")
+			continue // 其余字段都随之清空，不再逐项列出
+		}
+		if prev.Registrar != s.Registrar && prev.Registrar != "" && s.Registrar != "" {
+			add(ChangeRegistrar, prev.Registrar, s.Registrar)
+		}
+		if prev.Registrant != s.Registrant && prev.Registrant != "" && s.Registrant != "" {
+			add(ChangeRegistrant, prev.Registrant, s.Registrant)
+		}
+		if strings.Join(prev.NameServers, ",") != strings.Join(s.NameServers, ",") && len(prev.NameServers) > 0 && len(s.NameServers) > 0 {
+			add(ChangeNameServers, strings.Join(prev.NameServers, ", "), strings.Join(s.NameServers, ", "))
+		}
+		if prev.ExpiresAt != s.ExpiresAt && prev.ExpiresAt != "" && s.ExpiresAt != "" {
+			// 规范化后的日期按字符串比较即为时间先后；无法识别的格式不判断方向
+			if isDate(prev.ExpiresAt) && isDate(s.ExpiresAt) && s.ExpiresAt > prev.ExpiresAt {
+				add(ChangeExpiryExtend, prev.ExpiresAt, s.ExpiresAt)
+			} else {
+				add(ChangeExpiry, prev.ExpiresAt, s.ExpiresAt)
+			}
+		}
+		if strings.Join(prev.Status, ",") != strings.Join(s.Status, ",") && len(prev.Status) > 0 && len(s.Status) > 0 {
+			add(ChangeStatus, strings.Join(prev.Status, ", "), strings.Join(s.Status, ", "))
+		}
+	}
+	return changes
+}
+
+// isDate 是否为规范化后的 YYYY-MM-DD
+func isDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
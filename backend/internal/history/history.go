@@ -0,0 +1,151 @@
+// Package history 保存域名注册信息的快照，并比较出注册商、名称服务器和到期时间的变化
+//
+// 检查判为已注册的域名、以及原始查询和注册信息接口取得的 WHOIS、RDAP 和后缀
+// 委派数据会被规范化为快照，存入服务入口用 Open 打开的存储（DATABASE_URL，与
+// 会话存储相同）。同一来源内容没有变化时只更新最近一次看到的时间，所以历史中的
+// 每一份快照都代表一次真实的变化。最近一次看到的时间超过 MaxAge 的来源视为过期
+// （见 Stale），检查时会重新查询。
+package history
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"domain-agent/backend/internal/store"
+	"domain-agent/backend/internal/types"
+)
+
+// 快照来源
+const (
+	SourceWHOIS      = "whois"
+	SourceRDAP       = "rdap"
+	SourceDelegation = "delegation"
+)
+
+// defaultMaxAge 快照多久没有再看到即视为过期
+const defaultMaxAge = 7 * 24 * time.Hour
+
+// Recorder 快照记录器
+type Recorder struct {
+	Store  store.HistoryStore
+	Now    func() time.Time // 当前时间，测试可以替换
+	MaxAge time.Duration    // 同一来源多久没有记录即视为过期
+
+	mu sync.Mutex // 保证同一进程内“读取最近快照—写入”不交错
+}
+
+// New 创建记录器
+func New(s store.HistoryStore) *Recorder {
+	return &Recorder{Store: s, Now: time.Now, MaxAge: maxAge()}
+}
+
+var (
+	maxAgeValue time.Duration
+	maxAgeOnce  sync.Once
+)
+
+// maxAge 快照的过期时间，可以用 HISTORY_MAX_AGE 覆盖（Go duration 格式）
+func maxAge() time.Duration {
+	maxAgeOnce.Do(func() {
+		maxAgeValue = defaultMaxAge
+		if v := os.Getenv("HISTORY_MAX_AGE"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				maxAgeValue = d
+			} else {
+				fmt.Printf("Invalid HISTORY_MAX_AGE %q, using default: %v\n", v, err)
+			}
+		}
+	})
+	return maxAgeValue
+}
+
+var (
+	defaultRecorder *Recorder
+	defaultOnce     sync.Once
+	defaultMu       sync.RWMutex
+)
+
+// Default 默认记录器；服务入口调用 Open 之前使用内存存储
+func Default() *Recorder {
+	defaultOnce.Do(func() {
+		r := New(store.NewMemoryHistory())
+		defaultMu.Lock()
+		if defaultRecorder == nil {
+			defaultRecorder = r
+		}
+		defaultMu.Unlock()
+	})
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultRecorder
+}
+
+// Open 按 DATABASE_URL 打开快照存储并替换默认记录器，由服务入口在启动时调用
+//
+// 与会话存储相同，明确配置的存储连不上时返回错误而不是退回内存，由入口决定退出。
+func Open(databaseURL string) error {
+	s, err := store.OpenHistory(databaseURL)
+	if err != nil {
+		return fmt.Errorf("history store unavailable (DATABASE_URL): %w", err)
+	}
+	SetDefault(New(s))
+	return nil
+}
+
+// SetDefault 替换默认记录器，仅应在启动时、处理请求之前调用
+func SetDefault(r *Recorder) {
+	defaultMu.Lock()
+	defaultRecorder = r
+	defaultMu.Unlock()
+}
+
+// Record 记录快照：与同一来源最近的快照相同时只更新看到的时间，否则追加
+func (r *Recorder) Record(snapshot types.RegistrationSnapshot) error {
+	now := r.Now().UTC().Truncate(time.Millisecond)
+	snapshot = normalize(snapshot)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshots, err := r.Store.Snapshots(snapshot.Domain)
+	if err != nil {
+		return err
+	}
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshots[i].Source != snapshot.Source {
+			continue
+		}
+		if sameContent(snapshots[i], snapshot) {
+			return r.Store.TouchSnapshot(snapshot.Domain, snapshot.Source, snapshots[i].CapturedAt, now)
+		}
+		break
+	}
+
+	snapshot.CapturedAt, snapshot.LastSeenAt = now, now
+	return r.Store.AddSnapshot(snapshot)
+}
+
+// Stale 域名还没有该来源的快照，或最近一次记录已超过 MaxAge；读取失败时也视为过期
+func (r *Recorder) Stale(domain, source string) bool {
+	snapshots, err := r.Store.Snapshots(normalizeDomain(domain))
+	if err != nil {
+		return true
+	}
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshots[i].Source == source {
+			return r.Now().Sub(snapshots[i].LastSeenAt) > r.MaxAge
+		}
+	}
+	return true
+}
+
+// History 域名的全部快照和相邻快照之间的变化
+func (r *Recorder) History(domain string) ([]types.RegistrationSnapshot, []types.RegistrationChange, error) {
+	snapshots, err := r.Store.Snapshots(normalizeDomain(domain))
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshots, Diff(snapshots), nil
+}
@@ -0,0 +1,143 @@
+package history
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"domain-agent/backend/internal/rdap"
+	"domain-agent/backend/internal/store"
+	"domain-agent/backend/internal/types"
+)
+
+const domain = "example.test"
+
+// steps 依次记录的快照，每一步时钟前进一天
+var steps = []types.RegistrationSnapshot{
+	FromWHOIS(&types.Registration{Domain: domain, Registered: true, Registrar: "Alpha Registrar", CreatedAt: "2015-03-01T10:00:00Z",
+		ExpiresAt: "2025-03-01T10:00:00Z", NameServers: []string{"ns1.alpha.test", "ns2.alpha.test"}, Status: []string{"clientTransferProhibited"}}),
+	// 同样的内容，日期和大小写写法不同：只更新看到的时间
+	FromWHOIS(&types.Registration{Domain: "Example.TEST", Registered: true, Registrar: "Alpha Registrar", CreatedAt: "2015-03-01",
+		ExpiresAt: "01-Mar-2025", NameServers: []string{"NS2.alpha.test.", "ns1.alpha.test"}, Status: []string{"clientTransferProhibited"}}),
+	FromRDAP(domain, &rdap.Domain{Registrar: "Alpha Registrar", CreatedAt: "2015-03-01T10:00:00Z", ExpiresAt: "2025-03-01T10:00:00Z",
+		NameServers: []string{"ns1.alpha.test", "ns2.alpha.test"}, Status: []string{"client transfer prohibited"}}),
+	FromDelegation(domain, &types.DelegationEvidence{Referral: true, NameServers: []string{"ns1.alpha.test", "ns2.alpha.test"}}),
+	// 续费
+	FromWHOIS(&types.Registration{Domain: domain, Registered: true, Registrar: "Alpha Registrar", CreatedAt: "2015-03-01",
+		ExpiresAt: "2026-03-01", NameServers: []string{"ns1.alpha.test", "ns2.alpha.test"}, Status: []string{"clientTransferProhibited"}}),
+	// 转移注册商并更换名称服务器
+	FromWHOIS(&types.Registration{Domain: domain, Registered: true, Registrar: "Beta Names", CreatedAt: "2015-03-01",
+		ExpiresAt: "2027-03-01", NameServers: []string{"ns1.beta.test", "ns2.beta.test"}, Status: []string{"clientTransferProhibited"}}),
+	FromDelegation(domain, &types.DelegationEvidence{Referral: true, NameServers: []string{"ns1.beta.test", "ns2.beta.test"}}),
+	// 过期删除，再被重新注册
+	FromWHOIS(&types.Registration{Domain: domain}),
+	FromWHOIS(&types.Registration{Domain: domain, Registered: true, Registrar: "Gamma Domains", CreatedAt: "2028-05-01",
+		ExpiresAt: "2029-05-01", NameServers: []string{"ns1.gamma.test"}}),
+}
+
+// wantChanges 期望的变化（来源:类型）
+var wantChanges = []string{
+	"whois:expiry_extended",
+	"whois:registrar_changed", "whois:nameservers_changed", "whois:expiry_extended",
+	"delegation:nameservers_changed",
+	"whois:released",
+	"whois:registered",
+}
+
+func TestRecordAndDiff(t *testing.T) {
+	stores := map[string]func(t *testing.T) store.HistoryStore{
+		"memory": func(t *testing.T) store.HistoryStore { return store.NewMemoryHistory() },
+		"sqlite": func(t *testing.T) store.HistoryStore {
+			s, err := store.OpenHistory("sqlite://" + filepath.Join(t.TempDir(), "history.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := open(t)
+			defer s.Close()
+
+			clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			r := New(s)
+			r.Now = func() time.Time { return clock }
+			for _, snapshot := range steps {
+				if err := r.Record(snapshot); err != nil {
+					t.Fatalf("record: %v", err)
+				}
+				clock = clock.Add(24 * time.Hour)
+			}
+
+			snapshots, changes, err := r.History("EXAMPLE.test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 第二步与第一步内容相同，只更新看到的时间
+			if len(snapshots) != len(steps)-1 {
+				t.Fatalf("%d snapshots, want %d", len(snapshots), len(steps)-1)
+			}
+			if first := snapshots[0]; first.LastSeenAt.Sub(first.CapturedAt) != 24*time.Hour || first.ExpiresAt != "2025-03-01" {
+				t.Errorf("first snapshot: captured %s, last seen %s, expires %s", first.CapturedAt, first.LastSeenAt, first.ExpiresAt)
+			}
+
+			var got []string
+			for _, c := range changes {
+				got = append(got, c.Source+":"+c.Kind)
+			}
+			if strings.Join(got, " ") != strings.Join(wantChanges, " ") {
+				t.Errorf("changes = %v, want %v", got, wantChanges)
+			}
+		})
+	}
+}
+
+func TestStale(t *testing.T) {
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New(store.NewMemoryHistory())
+	r.Now = func() time.Time { return clock }
+	r.MaxAge = 7 * 24 * time.Hour
+
+	if !r.Stale(domain, SourceWHOIS) {
+		t.Error("no snapshot: want stale")
+	}
+	if err := r.Record(steps[0]); err != nil {
+		t.Fatal(err)
+	}
+	if r.Stale("Example.Test", SourceWHOIS) || !r.Stale(domain, SourceRDAP) {
+		t.Error("after a WHOIS snapshot: want whois fresh and rdap stale")
+	}
+
+	// 内容没有变化的记录也会更新看到的时间
+	clock = clock.Add(6 * 24 * time.Hour)
+	if err := r.Record(steps[1]); err != nil {
+		t.Fatal(err)
+	}
+	clock = clock.Add(6 * 24 * time.Hour)
+	if r.Stale(domain, SourceWHOIS) {
+		t.Error("seen 6 days ago: want fresh")
+	}
+	clock = clock.Add(2 * 24 * time.Hour)
+	if !r.Stale(domain, SourceWHOIS) {
+		t.Error("seen 8 days ago: want stale")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	previous := Default()
+	t.Cleanup(func() { SetDefault(previous) })
+
+	// 与会话存储相同：配置的存储打不开时返回错误，不悄悄改用内存
+	if err := Open("mysql://localhost/db"); err == nil || Default() != previous {
+		t.Errorf("Open(mysql) = %v, default replaced: %v", err, Default() != previous)
+	}
+	if err := Open("sqlite://" + filepath.Join(t.TempDir(), "history.db")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Default().Store.(*store.SQLHistory); !ok {
+		t.Errorf("default store = %T, want the sqlite store", Default().Store)
+	}
+	Default().Store.Close()
+}
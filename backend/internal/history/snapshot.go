@@ -0,0 +1,129 @@
+package history
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"domain-agent/backend/internal/rdap"
+	"domain-agent/backend/internal/types"
+)
+
+// FromWHOIS 由 WHOIS 解析出的注册信息生成快照
+func FromWHOIS(reg *types.Registration) types.RegistrationSnapshot {
+	return types.RegistrationSnapshot{
+		Domain:      reg.Domain,
+		Source:      SourceWHOIS,
+		Registered:  reg.Registered,
+		Registrar:   reg.Registrar,
+		Registrant:  reg.Registrant,
+		CreatedAt:   reg.CreatedAt,
+		ExpiresAt:   reg.ExpiresAt,
+		NameServers: reg.NameServers,
+		Status:      reg.Status,
+	}
+}
+
+// FromRDAP 由 RDAP 查询结果生成快照，d 为空表示注册局返回了不存在
+func FromRDAP(domain string, d *rdap.Domain) types.RegistrationSnapshot {
+	if d == nil {
+		return types.RegistrationSnapshot{Domain: domain, Source: SourceRDAP}
+	}
+	return types.RegistrationSnapshot{
+		Domain:      domain,
+		Source:      SourceRDAP,
+		Registered:  true,
+		Registrar:   d.Registrar,
+		CreatedAt:   d.CreatedAt,
+		ExpiresAt:   d.ExpiresAt,
+		NameServers: d.NameServers,
+		Status:      d.Status,
+	}
+}
+
+// FromDelegation 由后缀服务器的委派回答生成快照，只包含名称服务器
+func FromDelegation(domain string, d *types.DelegationEvidence) types.RegistrationSnapshot {
+	return types.RegistrationSnapshot{
+		Domain:      domain,
+		Source:      SourceDelegation,
+		Registered:  d.Referral,
+		NameServers: d.NameServers,
+	}
+}
+
+// normalize 统一大小写、日期格式和列表顺序
+func normalize(s types.RegistrationSnapshot) types.RegistrationSnapshot {
+	s.Domain = normalizeDomain(s.Domain)
+	s.Registrar = strings.TrimSpace(s.Registrar)
+	s.Registrant = strings.TrimSpace(s.Registrant)
+	s.CreatedAt = normalizeDate(s.CreatedAt)
+	s.ExpiresAt = normalizeDate(s.ExpiresAt)
+	s.NameServers = normalizeList(s.NameServers, func(ns string) string { return strings.TrimSuffix(ns, ".") })
+	// WHOIS 写作 clientTransferProhibited，RDAP 写作 client transfer prohibited
+	s.Status = normalizeList(s.Status, func(st string) string { return strings.ReplaceAll(st, " ", "") })
+	return s
+}
+
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+}
+
+func normalizeList(values []string, fn func(string) string) []string {
+	var out []string
+	for _, v := range values {
+		v = fn(strings.ToLower(strings.TrimSpace(v)))
+		if v != "" && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// dateLayouts WHOIS 和 RDAP 中常见的日期格式
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02",
+	"02-Jan-2006",
+	"2006.01.02",
+	"2006/01/02",
+	"02.01.2006",
+	"January 2 2006",
+	"2006年01月02日",
+}
+
+// normalizeDate 把日期规范化为 YYYY-MM-DD，无法识别时保留原文
+func normalizeDate(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC().Format("2006-01-02")
+		}
+	}
+	// 带时区名或额外说明的日期（如 "2025-08-13 04:00:00 UTC"），只取日期部分再试
+	if fields := strings.Fields(s); len(fields) > 1 {
+		if t, err := time.Parse("2006-01-02", fields[0]); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return s
+}
+
+// sameContent 两份快照的内容是否相同（不比较时间）
+func sameContent(a, b types.RegistrationSnapshot) bool {
+	return a.Registered == b.Registered &&
+		a.Registrar == b.Registrar &&
+		a.Registrant == b.Registrant &&
+		a.CreatedAt == b.CreatedAt &&
+		a.ExpiresAt == b.ExpiresAt &&
+		slices.Equal(a.NameServers, b.NameServers) &&
+		slices.Equal(a.Status, b.Status)
+}
@@ -0,0 +1,239 @@
+package intent
+
+import (
+	"bufio"
+	_ "embed"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// 内置意图
+const (
+	CheckSpecific = "check_specific"
+	GenerateIdeas = "generate_ideas"
+	Greeting      = "greeting"
+	General       = "general"
+	Compare       = "compare"
+	ExplainTaken  = "explain_taken"
+	Refine        = "refine"
+	Alternatives  = "alternatives"
+	SocialHandles = "social_handles"
+	PackageNames  = "package_names"
+)
+
+// genericIntents 不针对具体域名的意图；消息里出现域名或指代时不采用
+var genericIntents = map[string]bool{
+	GenerateIdeas: true,
+	Greeting:      true,
+	General:       true,
+}
+
+// 分类结果来源
+const (
+	SourceRule  = "rule"  // 正则规则命中
+	SourceModel = "model" // 本地朴素贝叶斯模型
+	SourceLLM   = "llm"   // LLM 兜底
+)
+
+//go:embed training.txt
+var trainingData string
+
+// DomainPattern 消息中的完整域名（不要求后缀在常见列表中）
+//
+// 分类和 agent 从消息中提取域名共用，两者对同一条消息看到的域名一致。
+var DomainPattern = regexp.MustCompile(`(?i)\b[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?(?:\.[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?)*\.[a-z]{2,24}\b`)
+
+// Result 意图分类结果
+type Result struct {
+	Intent     string             `json:"intent"`
+	Confidence float64            `json:"confidence"`
+	Scores     map[string]float64 `json:"scores,omitempty"`
+	Source     string             `json:"source"`
+}
+
+// Classifier 本地意图分类器：先匹配正则规则，再用朴素贝叶斯模型打分
+//
+// 模型以字符二元组和英文单词为特征，训练数据为内置样例，可通过 Train 追加。
+type Classifier struct {
+	mu          sync.RWMutex
+	docCount    map[string]int
+	tokenCount  map[string]map[string]int
+	totalTokens map[string]int
+	vocab       map[string]bool
+	docs        int
+}
+
+// NewClassifier 创建分类器，并用内置样例训练
+func NewClassifier() *Classifier {
+	c := &Classifier{
+		docCount:    make(map[string]int),
+		tokenCount:  make(map[string]map[string]int),
+		totalTokens: make(map[string]int),
+		vocab:       make(map[string]bool),
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(trainingData))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		label, text, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		c.Train(strings.TrimSpace(label), text)
+	}
+
+	return c
+}
+
+// Train 追加一条训练样例
+func (c *Classifier) Train(label, text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokenCount[label] == nil {
+		c.tokenCount[label] = make(map[string]int)
+	}
+	c.docCount[label]++
+	c.docs++
+	for _, token := range tokenize(text) {
+		c.tokenCount[label][token]++
+		c.totalTokens[label]++
+		c.vocab[token] = true
+	}
+}
+
+// Labels 返回模型已知的意图
+func (c *Classifier) Labels() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	labels := make([]string, 0, len(c.docCount))
+	for label := range c.docCount {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// Classify 对消息分类；hasReferences 表示消息指代了上一轮建议中的域名
+//
+// 消息包含完整域名时，除非模型判断为比较、查注册信息等针对域名的意图，
+// 否则按 check_specific 处理；指代了上一轮建议时同理。
+func (c *Classifier) Classify(message string, hasReferences bool) Result {
+	result := c.score(message)
+
+	if DomainPattern.MatchString(message) {
+		if result.Intent == CheckSpecific || genericIntents[result.Intent] || result.Confidence == 0 {
+			return Result{Intent: CheckSpecific, Confidence: 0.95, Scores: result.Scores, Source: SourceRule}
+		}
+		return result
+	}
+
+	if hasReferences && (result.Intent == Greeting || result.Intent == General) {
+		// “查一下第三个”之类的追问
+		return Result{Intent: CheckSpecific, Confidence: math.Max(result.Confidence, 0.85), Scores: result.Scores, Source: SourceRule}
+	}
+	return result
+}
+
+// score 朴素贝叶斯打分
+//
+// 对数似然按特征数取平均后再做 softmax，避免长消息产生过度自信的概率；
+// 没有任何已知特征时返回置信度 0，交由 LLM 判断。
+func (c *Classifier) score(message string) Result {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var known []string
+	for _, token := range tokenize(message) {
+		if c.vocab[token] {
+			known = append(known, token)
+		}
+	}
+	if len(known) == 0 || c.docs == 0 {
+		return Result{Intent: General, Confidence: 0, Source: SourceModel}
+	}
+
+	const sharpness = 4.0
+	vocabSize := float64(len(c.vocab))
+	logScores := make(map[string]float64, len(c.docCount))
+	for label, docs := range c.docCount {
+		likelihood := 0.0
+		for _, token := range known {
+			p := (float64(c.tokenCount[label][token]) + 1) / (float64(c.totalTokens[label]) + vocabSize)
+			likelihood += math.Log(p)
+		}
+		prior := math.Log(float64(docs) / float64(c.docs))
+		logScores[label] = prior/float64(len(known)) + likelihood/float64(len(known))
+	}
+
+	// softmax
+	maxScore := math.Inf(-1)
+	for _, s := range logScores {
+		maxScore = math.Max(maxScore, s)
+	}
+	sum := 0.0
+	scores := make(map[string]float64, len(logScores))
+	for label, s := range logScores {
+		scores[label] = math.Exp((s - maxScore) * sharpness)
+		sum += scores[label]
+	}
+
+	best, bestScore := General, -1.0
+	for label := range scores {
+		scores[label] /= sum
+		if scores[label] > bestScore || (scores[label] == bestScore && label < best) {
+			best, bestScore = label, scores[label]
+		}
+	}
+
+	return Result{Intent: best, Confidence: bestScore, Scores: scores, Source: SourceModel}
+}
+
+// tokenize 特征提取：英文/数字按单词，中文取单字和相邻二元组
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var han []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		for i, r := range han {
+			tokens = append(tokens, string(r))
+			if i+1 < len(han) {
+				tokens = append(tokens, string(han[i:i+2]))
+			}
+		}
+		han = han[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+
+	return tokens
+}
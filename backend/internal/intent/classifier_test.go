@@ -0,0 +1,92 @@
+package intent
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestClassifyRules(t *testing.T) {
+	c := NewClassifier()
+	tests := []struct {
+		message       string
+		hasReferences bool
+		want          string
+		source        string
+	}{
+		{"kitleaf.com 能注册吗", false, CheckSpecific, SourceRule},
+		{"帮我想几个 shop.com.cn 这样的", false, CheckSpecific, SourceRule},
+		// 后缀不在常见列表中，agent 同样会提取出这个域名
+		{"kitleaf.fun 还能注册吗", false, CheckSpecific, SourceRule},
+		{"谢谢，那个呢", true, CheckSpecific, SourceRule},
+	}
+	for _, tt := range tests {
+		got := c.Classify(tt.message, tt.hasReferences)
+		if got.Intent != tt.want || got.Source != tt.source {
+			t.Errorf("Classify(%q) = %s (%s), want %s (%s)", tt.message, got.Intent, got.Source, tt.want, tt.source)
+		}
+	}
+}
+
+func TestClassifyModel(t *testing.T) {
+	c := NewClassifier()
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"你好呀", Greeting},
+		{"帮我想几个咖啡店的域名", GenerateIdeas},
+		{"这个域名能不能注册", CheckSpecific},
+		{"recommend some short brand names", GenerateIdeas},
+	}
+	for _, tt := range tests {
+		got := c.Classify(tt.message, false)
+		if got.Intent != tt.want || got.Source != SourceModel || got.Confidence <= 0.5 {
+			t.Errorf("Classify(%q) = %+v, want %s", tt.message, got, tt.want)
+		}
+	}
+
+	// 没有任何已知特征时置信度为 0，交给 LLM
+	if got := c.Classify("zzqx vvkp", false); got.Confidence != 0 {
+		t.Errorf("Classify(unknown) = %+v, want confidence 0", got)
+	}
+}
+
+// TestTrainingDataSelfConsistent 每条训练样例都应被分到自己的意图，防止新增样例互相冲突
+func TestTrainingDataSelfConsistent(t *testing.T) {
+	c := NewClassifier()
+	scanner := bufio.NewScanner(strings.NewReader(trainingData))
+	wrong, total := 0, 0
+	for scanner.Scan() {
+		label, text, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok || strings.HasPrefix(label, "#") {
+			continue
+		}
+		total++
+		if got := c.score(text); got.Intent != label {
+			wrong++
+			t.Logf("%s: %q classified as %s (%.2f)", label, text, got.Intent, got.Confidence)
+		}
+	}
+	// 朴素贝叶斯不保证拟合全部样例，但大多数应分类正确
+	if wrong*10 > total {
+		t.Errorf("%d of %d training examples misclassified", wrong, total)
+	}
+}
+
+func TestTrain(t *testing.T) {
+	c := NewClassifier()
+	for _, text := range []string{"比较一下这两个", "哪个更好", "对比这几个域名"} {
+		c.Train(Compare, text)
+	}
+	if got := c.Classify("比较一下哪个更好", false); got.Intent != Compare {
+		t.Errorf("Classify after Train = %+v, want %s", got, Compare)
+	}
+	found := false
+	for _, l := range c.Labels() {
+		found = found || l == Compare
+	}
+	if !found {
+		t.Errorf("Labels() = %v, want %s", c.Labels(), Compare)
+	}
+}
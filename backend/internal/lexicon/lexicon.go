@@ -0,0 +1,231 @@
+// Package lexicon 关键词处理：中文分词、中译英、拼音和英文近义词扩展
+//
+// 词典和近义词表随程序内置（zh_en.txt、thesaurus.txt、stopwords.txt），
+// 用于把“我想要一个关于咖啡的域名”这样的输入变成 coffee、brew、kafei 等可用于域名的词。
+package lexicon
+
+import (
+	"bufio"
+	_ "embed"
+	"sort"
+	"strings"
+)
+
+var (
+	//go:embed zh_en.txt
+	zhEnData string
+	//go:embed thesaurus.txt
+	thesaurusData string
+	//go:embed stopwords.txt
+	stopWordsData string
+)
+
+// Entry 中文词条
+type Entry struct {
+	Word         string   // 中文词
+	Pinyin       string   // 不带声调、不带空格的拼音
+	Translations []string // 英文译名，常用的在前
+}
+
+var (
+	dictionary = map[string]Entry{}
+	thesaurus  = map[string][]string{}
+	stopWords  = map[string]bool{}
+
+	// maxWordLen 词典中最长词的字数，分词时的最大匹配窗口
+	maxWordLen = 1
+)
+
+func init() {
+	eachLine(zhEnData, func(fields []string) {
+		if len(fields) < 3 {
+			return
+		}
+		e := Entry{Word: fields[0], Pinyin: fields[1], Translations: splitList(fields[2])}
+		dictionary[e.Word] = e
+		if n := len([]rune(e.Word)); n > maxWordLen {
+			maxWordLen = n
+		}
+	})
+
+	eachLine(thesaurusData, func(fields []string) {
+		if len(fields) < 2 {
+			return
+		}
+		thesaurus[fields[0]] = splitList(fields[1])
+	})
+
+	eachLine(stopWordsData, func(fields []string) {
+		for _, line := range fields {
+			for _, w := range strings.Fields(line) {
+				stopWords[w] = true
+				if n := len([]rune(w)); n > maxWordLen {
+					maxWordLen = n
+				}
+			}
+		}
+	})
+}
+
+// eachLine 逐行解析内置数据，跳过空行和 # 注释，字段以 Tab 分隔
+func eachLine(data string, fn func(fields []string)) {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fn(strings.Split(line, "\t"))
+	}
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Lookup 查询中文词条
+func Lookup(word string) (Entry, bool) {
+	e, ok := dictionary[word]
+	return e, ok
+}
+
+// Synonyms 返回英文词的近义词，没有时返回 nil
+func Synonyms(word string) []string {
+	return thesaurus[strings.ToLower(word)]
+}
+
+// Headwords 返回近义词表中的全部词头（已排序）
+func Headwords() []string {
+	words := make([]string, 0, len(thesaurus))
+	for w := range thesaurus {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// IsWord 是否为近义词表中出现过的英文词（词头或近义词）
+func IsWord(word string) bool {
+	if _, ok := thesaurus[word]; ok {
+		return true
+	}
+	for _, synonyms := range thesaurus {
+		for _, w := range synonyms {
+			if w == word {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsStopWord 是否为停用词
+func IsStopWord(word string) bool {
+	return stopWords[strings.ToLower(word)]
+}
+
+// Keywords 从自然语言中提取关键词：分词后去掉停用词、标点和单个汉字
+//
+// 返回的是原文中的词（中文保持中文），需要英文时再调用 Translate 或 Expand。
+func Keywords(text string) []string {
+	var keywords []string
+	seen := map[string]bool{}
+	for _, token := range Segment(text) {
+		if IsStopWord(token) || seen[token] {
+			continue
+		}
+		if isHan(token) && len([]rune(token)) < 2 {
+			if _, known := dictionary[token]; !known {
+				continue
+			}
+		}
+		seen[token] = true
+		keywords = append(keywords, token)
+	}
+	return keywords
+}
+
+// Translate 把关键词转换为可用于域名的英文词
+//
+// 中文词先分词，再替换为英文译名和拼音；英文词原样保留（小写）。
+// 无法翻译的中文词会被丢弃。结果已去重，按“译名、拼音”的顺序排列。
+func Translate(words []string) []string {
+	return expand(words, false)
+}
+
+// Expand 在 Translate 的基础上追加每个词的英文近义词
+//
+// 顺序为：原词及首选译名、拼音、近义词、其余译名，
+// 调用方截断列表时优先保留最相关的词。
+func Expand(words []string) []string {
+	return expand(words, true)
+}
+
+func expand(words []string, withSynonyms bool) []string {
+	var primary, pinyin, synonyms, secondary []string
+	seen := map[string]bool{}
+	add := func(list *[]string, w string) {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" || seen[w] || !isASCIIWord(w) {
+			return
+		}
+		seen[w] = true
+		*list = append(*list, w)
+	}
+	addSynonyms := func(w string) {
+		if !withSynonyms {
+			return
+		}
+		for _, s := range Synonyms(w) {
+			add(&synonyms, s)
+		}
+	}
+
+	for _, word := range words {
+		for _, token := range Keywords(word) {
+			if !isHan(token) {
+				add(&primary, token)
+				addSynonyms(token)
+				continue
+			}
+			e, ok := dictionary[token]
+			if !ok {
+				continue
+			}
+			add(&pinyin, e.Pinyin)
+			if len(e.Translations) == 0 {
+				continue
+			}
+			add(&primary, e.Translations[0])
+			addSynonyms(e.Translations[0])
+			for _, t := range e.Translations[1:] {
+				add(&secondary, t)
+			}
+		}
+	}
+
+	result := make([]string, 0, len(primary)+len(pinyin)+len(synonyms)+len(secondary))
+	result = append(result, primary...)
+	result = append(result, pinyin...)
+	result = append(result, synonyms...)
+	return append(result, secondary...)
+}
+
+// isASCIIWord 是否只包含小写字母、数字和词中的连字符（可直接作为域名的一部分）
+func isASCIIWord(w string) bool {
+	if strings.HasPrefix(w, "-") || strings.HasSuffix(w, "-") {
+		return false
+	}
+	for _, r := range w {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return w != ""
+}
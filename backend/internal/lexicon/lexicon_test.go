@@ -0,0 +1,120 @@
+package lexicon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSegment(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"我想要一个咖啡店的域名", []string{"我", "想要", "一个", "咖啡店", "的", "域名"}},
+		{"AI 编程助手", []string{"ai", "编程", "助手"}},
+		{"e-commerce 平台", []string{"e-commerce", "平台"}},
+		{"tools- and -more--fun", []string{"tools", "and", "more", "fun"}},
+	}
+	for _, tt := range tests {
+		if got := Segment(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Segment(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"我想要一个关于咖啡的域名", []string{"咖啡"}},
+		{"please suggest a domain for my coffee shop", []string{"coffee", "shop"}},
+		{"帮我想几个 e-commerce 的名字", []string{"e-commerce"}},
+	}
+	for _, tt := range tests {
+		if got := Keywords(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Keywords(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		// 首选译名、拼音，其余译名放在最后
+		{[]string{"咖啡"}, []string{"coffee", "kafei", "cafe"}},
+		{[]string{"Coffee", "咖啡"}, []string{"coffee", "kafei", "cafe"}},
+		{[]string{"e-commerce"}, []string{"e-commerce"}},
+		// 词典外的中文词被丢弃
+		{[]string{"齉"}, []string{}},
+	}
+	for _, tt := range tests {
+		if got := Translate(tt.words); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Translate(%v) = %v, want %v", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	got := Expand([]string{"咖啡"})
+	if len(got) < 4 || got[0] != "coffee" || got[1] != "kafei" {
+		t.Fatalf("Expand(咖啡) = %v", got)
+	}
+	seen := map[string]bool{}
+	for _, w := range got {
+		if seen[w] {
+			t.Errorf("Expand(咖啡) has duplicate %q", w)
+		}
+		seen[w] = true
+	}
+	for _, w := range []string{"brew", "roast"} {
+		if !seen[w] {
+			t.Errorf("Expand(咖啡) = %v, missing synonym %q", got, w)
+		}
+	}
+}
+
+// TestExpandEntryWithoutTranslations 没有译名的词条只贡献拼音，不能越界
+func TestExpandEntryWithoutTranslations(t *testing.T) {
+	dictionary["测试词"] = Entry{Word: "测试词", Pinyin: "ceshici"}
+	defer delete(dictionary, "测试词")
+
+	if got := Expand([]string{"测试词"}); !reflect.DeepEqual(got, []string{"ceshici"}) {
+		t.Errorf("Expand = %v, want [ceshici]", got)
+	}
+}
+
+func TestIsASCIIWord(t *testing.T) {
+	for w, want := range map[string]bool{
+		"coffee":      true,
+		"web3":        true,
+		"e-commerce":  true,
+		"-coffee":     false,
+		"coffee-":     false,
+		"coffee shop": false,
+		"Coffee":      false,
+		"咖啡":          false,
+		"":            false,
+	} {
+		if got := isASCIIWord(w); got != want {
+			t.Errorf("isASCIIWord(%q) = %v, want %v", w, got, want)
+		}
+	}
+}
+
+func TestSynonymsAndStopWords(t *testing.T) {
+	if got := Synonyms("Coffee"); len(got) == 0 || got[0] != "brew" {
+		t.Errorf("Synonyms(Coffee) = %v", got)
+	}
+	if !IsWord("roast") || IsWord("zzqx") {
+		t.Errorf("IsWord mismatch")
+	}
+	if !IsStopWord("The") || IsStopWord("coffee") {
+		t.Errorf("IsStopWord mismatch")
+	}
+	if e, ok := Lookup("咖啡"); !ok || e.Pinyin != "kafei" {
+		t.Errorf("Lookup(咖啡) = %+v, %v", e, ok)
+	}
+}
@@ -0,0 +1,147 @@
+package lexicon
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Segment 分词：英文和数字按单词切分（转小写，保留词中的连字符），中文用双向最大匹配
+//
+// 正向和逆向最大匹配各切一次，取词数更少的结果；词数相同时取单字更少的，
+// 仍相同时取逆向结果（逆向对中文的歧义处理通常更好）。
+// 词典中没有的连续单字会合并为一个词，避免把未登录词切成零散的字。
+func Segment(text string) []string {
+	var tokens []string
+	var han, word []rune
+
+	flushHan := func() {
+		if len(han) > 0 {
+			tokens = append(tokens, segmentHan(han)...)
+			han = han[:0]
+		}
+	}
+	flushWord := func() {
+		if w := strings.TrimRight(string(word), "-"); w != "" {
+			tokens = append(tokens, strings.ToLower(w))
+		}
+		word = word[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			flushHan()
+			word = append(word, r)
+		case r == '-' && len(word) > 0 && word[len(word)-1] != '-':
+			// e-commerce 这样的连字符词作为一个词，连续的连字符处断开
+			word = append(word, r)
+		default:
+			flushHan()
+			flushWord()
+		}
+	}
+	flushHan()
+	flushWord()
+
+	return tokens
+}
+
+// segmentHan 对一段连续汉字做双向最大匹配
+func segmentHan(runes []rune) []string {
+	forward := mergeUnknown(forwardMatch(runes))
+	backward := mergeUnknown(backwardMatch(runes))
+
+	switch {
+	case len(forward) < len(backward):
+		return forward
+	case len(forward) > len(backward):
+		return backward
+	case singles(forward) < singles(backward):
+		return forward
+	default:
+		return backward
+	}
+}
+
+func forwardMatch(runes []rune) []string {
+	var tokens []string
+	for i := 0; i < len(runes); {
+		n := min(maxWordLen, len(runes)-i)
+		for ; n > 1; n-- {
+			if isKnown(string(runes[i : i+n])) {
+				break
+			}
+		}
+		tokens = append(tokens, string(runes[i:i+n]))
+		i += n
+	}
+	return tokens
+}
+
+func backwardMatch(runes []rune) []string {
+	var tokens []string
+	for j := len(runes); j > 0; {
+		n := min(maxWordLen, j)
+		for ; n > 1; n-- {
+			if isKnown(string(runes[j-n : j])) {
+				break
+			}
+		}
+		tokens = append(tokens, string(runes[j-n:j]))
+		j -= n
+	}
+	for i, k := 0, len(tokens)-1; i < k; i, k = i+1, k-1 {
+		tokens[i], tokens[k] = tokens[k], tokens[i]
+	}
+	return tokens
+}
+
+// mergeUnknown 合并相邻的未登录单字
+func mergeUnknown(tokens []string) []string {
+	var merged []string
+	var pending strings.Builder
+	flush := func() {
+		if pending.Len() > 0 {
+			merged = append(merged, pending.String())
+			pending.Reset()
+		}
+	}
+
+	for _, t := range tokens {
+		if len([]rune(t)) == 1 && !isKnown(t) {
+			pending.WriteString(t)
+			continue
+		}
+		flush()
+		merged = append(merged, t)
+	}
+	flush()
+	return merged
+}
+
+// singles 单字词的个数
+func singles(tokens []string) int {
+	n := 0
+	for _, t := range tokens {
+		if len([]rune(t)) == 1 {
+			n++
+		}
+	}
+	return n
+}
+
+func isKnown(word string) bool {
+	_, ok := dictionary[word]
+	return ok || stopWords[word]
+}
+
+// isHan 是否以汉字开头（分词后的词要么全是汉字，要么全是英文数字）
+func isHan(token string) bool {
+	for _, r := range token {
+		return unicode.Is(unicode.Han, r)
+	}
+	return false
+}
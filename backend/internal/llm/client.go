@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -14,6 +15,7 @@ type Client struct {
 	apiKey     string
 	baseURL    string
 	model      string
+	jsonMode   string
 	httpClient *http.Client
 }
 
@@ -23,10 +25,11 @@ type Message struct {
 }
 
 type ChatRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Temperature float64   `json:"temperature,omitempty"`
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Temperature    float64         `json:"temperature,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 }
 
 type ChatResponse struct {
@@ -44,6 +47,41 @@ type Choice struct {
 	Finish  string  `json:"finish_reason"`
 }
 
+// APIError LLM 服务返回的非 200 响应
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// IntentOption 可供 LLM 选择的意图
+type IntentOption struct {
+	Name        string
+	Description string
+}
+
+// IntentAnalysis LLM 的意图分析结果
+type IntentAnalysis struct {
+	Intent     string  `json:"intent"`
+	Confidence float64 `json:"confidence"`
+}
+
+// DomainIdeas LLM 生成的域名创意
+type DomainIdeas struct {
+	Suggestions []DomainIdea `json:"suggestions" jsonschema:"minItems=1"`
+	Summary     string       `json:"summary,omitempty"`
+}
+
+// DomainIdea 单个域名创意
+type DomainIdea struct {
+	Domain string `json:"domain" jsonschema:"minLength=1"`
+	Reason string `json:"reason"`
+	Style  string `json:"style,omitempty"`
+}
+
 type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
@@ -51,21 +89,32 @@ type Usage struct {
 }
 
 func NewClient() *Client {
+	// VIBECODING_JSON_MODE: none / json_object / json_schema，取决于服务商是否支持
+	jsonMode := os.Getenv("VIBECODING_JSON_MODE")
+	if jsonMode == "" {
+		jsonMode = JSONModeNone
+	}
+
+	baseURL := os.Getenv("VIBECODING_BASE_URL")
+	if baseURL == "" {
+		baseURL = "https://vibecodingapi.ai/v1"
+	}
+
 	return &Client{
-		apiKey:  os.Getenv("VIBECODING_API_KEY"),
-		baseURL: "https://vibecodingapi.ai/v1",
-		model:   "gpt-4-gizmo-g-2fkFE8rbu",
+		apiKey:   os.Getenv("VIBECODING_API_KEY"),
+		baseURL:  baseURL,
+		model:    "gpt-4-gizmo-g-2fkFE8rbu",
+		jsonMode: jsonMode,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 	}
 }
 
-func (c *Client) GenerateDomainIdeas(userInput string) (string, error) {
-	if c.apiKey == "" {
-		return "", fmt.Errorf("VIBECODING_API_KEY not set")
-	}
-
+// GenerateDomainIdeas 生成域名创意，返回经过模式校验的结构化结果
+//
+// history 为之前的对话（不含本次输入），用于理解“短一点”“换个风格”等追问。
+func (c *Client) GenerateDomainIdeas(userInput string, history []Message) (*DomainIdeas, error) {
 	prompt := fmt.Sprintf(`你是一个专业的域名顾问。根据用户需求生成创意域名建议。
 
 用户需求：%s
@@ -76,43 +125,31 @@ func (c *Client) GenerateDomainIdeas(userInput string) (string, error) {
 3. 适合品牌使用
 4. 包含不同的风格（正式、创意、技术感等）
 
-请以JSON格式返回：
-{
-  "suggestions": [
-    {
-      "domain": "域名",
-      "reason": "推荐理由",
-      "style": "风格类型"
-    }
-  ],
-  "summary": "总结建议"
-}`, userInput)
-
-	messages := []Message{
-		{Role: "system", Content: "你是一个专业的域名顾问，擅长根据用户需求生成有创意的域名建议。"},
-		{Role: "user", Content: prompt},
-	}
+每个建议包含 domain（域名）、reason（推荐理由）、style（风格类型），最后给出 summary（总结建议）。`, userInput)
 
-	req := ChatRequest{
-		Model:       c.model,
+	messages := withHistory("你是一个专业的域名顾问，擅长根据用户需求生成有创意的域名建议。如果用户是在调整之前的建议，请参考对话历史。", history, prompt)
+
+	var ideas DomainIdeas
+	err := c.CompleteStructured(StructuredRequest{
+		Name:        "domain_ideas",
 		Messages:    messages,
 		MaxTokens:   1000,
 		Temperature: 0.7,
+	}, &ideas)
+	if err != nil {
+		return nil, err
 	}
 
-	return c.chat(req)
+	return &ideas, nil
 }
 
 // GenerateResponse 生成通用对话响应（不要求返回 JSON）
-func (c *Client) GenerateResponse(userInput string) (string, error) {
+func (c *Client) GenerateResponse(userInput string, history []Message) (string, error) {
 	if c.apiKey == "" {
 		return "", fmt.Errorf("VIBECODING_API_KEY not set")
 	}
 
-	messages := []Message{
-		{Role: "system", Content: "你是 Domain Agent，一个专业友好的域名查询助手。你可以帮助用户查询域名可用性和生成创意域名建议。请用简短自然的语言回应用户。"},
-		{Role: "user", Content: userInput},
-	}
+	messages := withHistory("你是 Domain Agent，一个专业友好的域名查询助手。你可以帮助用户查询域名可用性和生成创意域名建议。请用简短自然的语言回应用户。", history, userInput)
 
 	req := ChatRequest{
 		Model:       c.model,
@@ -124,41 +161,57 @@ func (c *Client) GenerateResponse(userInput string) (string, error) {
 	return c.chat(req)
 }
 
-func (c *Client) AnalyzeUserIntent(userInput string) (string, error) {
-	if c.apiKey == "" {
-		return "", fmt.Errorf("VIBECODING_API_KEY not set")
+// AnalyzeUserIntent 分析用户意图，从 options 中选出一个意图并给出置信度
+func (c *Client) AnalyzeUserIntent(userInput string, options []IntentOption, history []Message) (*IntentAnalysis, error) {
+	var list strings.Builder
+	names := make([]string, 0, len(options))
+	for _, o := range options {
+		list.WriteString(fmt.Sprintf("- %q: %s\n", o.Name, o.Description))
+		names = append(names, o.Name)
 	}
 
 	prompt := fmt.Sprintf(`分析用户输入的意图，返回以下类型之一：
-- "check_specific": 用户提供了具体的域名（如 google.com, abc.cn），想查询这些域名是否可用
-- "generate_ideas": 用户想要域名创意建议，或者想要生成/推荐相关的域名
-- "greeting": 问候语
-- "general": 一般咨询
-
+%s
 重要区分：
-- 如果用户提供了完整域名格式（包含 .com/.cn/.ai 等），选择 "check_specific"
+- 如果用户提供了完整域名格式（包含 .com/.cn/.ai 等）并想知道能否注册，选择 "check_specific"
 - 如果用户只提供了关键词或想法，想要生成域名建议，选择 "generate_ideas"
 - 例如："查询 google.com" → check_specific
 - 例如："查询有关 kitleaf 的域名" → generate_ideas
 - 例如："我想要科技感的域名" → generate_ideas
+- 如果用户在追问之前的结果（如“查一下第三个”），结合对话历史判断
 
 用户输入：%s
 
-只返回意图类型，不要其他内容。`, userInput)
+返回 intent（意图类型）和 confidence（0 到 1 之间的置信度）。`, list.String(), userInput)
 
-	messages := []Message{
-		{Role: "system", Content: "你是一个意图分析助手，专门分析用户的域名查询意图。请准确区分用户是想查询具体域名的可用性，还是想要生成域名建议。"},
-		{Role: "user", Content: prompt},
-	}
+	messages := withHistory("你是一个意图分析助手，专门分析用户的域名查询意图。请准确区分用户是想查询具体域名的可用性，还是想要生成域名建议。", history, prompt)
 
-	req := ChatRequest{
-		Model:       c.model,
+	var analysis IntentAnalysis
+	schema := SchemaFor(&analysis)
+	schema.Properties["intent"].Enum = names
+
+	err := c.CompleteStructured(StructuredRequest{
+		Name:        "intent",
 		Messages:    messages,
+		Schema:      schema,
 		MaxTokens:   50,
 		Temperature: 0.1,
+		MaxRetries:  1,
+	}, &analysis)
+	if err != nil {
+		return nil, err
 	}
 
-	return c.chat(req)
+	return &analysis, nil
+}
+
+// withHistory 组装消息：系统提示 + 对话历史 + 本次输入
+func withHistory(system string, history []Message, userInput string) []Message {
+	messages := make([]Message, 0, len(history)+2)
+	messages = append(messages, Message{Role: "system", Content: system})
+	messages = append(messages, history...)
+	messages = append(messages, Message{Role: "user", Content: userInput})
+	return messages
 }
 
 func (c *Client) chat(req ChatRequest) (string, error) {
@@ -183,7 +236,7 @@ func (c *Client) chat(req ChatRequest) (string, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return "", &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -0,0 +1,481 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 结构化输出的 JSON 模式
+const (
+	JSONModeNone   = "none"        // 不发送 response_format，仅靠提示词约束
+	JSONModeObject = "json_object" // OpenAI 风格的 JSON mode
+	JSONModeSchema = "json_schema" // 携带 JSON Schema 的 structured outputs
+)
+
+// defaultStructuredRetries 校验失败后的默认重试次数
+const defaultStructuredRetries = 2
+
+// ResponseFormat 对应 chat/completions 的 response_format 字段
+type ResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat response_format 为 json_schema 时携带的模式
+type JSONSchemaFormat struct {
+	Name   string  `json:"name"`
+	Schema *Schema `json:"schema"`
+	Strict bool    `json:"strict,omitempty"`
+}
+
+// Schema JSON Schema 的子集，足够描述和校验 LLM 的结构化输出
+type Schema struct {
+	Type        string             `json:"type,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	MinItems    int                `json:"minItems,omitempty"`
+	MaxItems    int                `json:"maxItems,omitempty"`
+	MinLength   int                `json:"minLength,omitempty"`
+}
+
+// StructuredRequest 结构化输出请求
+type StructuredRequest struct {
+	Name        string    // 模式名称，用于 response_format
+	Messages    []Message // 对话消息，系统提示中会自动追加模式说明
+	Schema      *Schema   // 为空时从输出类型推导
+	MaxTokens   int
+	Temperature float64
+	MaxRetries  int // 校验失败后的重试次数，0 使用默认值，负数表示不重试
+}
+
+// StructuredError 多次重试后仍无法得到符合模式的输出
+type StructuredError struct {
+	Name     string
+	Attempts int
+	Raw      string // 最后一次 LLM 原始输出
+	Err      error
+}
+
+func (e *StructuredError) Error() string {
+	return fmt.Sprintf("structured output %q invalid after %d attempt(s): %v", e.Name, e.Attempts, e.Err)
+}
+
+func (e *StructuredError) Unwrap() error {
+	return e.Err
+}
+
+// RawFromError 从结构化输出错误中取出 LLM 的原始文本（如有）
+func RawFromError(err error) string {
+	var se *StructuredError
+	if errors.As(err, &se) {
+		return se.Raw
+	}
+	return ""
+}
+
+// CompleteStructured 请求 LLM 返回符合模式的 JSON，并解析到 out
+//
+// 支持时通过 response_format 要求 JSON 输出；收到的内容会先提取 JSON，
+// 再按模式校验，不符合时把错误反馈给模型重新生成，最多重试 MaxRetries 次。
+func (c *Client) CompleteStructured(req StructuredRequest, out interface{}) error {
+	if c.apiKey == "" {
+		return fmt.Errorf("VIBECODING_API_KEY not set")
+	}
+
+	schema := req.Schema
+	if schema == nil {
+		schema = SchemaFor(out)
+	}
+	name := req.Name
+	if name == "" {
+		name = "response"
+	}
+	retries := req.MaxRetries
+	if retries == 0 {
+		retries = defaultStructuredRetries
+	}
+	if retries < 0 {
+		retries = 0
+	}
+
+	schemaJSON, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	messages := withSchemaInstruction(req.Messages, string(schemaJSON))
+	chatReq := ChatRequest{
+		Model:          c.model,
+		Messages:       messages,
+		MaxTokens:      req.MaxTokens,
+		Temperature:    req.Temperature,
+		ResponseFormat: c.responseFormat(name, schema),
+	}
+
+	var lastErr error
+	var raw string
+	attempts := 0
+	for attempts <= retries {
+		attempts++
+
+		content, err := c.chatWithFormatFallback(&chatReq)
+		if err != nil {
+			return err
+		}
+		raw = content
+
+		data, err := ExtractJSON(content)
+		if err == nil {
+			err = schema.Validate(data)
+		}
+		if err == nil {
+			if err = json.Unmarshal(data, out); err == nil {
+				return nil
+			}
+		}
+		lastErr = err
+
+		// 把错误反馈给模型，要求修正
+		chatReq.Messages = append(chatReq.Messages,
+			Message{Role: "assistant", Content: content},
+			Message{Role: "user", Content: fmt.Sprintf("你上一次的输出不符合要求：%v\n请只返回修正后的 JSON，不要包含其他内容。", err)},
+		)
+	}
+
+	return &StructuredError{Name: name, Attempts: attempts, Raw: raw, Err: lastErr}
+}
+
+// responseFormat 根据客户端配置生成 response_format
+func (c *Client) responseFormat(name string, schema *Schema) *ResponseFormat {
+	switch c.jsonMode {
+	case JSONModeObject:
+		return &ResponseFormat{Type: JSONModeObject}
+	case JSONModeSchema:
+		return &ResponseFormat{
+			Type:       JSONModeSchema,
+			JSONSchema: &JSONSchemaFormat{Name: name, Schema: schema},
+		}
+	default:
+		return nil
+	}
+}
+
+// chatWithFormatFallback 发送请求；如果服务商拒绝 response_format，则去掉后重试一次
+func (c *Client) chatWithFormatFallback(req *ChatRequest) (string, error) {
+	content, err := c.chat(*req)
+	if err == nil || req.ResponseFormat == nil {
+		return content, err
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == 400 {
+		fmt.Printf("LLM provider rejected response_format, retrying without it: %v\n", err)
+		req.ResponseFormat = nil
+		return c.chat(*req)
+	}
+	return "", err
+}
+
+// withSchemaInstruction 在系统提示中追加 JSON 模式说明
+func withSchemaInstruction(messages []Message, schemaJSON string) []Message {
+	instruction := "请只返回一个符合以下 JSON Schema 的 JSON 对象，不要使用 Markdown 代码块，也不要输出其他内容：\n" + schemaJSON
+
+	result := make([]Message, 0, len(messages)+1)
+	injected := false
+	for _, m := range messages {
+		if m.Role == "system" && !injected {
+			m.Content = m.Content + "\n\n" + instruction
+			injected = true
+		}
+		result = append(result, m)
+	}
+	if !injected {
+		result = append([]Message{{Role: "system", Content: instruction}}, result...)
+	}
+	return result
+}
+
+// ExtractJSON 从 LLM 输出中提取 JSON：整体解析、```json 代码块、首个完整的 {...} 片段
+func ExtractJSON(content string) ([]byte, error) {
+	content = strings.TrimSpace(content)
+	if json.Valid([]byte(content)) {
+		return []byte(content), nil
+	}
+
+	// 代码块
+	for _, fence := range []string{"```json", "```JSON", "```"} {
+		start := strings.Index(content, fence)
+		if start == -1 {
+			continue
+		}
+		start += len(fence)
+		end := strings.Index(content[start:], "```")
+		if end == -1 {
+			continue
+		}
+		block := strings.TrimSpace(content[start : start+end])
+		if json.Valid([]byte(block)) {
+			return []byte(block), nil
+		}
+	}
+
+	// 首个括号平衡的对象
+	if start := strings.Index(content, "{"); start != -1 {
+		depth := 0
+		inString := false
+		escaped := false
+	scan:
+		for i := start; i < len(content); i++ {
+			ch := content[i]
+			if inString {
+				switch {
+				case escaped:
+					escaped = false
+				case ch == '\\':
+					escaped = true
+				case ch == '"':
+					inString = false
+				}
+				continue
+			}
+			switch ch {
+			case '"':
+				inString = true
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					candidate := content[start : i+1]
+					if json.Valid([]byte(candidate)) {
+						return []byte(candidate), nil
+					}
+					break scan
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no JSON object found in response")
+}
+
+// Validate 按模式校验 JSON 数据
+func (s *Schema) Validate(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var problems []string
+	s.validate("$", value, &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("schema violation: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, value interface{}, problems *[]string) {
+	if s == nil {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			fail("expected object")
+			return
+		}
+		// null 与缺失相同：模型常用 null 代替必填的数组或对象
+		for _, key := range s.Required {
+			if v, exists := obj[key]; !exists || v == nil {
+				fail("missing required field %q", key)
+			}
+		}
+		keys := make([]string, 0, len(s.Properties))
+		for key := range s.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if v, exists := obj[key]; exists && v != nil {
+				s.Properties[key].validate(path+"."+key, v, problems)
+			}
+		}
+
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			fail("expected array")
+			return
+		}
+		if s.MinItems > 0 && len(arr) < s.MinItems {
+			fail("expected at least %d items, got %d", s.MinItems, len(arr))
+		}
+		if s.MaxItems > 0 && len(arr) > s.MaxItems {
+			fail("expected at most %d items, got %d", s.MaxItems, len(arr))
+		}
+		for i, item := range arr {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("expected string")
+			return
+		}
+		if s.MinLength > 0 && len([]rune(str)) < s.MinLength {
+			fail("expected at least %d characters", s.MinLength)
+		}
+		if len(s.Enum) > 0 {
+			for _, e := range s.Enum {
+				if e == str {
+					return
+				}
+			}
+			fail("value %q not in %v", str, s.Enum)
+		}
+
+	case "integer":
+		num, ok := value.(json.Number)
+		if !ok {
+			fail("expected integer")
+			return
+		}
+		if _, err := num.Int64(); err != nil {
+			fail("expected integer, got %s", num)
+		}
+
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			fail("expected number")
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("expected boolean")
+		}
+	}
+}
+
+// SchemaFor 通过反射从 Go 类型推导模式
+//
+// 字段名取自 json 标签，未标记 omitempty 的字段视为必填；
+// 可用 jsonschema 标签补充约束，例如 `jsonschema:"enum=a|b,minItems=1,description=说明"`；
+// description 之后的全部内容（包括逗号）都是说明，因此应放在最后。
+func SchemaFor(v interface{}) *Schema {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return &Schema{}
+	}
+	return schemaForType(t)
+}
+
+func schemaForType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := field.Name
+			omitEmpty := false
+			if tag := field.Tag.Get("json"); tag != "" {
+				parts := strings.Split(tag, ",")
+				if parts[0] == "-" {
+					continue
+				}
+				if parts[0] != "" {
+					name = parts[0]
+				}
+				for _, opt := range parts[1:] {
+					if opt == "omitempty" {
+						omitEmpty = true
+					}
+				}
+			}
+
+			prop := schemaForType(field.Type)
+			applySchemaTag(prop, field.Tag.Get("jsonschema"))
+			s.Properties[name] = prop
+			if !omitEmpty {
+				s.Required = append(s.Required, name)
+			}
+		}
+		sort.Strings(s.Required)
+		return s
+
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaForType(t.Elem())}
+
+	case reflect.Map:
+		return &Schema{Type: "object"}
+
+	case reflect.String:
+		return &Schema{Type: "string"}
+
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+
+	default:
+		return &Schema{}
+	}
+}
+
+// applySchemaTag 解析 jsonschema 标签，description= 之后的内容整体作为说明
+func applySchemaTag(s *Schema, tag string) {
+	for tag != "" {
+		var part string
+		part, tag, _ = strings.Cut(tag, ",")
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if key == "description" && tag != "" {
+			value += "," + tag
+			tag = ""
+		}
+		switch key {
+		case "enum":
+			s.Enum = strings.Split(value, "|")
+		case "description":
+			s.Description = value
+		case "minItems":
+			s.MinItems, _ = strconv.Atoi(value)
+		case "maxItems":
+			s.MaxItems, _ = strconv.Atoi(value)
+		case "minLength":
+			s.MinLength, _ = strconv.Atoi(value)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"plain", `{"a": 1}`, `{"a": 1}`},
+		{"fenced", "建议如下：\n```json\n{\"a\": 1}\n```\n", `{"a": 1}`},
+		{"bare fence", "```\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"embedded", `好的 {"a": "}"} 以上`, `{"a": "}"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractJSON(tt.content)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("ExtractJSON = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+	if _, err := ExtractJSON("没有 JSON"); err == nil {
+		t.Error("ExtractJSON without JSON: want error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema := SchemaFor(&DomainIdeas{})
+	tests := []struct {
+		name    string
+		data    string
+		problem string // 为空时应通过
+	}{
+		{"valid", `{"suggestions": [{"domain": "a.com", "reason": "短"}]}`, ""},
+		{"optional null", `{"suggestions": [{"domain": "a.com", "reason": "短", "style": null}], "summary": null}`, ""},
+		{"missing", `{"summary": "x"}`, `missing required field "suggestions"`},
+		{"null required", `{"suggestions": null}`, `missing required field "suggestions"`},
+		{"null item field", `{"suggestions": [{"domain": null, "reason": "短"}]}`, `missing required field "domain"`},
+		{"min items", `{"suggestions": []}`, "at least 1 items"},
+		{"min length", `{"suggestions": [{"domain": "", "reason": "短"}]}`, "at least 1 characters"},
+		{"wrong type", `{"suggestions": "a.com"}`, "expected array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate([]byte(tt.data))
+			switch {
+			case tt.problem == "" && err != nil:
+				t.Errorf("Validate: %v", err)
+			case tt.problem != "" && (err == nil || !strings.Contains(err.Error(), tt.problem)):
+				t.Errorf("Validate = %v; want %q", err, tt.problem)
+			}
+		})
+	}
+}
+
+func TestSchemaTag(t *testing.T) {
+	var v struct {
+		Style string   `json:"style" jsonschema:"enum=formal|creative,description=风格，例如 formal, creative"`
+		Names []string `json:"names,omitempty" jsonschema:"minItems=1,maxItems=5"`
+	}
+	s := SchemaFor(&v)
+	style := s.Properties["style"]
+	if style.Description != "风格，例如 formal, creative" || len(style.Enum) != 2 {
+		t.Errorf("style = %+v", style)
+	}
+	names := s.Properties["names"]
+	if names.MinItems != 1 || names.MaxItems != 5 || names.Items.Type != "string" {
+		t.Errorf("names = %+v", names)
+	}
+	if len(s.Required) != 1 || s.Required[0] != "style" {
+		t.Errorf("required = %v", s.Required)
+	}
+}
+
+func TestCompleteStructuredRetries(t *testing.T) {
+	replies := []string{`{"suggestions": null}`, "```json\n{\"suggestions\": [{\"domain\": \"a.com\", \"reason\": \"短\"}]}\n```"}
+	var requests []ChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		reply := replies[min(len(requests), len(replies))-1]
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Role: "assistant", Content: reply}}}})
+	}))
+	defer srv.Close()
+
+	c := &Client{apiKey: "test", baseURL: srv.URL, jsonMode: JSONModeNone, httpClient: srv.Client()}
+	var ideas DomainIdeas
+	err := c.CompleteStructured(StructuredRequest{Messages: []Message{{Role: "user", Content: "起名"}}}, &ideas)
+	if err != nil || len(ideas.Suggestions) != 1 || ideas.Suggestions[0].Domain != "a.com" {
+		t.Fatalf("CompleteStructured = %+v, %v", ideas, err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(requests))
+	}
+	feedback := requests[1].Messages[len(requests[1].Messages)-1].Content
+	if !strings.Contains(feedback, "suggestions") {
+		t.Errorf("retry feedback = %q", feedback)
+	}
+
+	replies = []string{"不是 JSON"}
+	requests = nil
+	err = c.CompleteStructured(StructuredRequest{Name: "ideas", Messages: []Message{{Role: "user", Content: "起名"}}, MaxRetries: 1}, &ideas)
+	if RawFromError(err) != "不是 JSON" || len(requests) != 2 {
+		t.Errorf("CompleteStructured = %v after %d request(s)", err, len(requests))
+	}
+	if want := fmt.Sprintf("%q", "ideas"); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestFormatFallback(t *testing.T) {
+	// 服务商不支持 response_format 时返回 400
+	var requests []ChatRequest
+	status := http.StatusBadRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		if req.ResponseFormat != nil {
+			http.Error(w, `{"error": "response_format is not supported"}`, status)
+			return
+		}
+		reply := `{"suggestions": [{"domain": "a.com", "reason": "短"}]}`
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Role: "assistant", Content: reply}}}})
+	}))
+	defer srv.Close()
+
+	c := &Client{apiKey: "test", baseURL: srv.URL, jsonMode: JSONModeSchema, httpClient: srv.Client()}
+	var ideas DomainIdeas
+	err := c.CompleteStructured(StructuredRequest{Messages: []Message{{Role: "user", Content: "起名"}}}, &ideas)
+	if err != nil || len(ideas.Suggestions) != 1 {
+		t.Fatalf("CompleteStructured = %+v, %v", ideas, err)
+	}
+	if len(requests) != 2 || requests[0].ResponseFormat == nil || requests[0].ResponseFormat.JSONSchema == nil || requests[1].ResponseFormat != nil {
+		t.Errorf("requests = %+v, want one with a json_schema response_format and a retry without it", requests)
+	}
+
+	// 其它错误不重试
+	status = http.StatusInternalServerError
+	requests = nil
+	err = c.CompleteStructured(StructuredRequest{Messages: []Message{{Role: "user", Content: "起名"}}}, &ideas)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError || len(requests) != 1 {
+		t.Errorf("CompleteStructured = %v after %d request(s), want the 500 without a retry", err, len(requests))
+	}
+}
@@ -0,0 +1,229 @@
+// Package namecheck 名称在外部平台上的可用性检查
+//
+// 社交账号（internal/handles）和包仓库（internal/ecosystems）共用这里的检查器注册表、
+// 检查状态和基于 HTTP 状态码的检查器；各包只负责注册自己的平台。
+package namecheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 检查结果状态
+const (
+	StatusAvailable = "available"
+	StatusTaken     = "taken"
+	StatusInvalid   = "invalid" // 名称不符合平台的命名规则
+	StatusUnknown   = "unknown" // 请求失败或被限流，无法判断
+)
+
+// Timeout 单个平台检查的超时
+const Timeout = 8 * time.Second
+
+// Checker 单个平台的名称检查器
+type Checker interface {
+	Name() string
+	Check(ctx context.Context, name string) (string, error) // 返回状态，见 Status* 常量
+}
+
+// Result 名称在一个平台上的检查结果
+type Result struct {
+	Checker string // 平台名称
+	Name    string
+	Status  string
+	Error   string
+}
+
+// Available 名称是否可用
+func (r Result) Available() bool {
+	return r.Status == StatusAvailable
+}
+
+// Registry 检查器注册表
+type Registry struct {
+	kind     string // 错误信息中的类别名，如 platforms、registries
+	mu       sync.RWMutex
+	checkers map[string]Checker
+	order    []string
+}
+
+// NewRegistry 创建空的注册表，kind 用于错误信息
+func NewRegistry(kind string) *Registry {
+	return &Registry{kind: kind, checkers: map[string]Checker{}}
+}
+
+// Register 注册检查器，同名检查器会被覆盖（可用于替换为替身）
+func (r *Registry) Register(c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.checkers[c.Name()]; !exists {
+		r.order = append(r.order, c.Name())
+	}
+	r.checkers[c.Name()] = c
+}
+
+// Names 已注册的检查器，按注册顺序
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.order...)
+}
+
+// Validate 检查名称是否都已注册
+func (r *Registry) Validate(names []string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var unknown []string
+	for _, name := range names {
+		if _, exists := r.checkers[name]; !exists {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown %s: %s (available: %s)", r.kind, strings.Join(unknown, ", "), strings.Join(r.order, ", "))
+	}
+	return nil
+}
+
+// Check 并发检查一个名称在各平台的可用性，selected 为空时检查全部平台
+//
+// 结果按 selected 的顺序（为空时按注册顺序）排列，未注册的平台被忽略；
+// 单个平台失败时状态为 unknown，不影响其他平台。
+func (r *Registry) Check(name string, selected []string) []Result {
+	r.mu.RLock()
+	var checkers []Checker
+	if len(selected) == 0 {
+		selected = r.order
+	}
+	for _, n := range selected {
+		if c, exists := r.checkers[n]; exists {
+			checkers = append(checkers, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	results := make([]Result, len(checkers))
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func(i int, c Checker) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+			defer cancel()
+
+			result := Result{Checker: c.Name(), Name: name}
+			status, err := c.Check(ctx, name)
+			if err != nil {
+				result.Status = StatusUnknown
+				result.Error = err.Error()
+			} else {
+				result.Status = status
+			}
+			results[i] = result
+		}(i, c)
+	}
+	wg.Wait()
+	return results
+}
+
+// CheckAll 检查多个名称，相同的名称（忽略大小写和首尾空白）只检查一次
+func (r *Registry) CheckAll(names []string, selected []string) map[string][]Result {
+	var unique []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+
+	results := make(map[string][]Result, len(unique))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range unique {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			found := r.Check(name, selected)
+			mu.Lock()
+			results[name] = found
+			mu.Unlock()
+		}(name)
+	}
+	wg.Wait()
+	return results
+}
+
+// HTTPChecker 通过资料页或元数据接口的状态码判断：NotFound 中的状态码为可用，2xx 为已占用
+type HTTPChecker struct {
+	name     string
+	BaseURL  string
+	Path     string // 带 %s 占位符的路径
+	Pattern  *regexp.Regexp
+	NotFound []int
+	Header   http.Header
+	Client   *http.Client
+}
+
+// NewHTTPChecker 创建检查器，baseURL 为空时使用 defaultURL；notFound 为空时只有 404 表示可用
+func NewHTTPChecker(name, baseURL, defaultURL, path, pattern string, notFound ...int) *HTTPChecker {
+	if baseURL == "" {
+		baseURL = defaultURL
+	}
+	if len(notFound) == 0 {
+		notFound = []int{http.StatusNotFound}
+	}
+	return &HTTPChecker{
+		name:     name,
+		BaseURL:  strings.TrimSuffix(baseURL, "/"),
+		Path:     path,
+		Pattern:  regexp.MustCompile(pattern),
+		NotFound: notFound,
+		Header:   http.Header{},
+		Client:   &http.Client{Timeout: Timeout},
+	}
+}
+
+func (c *HTTPChecker) Name() string { return c.name }
+
+func (c *HTTPChecker) Check(ctx context.Context, name string) (string, error) {
+	if !c.Pattern.MatchString(name) {
+		return StatusInvalid, nil
+	}
+
+	resp, err := c.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	for _, code := range c.NotFound {
+		if resp.StatusCode == code {
+			return StatusAvailable, nil
+		}
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return StatusTaken, nil
+	}
+	return "", fmt.Errorf("%s returned HTTP %d", c.name, resp.StatusCode)
+}
+
+// Get 请求名称对应的地址，调用方负责关闭响应
+func (c *HTTPChecker) Get(ctx context.Context, name string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+fmt.Sprintf(c.Path, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "domain-agent/1.0")
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+	return c.Client.Do(req)
+}
@@ -0,0 +1,104 @@
+package namecheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// stubChecker 不发请求的检查器，按名称返回固定状态
+type stubChecker struct {
+	name   string
+	status map[string]string
+	calls  atomic.Int64
+}
+
+func (c *stubChecker) Name() string { return c.name }
+
+func (c *stubChecker) Check(_ context.Context, name string) (string, error) {
+	c.calls.Add(1)
+	if s, ok := c.status[name]; ok {
+		return s, nil
+	}
+	return "", errors.New("unreachable")
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry("platforms")
+	a := &stubChecker{name: "a", status: map[string]string{"kit": StatusTaken}}
+	b := &stubChecker{name: "b", status: map[string]string{"kit": StatusAvailable}}
+	r.Register(a)
+	r.Register(b)
+	r.Register(a) // 重复注册不改变顺序
+
+	if got := strings.Join(r.Names(), ","); got != "a,b" {
+		t.Errorf("Names = %s, want a,b", got)
+	}
+	if err := r.Validate([]string{"a", "c"}); err == nil || err.Error() != "unknown platforms: c (available: a, b)" {
+		t.Errorf("Validate = %v", err)
+	}
+
+	results := r.CheckAll([]string{"Kit", " kit ", "leaf"}, nil)
+	if len(results) != 2 || a.calls.Load() != 2 {
+		t.Fatalf("CheckAll = %+v with %d call(s) to a, want each name checked once", results, a.calls.Load())
+	}
+	kit := results["kit"]
+	if len(kit) != 2 || kit[0] != (Result{Checker: "a", Name: "kit", Status: StatusTaken}) || !kit[1].Available() {
+		t.Errorf("kit = %+v", kit)
+	}
+	leaf := results["leaf"]
+	if leaf[0].Status != StatusUnknown || leaf[0].Error != "unreachable" || leaf[0].Available() {
+		t.Errorf("leaf = %+v, want unknown with the error", leaf)
+	}
+
+	if got := r.Check("kit", []string{"b", "missing", "a"}); len(got) != 2 || got[0].Checker != "b" {
+		t.Errorf("Check(selected) = %+v, want b then a", got)
+	}
+}
+
+func TestHTTPChecker(t *testing.T) {
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Accept")
+		switch r.URL.Path {
+		case "/pkg/taken":
+			w.WriteHeader(http.StatusOK)
+		case "/pkg/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/pkg/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := NewHTTPChecker("pkg", server.URL+"/", "https://example.invalid", "/pkg/%s", `^[a-z]+$`, http.StatusNotFound, http.StatusGone)
+	c.Header.Set("Accept", "application/json")
+	tests := []struct {
+		name, status string
+		err          bool
+	}{
+		{"taken", StatusTaken, false},
+		{"free", StatusAvailable, false},
+		{"gone", StatusAvailable, false},
+		{"limited", "", true},
+		{"Bad-Name", StatusInvalid, false},
+	}
+	for _, tt := range tests {
+		status, err := c.Check(context.Background(), tt.name)
+		if status != tt.status || (err != nil) != tt.err {
+			t.Errorf("Check(%s) = %q, %v, want %q (error %v)", tt.name, status, err, tt.status, tt.err)
+		}
+	}
+	if header != "application/json" {
+		t.Errorf("Accept header = %q", header)
+	}
+	if d := NewHTTPChecker("pkg", "", "https://example.invalid", "/%s", `.`); d.BaseURL != "https://example.invalid" || len(d.NotFound) != 1 {
+		t.Errorf("defaults = %s %v", d.BaseURL, d.NotFound)
+	}
+}
@@ -0,0 +1,267 @@
+// Package pinyin 中文品牌名的拼音转换和拼音域名评估
+//
+// 把“阿里巴巴”转换为全拼 alibaba、首字母 albb 以及各种音节组合，
+// 并评估一个拼音域名读起来是否自然。
+package pinyin
+
+import (
+	"strings"
+	"unicode"
+
+	gopinyin "github.com/mozillazg/go-pinyin"
+)
+
+// 组合方式
+const (
+	KindFull     = "full"     // 全拼：alibaba
+	KindInitials = "initials" // 首字母：albb
+	KindPartial  = "partial"  // 连续的部分音节：ali、baba
+	KindMixed    = "mixed"    // 首个音节全拼加其余首字母：xiaomkj
+)
+
+// Combination 拼音组合
+type Combination struct {
+	Label string
+	Kind  string
+}
+
+// phraseSyllables 常见多音字词的读音，逐字转换会读错
+var phraseSyllables = map[string][]string{
+	"重庆": {"chong", "qing"},
+	"银行": {"yin", "hang"},
+	"行业": {"hang", "ye"},
+	"长城": {"chang", "cheng"},
+	"长沙": {"chang", "sha"},
+	"长安": {"chang", "an"},
+	"厦门": {"xia", "men"},
+	"音乐": {"yin", "yue"},
+	"快乐": {"kuai", "le"},
+	"乐园": {"le", "yuan"},
+	"便宜": {"pian", "yi"},
+	"单于": {"chan", "yu"},
+	"朝阳": {"chao", "yang"},
+	"重要": {"zhong", "yao"},
+	"觉得": {"jue", "de"},
+	"睡觉": {"shui", "jiao"},
+	"会计": {"kuai", "ji"},
+	"了解": {"liao", "jie"},
+	"调查": {"diao", "cha"},
+	"好奇": {"hao", "qi"},
+	"爱好": {"ai", "hao"},
+	"数据": {"shu", "ju"},
+	"传记": {"zhuan", "ji"},
+}
+
+// maxPhraseLen 读音表中最长词的字数
+const maxPhraseLen = 2
+
+// Syllables 把中文转换为不带声调的拼音音节，非汉字的字母数字按原样作为一个音节
+//
+// ü 按域名惯例写作 v（绿 → lv）。
+func Syllables(text string) []string {
+	args := gopinyin.NewArgs()
+	runes := []rune(text)
+
+	var syllables []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			syllables = append(syllables, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if !unicode.Is(unicode.Han, r) {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				word = append(word, r)
+			} else {
+				flush()
+			}
+			i++
+			continue
+		}
+		flush()
+
+		if n := min(maxPhraseLen, len(runes)-i); n > 1 {
+			if p, ok := phraseSyllables[string(runes[i:i+n])]; ok {
+				syllables = append(syllables, p...)
+				i += n
+				continue
+			}
+		}
+		for _, p := range gopinyin.LazyPinyin(string(r), args) {
+			syllables = append(syllables, strings.ReplaceAll(p, "ü", "v"))
+		}
+		i++
+	}
+	flush()
+
+	return syllables
+}
+
+// Full 全拼（阿里巴巴 → alibaba）
+func Full(text string) string {
+	return strings.Join(Syllables(text), "")
+}
+
+// Initials 每个音节的首字母（阿里巴巴 → albb）
+func Initials(text string) string {
+	return initials(Syllables(text))
+}
+
+func initials(syllables []string) string {
+	var b strings.Builder
+	for _, s := range syllables {
+		if s != "" {
+			b.WriteByte(s[0])
+		}
+	}
+	return b.String()
+}
+
+// Combinations 由音节生成可用作域名的组合：全拼、首字母、连续的部分音节、首个全拼加其余首字母
+//
+// 结果已去重，按全拼、首字母、混合、部分音节的顺序排列。
+func Combinations(syllables []string) []Combination {
+	var combos []Combination
+	seen := map[string]bool{}
+	add := func(label, kind string) {
+		if label == "" || seen[label] {
+			return
+		}
+		seen[label] = true
+		combos = append(combos, Combination{Label: label, Kind: kind})
+	}
+
+	add(strings.Join(syllables, ""), KindFull)
+	if len(syllables) < 2 {
+		return combos
+	}
+
+	add(initials(syllables), KindInitials)
+	add(syllables[0]+initials(syllables[1:]), KindMixed)
+	if len(syllables) > 2 {
+		add(strings.Join(syllables[:2], "")+initials(syllables[2:]), KindMixed)
+	}
+
+	// 连续的部分音节（至少两个），较长的在前
+	for n := len(syllables) - 1; n >= 2; n-- {
+		for i := 0; i+n <= len(syllables); i++ {
+			add(strings.Join(syllables[i:i+n], ""), KindPartial)
+		}
+	}
+
+	return combos
+}
+
+// 声母和韵母，用于判断一个字母串能否切分为拼音音节
+var (
+	pinyinInitials = []string{"zh", "ch", "sh", "b", "p", "m", "f", "d", "t", "n", "l", "g", "k", "h", "j", "q", "x", "r", "z", "c", "s", "y", "w", ""}
+	pinyinFinals   = []string{
+		"a", "o", "e", "i", "u", "v", "ai", "ei", "ui", "ao", "ou", "iu", "ie", "ve", "ue", "er",
+		"an", "en", "in", "un", "vn", "ang", "eng", "ing", "ong",
+		"ia", "iao", "ian", "iang", "iong", "ua", "uo", "uai", "uan", "uang",
+	}
+	validSyllables = buildSyllables()
+)
+
+// maxSyllableLen 最长音节的字母数（zhuang）
+const maxSyllableLen = 6
+
+func buildSyllables() map[string]bool {
+	set := map[string]bool{}
+	for _, i := range pinyinInitials {
+		for _, f := range pinyinFinals {
+			set[i+f] = true
+		}
+	}
+	// 零声母的 i、u、v 开头韵母在拼写上由 y、w 代替
+	for _, s := range []string{"i", "u", "v", "ia", "iao", "ian", "iang", "iong", "ie", "iu", "in", "ing", "ua", "uo", "uai", "uan", "uang", "ui", "un", "ve", "vn"} {
+		delete(set, s)
+	}
+	return set
+}
+
+// IsSyllable 是否为合法的不带声调拼音音节
+func IsSyllable(s string) bool {
+	return validSyllables[s]
+}
+
+// Split 把字母串切分为拼音音节，音节数尽量少；无法完整切分时返回 false
+//
+// 如 alibaba → a li ba ba、xian → xian（而不是 xi an）。
+func Split(label string) ([]string, bool) {
+	label = strings.ToLower(label)
+	n := len(label)
+	if n == 0 {
+		return nil, false
+	}
+
+	// best[i] 为 label[i:] 的最少音节数，-1 表示无法切分
+	best := make([]int, n+1)
+	next := make([]int, n+1)
+	for i := n - 1; i >= 0; i-- {
+		best[i] = -1
+		for l := min(maxSyllableLen, n-i); l >= 1; l-- {
+			if !validSyllables[label[i:i+l]] || best[i+l] < 0 {
+				continue
+			}
+			if best[i] < 0 || best[i+l]+1 < best[i] {
+				best[i] = best[i+l] + 1
+				next[i] = i + l
+			}
+		}
+	}
+	if best[0] < 0 {
+		return nil, false
+	}
+
+	var parts []string
+	for i := 0; i < n; i = next[i] {
+		parts = append(parts, label[i:next[i]])
+	}
+	return parts, true
+}
+
+// Naturalness 评估拼音域名读起来是否自然（0-1）
+//
+// 能完整切分为拼音的名称最自然，音节越多越难记；
+// 2-4 个字母的首字母缩写（albb）可以接受；其余情况返回 0。
+func Naturalness(label string) float64 {
+	label = strings.ToLower(label)
+	if parts, ok := Split(label); ok {
+		score := 1.0
+		if len(parts) > 3 {
+			score -= 0.1 * float64(len(parts)-3)
+		}
+		// 以元音开头的音节接在其他音节后容易读错（xian 还是 xi'an）
+		for _, p := range parts[1:] {
+			if strings.IndexByte("aoe", p[0]) >= 0 {
+				score -= 0.1
+			}
+		}
+		return max(score, 0.3)
+	}
+
+	if len(label) >= 2 && len(label) <= 4 {
+		for _, r := range label {
+			if r < 'a' || r > 'z' {
+				return 0
+			}
+		}
+		return 0.5
+	}
+	return 0
+}
+
+// HasHan 文本中是否包含汉字
+func HasHan(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package pinyin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSyllables(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"阿里巴巴", []string{"a", "li", "ba", "ba"}},
+		{"重庆火锅", []string{"chong", "qing", "huo", "guo"}},
+		{"绿茶", []string{"lv", "cha"}},
+		{"小米AI", []string{"xiao", "mi", "ai"}},
+	}
+	for _, tt := range tests {
+		if got := Syllables(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Syllables(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+	if got, want := Full("阿里巴巴"), "alibaba"; got != want {
+		t.Errorf("Full = %q, want %q", got, want)
+	}
+	if got, want := Initials("阿里巴巴"), "albb"; got != want {
+		t.Errorf("Initials = %q, want %q", got, want)
+	}
+}
+
+func TestCombinations(t *testing.T) {
+	got := Combinations([]string{"xiao", "mi", "ke", "ji"})
+	want := []Combination{
+		{"xiaomikeji", KindFull},
+		{"xmkj", KindInitials},
+		{"xiaomkj", KindMixed},
+		{"xiaomikj", KindMixed},
+		{"xiaomike", KindPartial},
+		{"mikeji", KindPartial},
+		{"xiaomi", KindPartial},
+		{"mike", KindPartial},
+		{"keji", KindPartial},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Combinations = %v, want %v", got, want)
+	}
+
+	if got := Combinations([]string{"cha"}); !reflect.DeepEqual(got, []Combination{{"cha", KindFull}}) {
+		t.Errorf("Combinations(single) = %v", got)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		label string
+		want  []string
+		ok    bool
+	}{
+		{"alibaba", []string{"a", "li", "ba", "ba"}, true},
+		{"xian", []string{"xian"}, true},
+		{"KaFei", []string{"ka", "fei"}, true},
+		{"coffee", nil, false},
+		{"", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := Split(tt.label)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) = %v, %v, want %v, %v", tt.label, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNaturalness(t *testing.T) {
+	tests := []struct {
+		label string
+		want  float64
+	}{
+		{"kafei", 1},
+		{"xian", 1},
+		{"xiane", 0.9}, // 元音开头的音节接在后面
+		{"albb", 0.5},
+		{"coffee", 0},
+		{"x1", 0},
+	}
+	for _, tt := range tests {
+		if got := Naturalness(tt.label); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("Naturalness(%q) = %v, want %v", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestHasHan(t *testing.T) {
+	if !HasHan("AI 助手") || HasHan("coffee") || HasHan("") {
+		t.Errorf("HasHan mismatch")
+	}
+}
@@ -0,0 +1,327 @@
+// Package rdap 通过 RDAP（RFC 9082/9083）查询域名注册信息
+//
+// 后缀对应的 RDAP 服务地址来自 IANA 的引导文件（RFC 9224），按后缀缓存。
+// 与 WHOIS 不同，RDAP 的回答是结构化的 JSON，未注册的域名返回 HTTP 404，
+// 不需要猜测各注册局的文本格式。
+package rdap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBootstrapURL IANA 的域名 RDAP 引导文件
+const DefaultBootstrapURL = "https://data.iana.org/rdap/dns.json"
+
+// bootstrapTTL 引导文件的缓存时间
+const bootstrapTTL = 24 * time.Hour
+
+// bootstrapRetry 引导文件下载失败后，在这段时间内直接返回上次的错误（或继续使用旧数据）
+var bootstrapRetry = time.Minute
+
+// maxResponseSize RDAP 响应的大小上限
+const maxResponseSize = 1 << 20
+
+var (
+	// ErrNotFound 注册局没有该域名（未注册）
+	ErrNotFound = errors.New("domain not found")
+	// ErrNoService 后缀没有 RDAP 服务
+	ErrNoService = errors.New("no RDAP service for TLD")
+)
+
+// Domain RDAP 域名对象中用到的字段
+type Domain struct {
+	Name        string   `json:"name"`
+	Status      []string `json:"status,omitempty"` // 如 active、client transfer prohibited、redemption period
+	Registrar   string   `json:"registrar,omitempty"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
+	ExpiresAt   string   `json:"expires_at,omitempty"`
+	NameServers []string `json:"name_servers,omitempty"`
+	Server      string   `json:"server"` // 查询的 RDAP 服务地址
+
+	Raw json.RawMessage `json:"-"` // 原始响应
+}
+
+// Client RDAP 客户端
+type Client struct {
+	BootstrapURL string
+	HTTP         *http.Client
+
+	mu       sync.Mutex
+	services map[string]string // 后缀 → 服务地址（以 / 结尾）
+	loadedAt time.Time
+	failure  error         // 上次下载引导文件的错误
+	failedAt time.Time     // 上次下载失败的时间
+	fetching chan struct{} // 正在下载时非空，下载结束时关闭
+}
+
+// New 创建客户端，bootstrapURL 为空时使用 IANA 的引导文件
+func New(bootstrapURL string) *Client {
+	if bootstrapURL == "" {
+		bootstrapURL = DefaultBootstrapURL
+	}
+	return &Client{
+		BootstrapURL: bootstrapURL,
+		HTTP:         &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+var (
+	defaultClient *Client
+	defaultOnce   sync.Once
+	defaultMu     sync.RWMutex
+)
+
+// Default 默认客户端，引导文件地址可以用 RDAP_BOOTSTRAP_URL 覆盖
+func Default() *Client {
+	defaultOnce.Do(func() {
+		c := New(os.Getenv("RDAP_BOOTSTRAP_URL"))
+		defaultMu.Lock()
+		if defaultClient == nil {
+			defaultClient = c
+		}
+		defaultMu.Unlock()
+	})
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultClient
+}
+
+// SetDefault 替换默认客户端，仅应在启动时、处理请求之前调用
+func SetDefault(c *Client) {
+	defaultMu.Lock()
+	defaultClient = c
+	defaultMu.Unlock()
+}
+
+// ServiceURL 后缀对应的 RDAP 服务地址
+//
+// 引导文件在锁外下载，同一时间只有一个请求下载：有旧数据时其余请求继续使用旧数据，
+// 没有时等待下载结果。
+// 下载失败时继续使用旧数据；没有旧数据时在 bootstrapRetry 内直接返回上次的错误，
+// 不会让每次查询都去请求引导文件。
+func (c *Client) ServiceURL(ctx context.Context, tld string) (string, error) {
+	tld = strings.ToLower(strings.Trim(tld, "."))
+	services, err := c.bootstrap(ctx)
+	if services == nil {
+		return "", err
+	}
+	base, ok := services[tld]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNoService, tld)
+	}
+	return base, nil
+}
+
+// bootstrap 返回缓存的引导数据，过期且不在退避期内时重新下载；返回的数据为 nil 时 error 说明原因
+func (c *Client) bootstrap(ctx context.Context) (map[string]string, error) {
+	c.mu.Lock()
+	for {
+		fresh := c.services != nil && time.Since(c.loadedAt) <= bootstrapTTL
+		backoff := c.failure != nil && time.Since(c.failedAt) < bootstrapRetry
+		refreshing := c.fetching != nil && c.services != nil
+		if fresh || backoff || refreshing {
+			defer c.mu.Unlock()
+			return c.services, c.failure
+		}
+		if c.fetching == nil {
+			break
+		}
+		wait := c.fetching
+		c.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		c.mu.Lock()
+	}
+
+	done := make(chan struct{})
+	c.fetching = done
+	c.mu.Unlock()
+
+	services, err := c.fetchBootstrap(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetching = nil
+	close(done)
+	switch {
+	case err == nil:
+		c.services, c.loadedAt, c.failure = services, time.Now(), nil
+	case ctx.Err() == nil:
+		// 调用方取消导致的失败不是引导文件的问题，不进入退避
+		c.failure, c.failedAt = err, time.Now()
+	}
+	if err != nil && c.services != nil {
+		return c.services, nil
+	}
+	return c.services, err
+}
+
+// fetchBootstrap 下载并解析引导文件：services 中每项为 [[后缀...], [服务地址...]]
+func (c *Client) fetchBootstrap(ctx context.Context) (map[string]string, error) {
+	body, status, err := c.get(ctx, c.BootstrapURL, "application/json")
+	if err != nil {
+		return nil, fmt.Errorf("rdap bootstrap: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("rdap bootstrap: HTTP %d", status)
+	}
+
+	var doc struct {
+		Services [][][]string `json:"services"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("rdap bootstrap: %w", err)
+	}
+	services := map[string]string{}
+	for _, svc := range doc.Services {
+		if len(svc) < 2 || len(svc[1]) == 0 {
+			continue
+		}
+		// 有多个地址时优先 HTTPS
+		base := svc[1][0]
+		for _, u := range svc[1] {
+			if strings.HasPrefix(u, "https://") {
+				base = u
+				break
+			}
+		}
+		if !strings.HasSuffix(base, "/") {
+			base += "/"
+		}
+		for _, tld := range svc[0] {
+			services[strings.ToLower(tld)] = base
+		}
+	}
+	return services, nil
+}
+
+// Lookup 查询域名，未注册时返回 ErrNotFound
+func (c *Client) Lookup(ctx context.Context, domain string) (*Domain, error) {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	i := strings.LastIndexByte(domain, '.')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid domain %q", domain)
+	}
+	base, err := c.ServiceURL(ctx, domain[i+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	url := base + "domain/" + domain
+	body, status, err := c.get(ctx, url, "application/rdap+json")
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, fmt.Errorf("%s: HTTP %d", url, status)
+	}
+
+	d, err := parseDomain(body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	d.Server = base
+	if d.Name == "" {
+		d.Name = domain
+	}
+	return d, nil
+}
+
+func (c *Client) get(ctx context.Context, url, accept string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, resp.StatusCode, nil
+}
+
+// parseDomain 提取状态、事件、注册商和名称服务器
+func parseDomain(body []byte) (*Domain, error) {
+	var obj struct {
+		LDHName string   `json:"ldhName"`
+		Status  []string `json:"status"`
+		Events  []struct {
+			Action string `json:"eventAction"`
+			Date   string `json:"eventDate"`
+		} `json:"events"`
+		Nameservers []struct {
+			LDHName string `json:"ldhName"`
+		} `json:"nameservers"`
+		Entities []struct {
+			Roles      []string          `json:"roles"`
+			VCardArray []json.RawMessage `json:"vcardArray"`
+		} `json:"entities"`
+	}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nil, err
+	}
+
+	d := &Domain{Name: strings.ToLower(obj.LDHName), Status: obj.Status, Raw: body}
+	for _, e := range obj.Events {
+		switch e.Action {
+		case "registration":
+			d.CreatedAt = e.Date
+		case "last changed":
+			d.UpdatedAt = e.Date
+		case "expiration":
+			d.ExpiresAt = e.Date
+		}
+	}
+	for _, ns := range obj.Nameservers {
+		d.NameServers = append(d.NameServers, strings.ToLower(strings.TrimSuffix(ns.LDHName, ".")))
+	}
+	for _, e := range obj.Entities {
+		for _, role := range e.Roles {
+			if role == "registrar" {
+				d.Registrar = vcardName(e.VCardArray)
+			}
+		}
+	}
+	return d, nil
+}
+
+// vcardName 从 jCard（RFC 7095）中取 fn 属性：["vcard", [["fn", {}, "text", "名称"], ...]]
+func vcardName(card []json.RawMessage) string {
+	if len(card) < 2 {
+		return ""
+	}
+	var props [][]any
+	if err := json.Unmarshal(card[1], &props); err != nil {
+		return ""
+	}
+	for _, p := range props {
+		if len(p) >= 4 && p[0] == "fn" {
+			if name, ok := p[3].(string); ok {
+				return name
+			}
+		}
+	}
+	return ""
+}
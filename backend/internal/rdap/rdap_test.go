@@ -0,0 +1,138 @@
+package rdap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const registered = `{
+  "objectClassName": "domain",
+  "ldhName": "TAKEN.TEST",
+  "status": ["active", "client transfer prohibited"],
+  "events": [
+    {"eventAction": "registration", "eventDate": "2015-03-01T00:00:00Z"},
+    {"eventAction": "expiration", "eventDate": "2027-03-01T00:00:00Z"}
+  ],
+  "nameservers": [{"ldhName": "NS1.HOSTING.NET"}],
+  "entities": [{"roles": ["registrar"], "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example Registrar, Inc."]]]}]
+}`
+
+// standIn 提供引导文件和 RDAP 接口的替身：example 有两个服务地址，应优先使用 HTTPS 的那个
+func standIn(t *testing.T) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bootstrap.json":
+			fmt.Fprintf(w, `{"version": "1.0", "services": [[["test"], ["%s/rdap"]], [["example"], ["http://a.invalid/", "https://b.invalid/rdap"]]]}`, srv.URL)
+		case "/rdap/domain/taken.test":
+			w.Header().Set("Content-Type", "application/rdap+json")
+			fmt.Fprint(w, registered)
+		case "/rdap/domain/busy.test":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLookup(t *testing.T) {
+	client := New(standIn(t).URL + "/bootstrap.json")
+	ctx := context.Background()
+
+	if base, err := client.ServiceURL(ctx, "EXAMPLE"); err != nil || base != "https://b.invalid/rdap/" {
+		t.Errorf("ServiceURL(EXAMPLE) = %q, %v", base, err)
+	}
+
+	d, err := client.Lookup(ctx, "Taken.Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "taken.test" || d.Registrar != "Example Registrar, Inc." || d.CreatedAt != "2015-03-01T00:00:00Z" ||
+		d.ExpiresAt != "2027-03-01T00:00:00Z" || len(d.Status) != 2 || len(d.NameServers) != 1 || d.NameServers[0] != "ns1.hosting.net" {
+		d.Raw = nil
+		t.Errorf("Lookup(Taken.Test) = %+v", d)
+	}
+
+	if _, err := client.Lookup(ctx, "free.test"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup(free.test) error = %v, want ErrNotFound", err)
+	}
+	if _, err := client.Lookup(ctx, "busy.test"); err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "429") {
+		t.Errorf("Lookup(busy.test) error = %v, want HTTP 429", err)
+	}
+	if _, err := client.Lookup(ctx, "name.nordap"); !errors.Is(err, ErrNoService) {
+		t.Errorf("Lookup(name.nordap) error = %v, want ErrNoService", err)
+	}
+}
+
+func TestBootstrapFailureBackoff(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	client := New(srv.URL)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.ServiceURL(context.Background(), "test"); err == nil || !strings.Contains(err.Error(), "503") {
+			t.Errorf("attempt %d: error = %v, want the bootstrap failure", i, err)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetched the bootstrap file %d times within the backoff, want 1", n)
+	}
+
+	retry := bootstrapRetry
+	bootstrapRetry = 0
+	t.Cleanup(func() { bootstrapRetry = retry })
+	client.ServiceURL(context.Background(), "test")
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("fetched the bootstrap file %d times after the backoff, want 2", n)
+	}
+}
+
+func TestBootstrapFetchedOutsideLock(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		fmt.Fprint(w, `{"services": [[["test"], ["https://rdap.test/"]]]}`)
+	}))
+	t.Cleanup(srv.Close)
+	client := New(srv.URL)
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.ServiceURL(context.Background(), "test")
+		first <- err
+	}()
+
+	// 第一次下载卡住时，其余请求按自己的时限返回，而不是阻塞在锁上
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.ServiceURL(ctx, "test"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waiting request error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("waiting request returned after %v", elapsed)
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	// 等待者的超时不会被当作引导文件的失败
+	if base, err := client.ServiceURL(context.Background(), "test"); err != nil || base != "https://rdap.test/" {
+		t.Errorf("ServiceURL after the download = %q, %v", base, err)
+	}
+}
@@ -0,0 +1,154 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// tldServerTTL 后缀权威服务器地址的缓存时间
+const tldServerTTL = time.Hour
+
+type tldServerEntry struct {
+	servers []string // host:port
+	expires time.Time
+}
+
+// Delegation 父区域权威服务器对域名委派的回答
+type Delegation struct {
+	Domain      string   `json:"domain"`
+	Zone        string   `json:"zone"`   // 父区域，如 com
+	Server      string   `json:"server"` // 给出回答的权威服务器
+	Rcode       string   `json:"rcode"`  // NOERROR / NXDOMAIN / ...
+	Referral    bool     `json:"referral"`
+	NameServers []string `json:"name_servers,omitempty"` // 委派到的服务器
+}
+
+// ParentZone 域名的父区域：去掉第一个标签（example.com.cn → com.cn）
+func ParentZone(domain string) string {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if i := strings.IndexByte(domain, '.'); i >= 0 {
+		return domain[i+1:]
+	}
+	return ""
+}
+
+// ZoneServers 区域的权威服务器地址（host:port），从上游查询 NS 和地址并缓存
+//
+// 区域本身没有 NS 记录时（如 com.cn 不是独立的区域）向上一级查找。
+func (r *Resolver) ZoneServers(ctx context.Context, zone string) ([]string, string, error) {
+	for z := strings.TrimSuffix(strings.ToLower(zone), "."); z != ""; z = ParentZone(z) {
+		r.mu.Lock()
+		entry, ok := r.tldServers[z]
+		r.mu.Unlock()
+		if ok && time.Now().Before(entry.expires) {
+			return entry.servers, z, nil
+		}
+
+		servers, err := r.resolveZoneServers(ctx, z)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(servers) == 0 {
+			continue
+		}
+		r.mu.Lock()
+		r.tldServers[z] = tldServerEntry{servers: servers, expires: time.Now().Add(tldServerTTL)}
+		r.mu.Unlock()
+		return servers, z, nil
+	}
+	return nil, "", fmt.Errorf("no authoritative servers found for %s", zone)
+}
+
+// resolveZoneServers 查询区域的 NS 记录和服务器地址（优先用附加段中的 glue 记录）
+func (r *Resolver) resolveZoneServers(ctx context.Context, zone string) ([]string, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(zone), dns.TypeNS)
+	resp, err := r.Exchange(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	glue := map[string][]string{}
+	for _, rr := range resp.Extra {
+		switch v := rr.(type) {
+		case *dns.A:
+			glue[v.Hdr.Name] = append(glue[v.Hdr.Name], v.A.String())
+		case *dns.AAAA:
+			glue[v.Hdr.Name] = append(glue[v.Hdr.Name], v.AAAA.String())
+		}
+	}
+
+	var servers []string
+	for _, rr := range resp.Answer {
+		ns, ok := rr.(*dns.NS)
+		if !ok || !strings.EqualFold(dns.CanonicalName(ns.Hdr.Name), dns.Fqdn(zone)) {
+			continue
+		}
+		addrs := glue[ns.Ns]
+		if len(addrs) == 0 {
+			ips, err := r.LookupIP(ctx, ns.Ns)
+			if err != nil {
+				continue
+			}
+			for _, ip := range ips {
+				addrs = append(addrs, ip.String())
+			}
+		}
+		for _, a := range addrs {
+			servers = append(servers, net.JoinHostPort(a, r.cfg.AuthoritativePort))
+		}
+	}
+	return servers, nil
+}
+
+// Delegation 直接询问父区域的权威服务器域名是否有委派（不经过递归解析器和它的缓存）
+//
+// 依次尝试各权威服务器，直到有一个给出回答。
+func (r *Resolver) Delegation(ctx context.Context, domain string) (*Delegation, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	servers, zone, err := r.ZoneServers(ctx, ParentZone(domain))
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(domain), dns.TypeNS)
+	m.RecursionDesired = false
+
+	var errs []error
+	for _, server := range servers {
+		up := Upstream{Network: NetworkUDP, Address: server}
+		resp, err := r.ExchangeWith(ctx, up, m)
+		if err != nil {
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if resp.Rcode == dns.RcodeServerFailure || resp.Rcode == dns.RcodeRefused {
+			errs = append(errs, fmt.Errorf("%s: %s", server, dns.RcodeToString[resp.Rcode]))
+			continue
+		}
+
+		d := &Delegation{Domain: domain, Zone: zone, Server: server, Rcode: dns.RcodeToString[resp.Rcode]}
+		// 委派在权威段（referral）或回答段（服务器同时是子区域的权威）中
+		for _, rr := range append(resp.Ns, resp.Answer...) {
+			if ns, ok := rr.(*dns.NS); ok && strings.EqualFold(dns.CanonicalName(ns.Hdr.Name), dns.Fqdn(domain)) {
+				d.NameServers = append(d.NameServers, strings.TrimSuffix(strings.ToLower(ns.Ns), "."))
+			}
+		}
+		d.Referral = len(d.NameServers) > 0
+		return d, nil
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no authoritative servers answered")
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,275 @@
+package resolver
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// DNSSEC 验证结果
+const (
+	DNSSECSecure        = "secure"        // 签名验证通过，信任链到达信任锚
+	DNSSECInsecure      = "insecure"      // 区域未签名（父区域经过验证地否认了 DS）
+	DNSSECBogus         = "bogus"         // 签名无效、过期或缺失
+	DNSSECIndeterminate = "indeterminate" // 无法判断（否定回答、查询失败）
+)
+
+// zoneCacheTTL 区域验证状态的缓存时间
+const zoneCacheTTL = 10 * time.Minute
+
+type zoneEntry struct {
+	status  string
+	keys    []*dns.DNSKEY
+	expires time.Time
+}
+
+// rootAnchor 根区 KSK-2017（key tag 20326）的 DS
+func rootAnchor() *dns.DS {
+	rr, err := dns.NewRR(". 172800 IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D")
+	if err != nil {
+		panic(err)
+	}
+	return rr.(*dns.DS)
+}
+
+// validateAnswer 验证回答中查询类型的记录集
+//
+// 记录集的签名用签名者区域的 DNSKEY 验证，DNSKEY 再沿 DS 链向上验证到信任锚。
+// 区域是否签名由父区域的 DS 或经过验证的 DS 否认决定；查询名称本身的否定回答
+// （NXDOMAIN、NODATA）不做验证，结果为 indeterminate。
+func (r *Resolver) validateAnswer(ctx context.Context, resp *dns.Msg, qname string, qtype uint16) string {
+	rrset, sigs := splitRRset(resp.Answer, qname, qtype)
+	if len(rrset) == 0 {
+		return DNSSECIndeterminate
+	}
+
+	if len(sigs) == 0 {
+		// 没有签名：所在区域已签名时说明签名被剥离
+		status, _ := r.zoneStatus(ctx, qname)
+		if status == DNSSECSecure {
+			return DNSSECBogus
+		}
+		return status
+	}
+
+	zone := dns.CanonicalName(sigs[0].SignerName)
+	status, keys := r.zoneStatus(ctx, zone)
+	if status != DNSSECSecure {
+		return status
+	}
+	if !verifyRRset(rrset, sigs, keys) {
+		return DNSSECBogus
+	}
+	return DNSSECSecure
+}
+
+// zoneStatus 区域的验证状态和已验证的 DNSKEY，结果缓存一段时间
+func (r *Resolver) zoneStatus(ctx context.Context, zone string) (string, []*dns.DNSKEY) {
+	zone = dns.CanonicalName(zone)
+	r.mu.Lock()
+	entry, ok := r.zoneCache[zone]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.status, entry.keys
+	}
+
+	status, keys := r.computeZoneStatus(ctx, zone)
+	if status != DNSSECIndeterminate {
+		r.mu.Lock()
+		r.zoneCache[zone] = zoneEntry{status: status, keys: keys, expires: time.Now().Add(zoneCacheTTL)}
+		r.mu.Unlock()
+	}
+	return status, keys
+}
+
+func (r *Resolver) computeZoneStatus(ctx context.Context, zone string) (string, []*dns.DNSKEY) {
+	// 有信任锚的区域直接用锚验证 DNSKEY
+	if anchors, ok := r.anchors[zone]; ok {
+		return r.verifyKeys(ctx, zone, anchors)
+	}
+	if zone == "." {
+		return DNSSECInsecure, nil
+	}
+
+	parent := "."
+	if labels := dns.SplitDomainName(zone); len(labels) > 1 {
+		parent = dns.Fqdn(strings.Join(labels[1:], "."))
+	}
+	status, parentKeys := r.zoneStatus(ctx, parent)
+	if status != DNSSECSecure {
+		return status, nil
+	}
+
+	// 父区域已验证，用它的密钥验证本区域的 DS
+	resp, err := r.query(ctx, zone, dns.TypeDS)
+	if err != nil {
+		return DNSSECIndeterminate, nil
+	}
+	rrset, sigs := splitRRset(resp.Answer, zone, dns.TypeDS)
+	if len(rrset) == 0 {
+		// 没有 DS 时要求父区域用已验证的 NSEC/NSEC3 否认：名称不是委派点时与父区域同属
+		// 一个区域，是委派点时为未签名的委派；没有可验证的否认时无法区分剥离和未签名
+		denied, cut := deniesDS(resp, zone, parentKeys)
+		switch {
+		case !denied:
+			return DNSSECIndeterminate, nil
+		case !cut:
+			return status, parentKeys
+		}
+		return DNSSECInsecure, nil
+	}
+	if !verifyRRset(rrset, sigs, parentKeys) {
+		return DNSSECBogus, nil
+	}
+
+	var ds []*dns.DS
+	for _, rr := range rrset {
+		ds = append(ds, rr.(*dns.DS))
+	}
+	return r.verifyKeys(ctx, zone, ds)
+}
+
+// verifyKeys 查询区域的 DNSKEY，要求有密钥与 DS 匹配，并且 DNSKEY 记录集由该密钥签名
+func (r *Resolver) verifyKeys(ctx context.Context, zone string, ds []*dns.DS) (string, []*dns.DNSKEY) {
+	resp, err := r.query(ctx, zone, dns.TypeDNSKEY)
+	if err != nil {
+		return DNSSECIndeterminate, nil
+	}
+	rrset, sigs := splitRRset(resp.Answer, zone, dns.TypeDNSKEY)
+	if len(rrset) == 0 {
+		return DNSSECBogus, nil
+	}
+
+	var keys, trusted []*dns.DNSKEY
+	for _, rr := range rrset {
+		key := rr.(*dns.DNSKEY)
+		keys = append(keys, key)
+		for _, d := range ds {
+			if key.KeyTag() == d.KeyTag && key.Algorithm == d.Algorithm {
+				if computed := key.ToDS(d.DigestType); computed != nil && strings.EqualFold(computed.Digest, d.Digest) {
+					trusted = append(trusted, key)
+				}
+			}
+		}
+	}
+	if len(trusted) == 0 || !verifyRRset(rrset, sigs, trusted) {
+		return DNSSECBogus, nil
+	}
+	return DNSSECSecure, keys
+}
+
+// deniesDS 否定回答是否用父区域密钥签名的 NSEC/NSEC3 证明了名称没有 DS，
+// 以及名称是否为委派点
+//
+// NSEC：名称自身的 NSEC 类型位图中没有 DS，有 NS 且没有 SOA 时为委派点。
+// NSEC3：名称的哈希与某条 NSEC3 匹配时同样看类型位图；不匹配时需要 opt-out 证明，
+// 即某个祖先的哈希被匹配（最近的存在祖先），名称的哈希被带 opt-out 标志的 NSEC3 覆盖，
+// opt-out 只允许跳过未签名的委派，因此按委派点处理。
+func deniesDS(resp *dns.Msg, name string, keys []*dns.DNSKEY) (denied, cut bool) {
+	var nsec []*dns.NSEC
+	var nsec3 []*dns.NSEC3
+	for _, rr := range resp.Ns {
+		switch rr := rr.(type) {
+		case *dns.NSEC:
+			if set, sigs := splitRRset(resp.Ns, rr.Hdr.Name, dns.TypeNSEC); verifyRRset(set, sigs, keys) {
+				nsec = append(nsec, rr)
+			}
+		case *dns.NSEC3:
+			if set, sigs := splitRRset(resp.Ns, rr.Hdr.Name, dns.TypeNSEC3); verifyRRset(set, sigs, keys) {
+				nsec3 = append(nsec3, rr)
+			}
+		}
+	}
+
+	name = dns.CanonicalName(name)
+	for _, rr := range nsec {
+		if dns.CanonicalName(rr.Hdr.Name) == name {
+			return denyTypes(rr.TypeBitMap)
+		}
+	}
+	for _, rr := range nsec3 {
+		if rr.Match(name) {
+			return denyTypes(rr.TypeBitMap)
+		}
+	}
+
+	encloser := false
+	for parent, end := dns.NextLabel(name, 0); !end && !encloser; parent, end = dns.NextLabel(name, parent) {
+		for _, rr := range nsec3 {
+			if rr.Match(name[parent:]) {
+				encloser = true
+				break
+			}
+		}
+	}
+	if !encloser {
+		return false, false
+	}
+	for _, rr := range nsec3 {
+		if rr.Flags&1 == 1 && rr.Cover(name) {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// denyTypes 类型位图是否否认了 DS（CNAME 存在时 DS 查询的回答不可信），以及是否为委派点
+func denyTypes(types []uint16) (denied, cut bool) {
+	has := func(t uint16) bool { return slices.Contains(types, t) }
+	if has(dns.TypeDS) || has(dns.TypeCNAME) {
+		return false, false
+	}
+	return true, has(dns.TypeNS) && !has(dns.TypeSOA)
+}
+
+// query 带 DO 位查询上游
+func (r *Resolver) query(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	m.RecursionDesired = true
+	m.SetEdns0(4096, true)
+	return r.Exchange(ctx, m)
+}
+
+// splitRRset 取出回答中指定名称和类型的记录集及覆盖它的签名
+func splitRRset(rrs []dns.RR, name string, qtype uint16) ([]dns.RR, []*dns.RRSIG) {
+	var rrset []dns.RR
+	var sigs []*dns.RRSIG
+	for _, rr := range rrs {
+		if !strings.EqualFold(dns.CanonicalName(rr.Header().Name), dns.CanonicalName(name)) {
+			continue
+		}
+		if sig, ok := rr.(*dns.RRSIG); ok {
+			if sig.TypeCovered == qtype {
+				sigs = append(sigs, sig)
+			}
+			continue
+		}
+		if rr.Header().Rrtype == qtype {
+			rrset = append(rrset, rr)
+		}
+	}
+	return rrset, sigs
+}
+
+// verifyRRset 任意一个签名能用给定密钥验证通过，并且在有效期内
+func verifyRRset(rrset []dns.RR, sigs []*dns.RRSIG, keys []*dns.DNSKEY) bool {
+	now := time.Now()
+	for _, sig := range sigs {
+		if !sig.ValidityPeriod(now) {
+			continue
+		}
+		for _, key := range keys {
+			if key.KeyTag() != sig.KeyTag || key.Algorithm != sig.Algorithm {
+				continue
+			}
+			if sig.Verify(key, rrset) == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,314 @@
+// Package resolver 直接查询指定上游的 DNS 解析
+//
+// 系统解析器的结果取决于主机的 /etc/resolv.conf、本地缓存和分离视图（split-horizon），
+// 这里改为直接向配置的上游发送查询，支持 UDP/TCP、DoT（DNS over TLS）和
+// DoH（DNS over HTTPS），可以直接询问后缀的权威服务器（委派检查），并可选地验证 DNSSEC。
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// 上游协议
+const (
+	NetworkUDP   = "udp"
+	NetworkTCP   = "tcp"
+	NetworkTLS   = "tls"   // DoT
+	NetworkHTTPS = "https" // DoH
+)
+
+// defaultTimeout 单次查询的默认超时
+const defaultTimeout = 3 * time.Second
+
+// Upstream 上游解析服务器
+type Upstream struct {
+	Network    string // udp / tcp / tls / https
+	Address    string // host:port；DoH 为完整 URL
+	ServerName string // DoT 校验证书用的名称
+}
+
+func (u Upstream) String() string {
+	if u.Network == NetworkHTTPS {
+		return u.Address
+	}
+	return u.Network + "://" + u.Address
+}
+
+// ParseUpstream 解析上游地址
+//
+//	1.1.1.1                          UDP，端口 53（截断时自动改用 TCP）
+//	udp://1.1.1.1:53、tcp://8.8.8.8
+//	tls://1.1.1.1:853#cloudflare-dns.com   DoT，# 后为证书名称（默认取主机名）
+//	https://cloudflare-dns.com/dns-query   DoH（http:// 用于本地代理）
+func ParseUpstream(s string) (Upstream, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Upstream{}, errors.New("empty upstream")
+	}
+	if !strings.Contains(s, "://") {
+		s = "udp://" + s
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return Upstream{}, fmt.Errorf("invalid upstream %q: %w", s, err)
+	}
+	switch u.Scheme {
+	case NetworkHTTPS, "http":
+		// http:// 只用于本地的 DoH 代理或替身
+		u.Fragment = ""
+		return Upstream{Network: NetworkHTTPS, Address: u.String()}, nil
+	case NetworkUDP, NetworkTCP, NetworkTLS:
+		port := "53"
+		if u.Scheme == NetworkTLS {
+			port = "853"
+		}
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(strings.Trim(u.Host, "[]"), port)
+		}
+		up := Upstream{Network: u.Scheme, Address: host, ServerName: u.Fragment}
+		if up.Network == NetworkTLS && up.ServerName == "" {
+			up.ServerName = u.Hostname()
+		}
+		return up, nil
+	}
+	return Upstream{}, fmt.Errorf("invalid upstream %q: unsupported scheme %q", s, u.Scheme)
+}
+
+// Config 解析器配置
+type Config struct {
+	Upstreams []Upstream
+	Timeout   time.Duration
+
+	// DNSSEC 是否验证 DNSSEC 签名
+	DNSSEC bool
+	// TrustAnchors 信任锚（DS 记录），按区域名称索引；为空时使用根区的 KSK-2017
+	TrustAnchors []*dns.DS
+
+	// AuthoritativePort 查询权威服务器时使用的端口，默认 53（仅替身测试需要修改）
+	AuthoritativePort string
+}
+
+// Resolver DNS 解析器
+type Resolver struct {
+	cfg     Config
+	anchors map[string][]*dns.DS
+
+	mu         sync.Mutex
+	zoneCache  map[string]zoneEntry      // DNSSEC 区域状态
+	tldServers map[string]tldServerEntry // 后缀的权威服务器地址
+}
+
+// New 创建解析器，未配置上游时使用 /etc/resolv.conf 中的服务器（直接查询，不经过系统解析器）
+func New(cfg Config) *Resolver {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.AuthoritativePort == "" {
+		cfg.AuthoritativePort = "53"
+	}
+	if len(cfg.Upstreams) == 0 {
+		cfg.Upstreams = systemUpstreams()
+	}
+	if len(cfg.TrustAnchors) == 0 {
+		cfg.TrustAnchors = []*dns.DS{rootAnchor()}
+	}
+
+	r := &Resolver{
+		cfg:        cfg,
+		anchors:    map[string][]*dns.DS{},
+		zoneCache:  map[string]zoneEntry{},
+		tldServers: map[string]tldServerEntry{},
+	}
+	for _, ds := range cfg.TrustAnchors {
+		zone := dns.CanonicalName(ds.Hdr.Name)
+		r.anchors[zone] = append(r.anchors[zone], ds)
+	}
+	return r
+}
+
+// ConfigFromEnv 从环境变量读取配置
+//
+// DNS_RESOLVERS 为逗号分隔的上游（格式见 ParseUpstream），DNSSEC_VALIDATE=true 时验证签名，
+// DNS_TIMEOUT 为单次查询超时（Go duration 格式）。
+func ConfigFromEnv() Config {
+	var cfg Config
+	for _, s := range strings.Split(os.Getenv("DNS_RESOLVERS"), ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		up, err := ParseUpstream(s)
+		if err != nil {
+			fmt.Printf("Ignoring DNS resolver: %v\n", err)
+			continue
+		}
+		cfg.Upstreams = append(cfg.Upstreams, up)
+	}
+	cfg.DNSSEC = os.Getenv("DNSSEC_VALIDATE") == "true"
+	if v := os.Getenv("DNS_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Timeout = d
+		} else {
+			fmt.Printf("Invalid DNS_TIMEOUT %q, using default: %v\n", v, err)
+		}
+	}
+	return cfg
+}
+
+// systemUpstreams 读取 /etc/resolv.conf，读取失败时使用公共解析器
+func systemUpstreams() []Upstream {
+	conf, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+	if err != nil || len(conf.Servers) == 0 {
+		return []Upstream{{Network: NetworkUDP, Address: "1.1.1.1:53"}, {Network: NetworkUDP, Address: "8.8.8.8:53"}}
+	}
+	var ups []Upstream
+	for _, s := range conf.Servers {
+		ups = append(ups, Upstream{Network: NetworkUDP, Address: net.JoinHostPort(s, conf.Port)})
+	}
+	return ups
+}
+
+var (
+	defaultResolver *Resolver
+	defaultOnce     sync.Once
+	defaultMu       sync.RWMutex
+)
+
+// Default 默认解析器，首次调用时按环境变量创建
+func Default() *Resolver {
+	defaultOnce.Do(func() {
+		r := New(ConfigFromEnv())
+		defaultMu.Lock()
+		if defaultResolver == nil {
+			defaultResolver = r
+		}
+		defaultMu.Unlock()
+	})
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultResolver
+}
+
+// SetDefault 替换默认解析器，仅应在启动时、处理请求之前调用
+func SetDefault(r *Resolver) {
+	defaultMu.Lock()
+	defaultResolver = r
+	defaultMu.Unlock()
+}
+
+// Answer 查询结果
+type Answer struct {
+	Rcode   int      // dns.RcodeSuccess、dns.RcodeNameError 等
+	Records []dns.RR // 与查询类型相同的记录（CNAME 链上的记录不包括在内）
+	DNSSEC  string   // 未开启验证时为空，见 DNSSEC* 常量
+}
+
+// Lookup 通过上游查询，开启 DNSSEC 时同时验证签名
+func (r *Resolver) Lookup(ctx context.Context, name string, qtype uint16) (*Answer, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	m.RecursionDesired = true
+	if r.cfg.DNSSEC {
+		m.SetEdns0(4096, true)
+	}
+
+	resp, err := r.Exchange(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	answer := &Answer{Rcode: resp.Rcode}
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype == qtype {
+			answer.Records = append(answer.Records, rr)
+		}
+	}
+	if r.cfg.DNSSEC {
+		answer.DNSSEC = r.validateAnswer(ctx, resp, dns.Fqdn(name), qtype)
+	}
+	return answer, nil
+}
+
+// LookupNS 查询 NS 记录，返回服务器名称（不带结尾的点）
+func (r *Resolver) LookupNS(ctx context.Context, name string) ([]string, error) {
+	a, err := r.Lookup(ctx, name, dns.TypeNS)
+	if err != nil {
+		return nil, err
+	}
+	var hosts []string
+	for _, rr := range a.Records {
+		hosts = append(hosts, strings.TrimSuffix(rr.(*dns.NS).Ns, "."))
+	}
+	return hosts, nil
+}
+
+// LookupIP 查询 A 和 AAAA 记录
+func (r *Resolver) LookupIP(ctx context.Context, name string) ([]net.IP, error) {
+	var ips []net.IP
+	var firstErr error
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		a, err := r.Lookup(ctx, name, qtype)
+		if err != nil {
+			firstErr = err
+			continue
+		}
+		for _, rr := range a.Records {
+			switch v := rr.(type) {
+			case *dns.A:
+				ips = append(ips, v.A)
+			case *dns.AAAA:
+				ips = append(ips, v.AAAA)
+			}
+		}
+	}
+	if len(ips) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return ips, nil
+}
+
+// LookupMX 查询 MX 记录，返回邮件服务器名称（不带结尾的点）
+func (r *Resolver) LookupMX(ctx context.Context, name string) ([]string, error) {
+	a, err := r.Lookup(ctx, name, dns.TypeMX)
+	if err != nil {
+		return nil, err
+	}
+	var hosts []string
+	for _, rr := range a.Records {
+		hosts = append(hosts, strings.TrimSuffix(rr.(*dns.MX).Mx, "."))
+	}
+	return hosts, nil
+}
+
+// Exchange 依次尝试各上游，返回第一个成功的响应（SERVFAIL 视为失败）
+func (r *Resolver) Exchange(ctx context.Context, m *dns.Msg) (*dns.Msg, error) {
+	var errs []error
+	for _, up := range r.cfg.Upstreams {
+		resp, err := r.ExchangeWith(ctx, up, m)
+		if err == nil && resp.Rcode != dns.RcodeServerFailure {
+			return resp, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("%s: SERVFAIL", up)
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no DNS upstreams configured")
+	}
+	return nil, errors.Join(errs...)
+}
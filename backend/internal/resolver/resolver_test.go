@@ -0,0 +1,303 @@
+package resolver
+
+import (
+	"context"
+	"crypto"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// standIn 同时充当递归上游和权威服务器的 DNS 替身
+//
+// 提供以 example. 为信任锚的已签名区域（含签名被篡改、被剥离的记录和几个未签名的委派）、
+// 未签名的 plain. 区域，以及可查询委派的 test. 后缀。
+type standIn struct {
+	records map[string][]dns.RR // "名称 类型" → 回答中的记录（含签名）
+	denials map[string][]dns.RR // "名称 类型" → NODATA 回答授权部分的 NSEC/NSEC3（含签名）
+	glue    []dns.RR
+}
+
+func key(name string, qtype uint16) string {
+	return dns.CanonicalName(name) + " " + dns.TypeToString[qtype]
+}
+
+func (s *standIn) add(rrs ...dns.RR) {
+	for _, rr := range rrs {
+		k := key(rr.Header().Name, rr.Header().Rrtype)
+		if sig, ok := rr.(*dns.RRSIG); ok {
+			k = key(sig.Hdr.Name, sig.TypeCovered)
+		}
+		s.records[k] = append(s.records[k], rr)
+	}
+}
+
+// deny 为名称的 DS 查询给出 NODATA 和否认记录
+func (s *standIn) deny(name string, rrs ...dns.RR) {
+	s.denials[key(name, dns.TypeDS)] = rrs
+}
+
+// answer 按查询返回区域数据；test. 下的名称按权威服务器的方式给出委派或 NXDOMAIN
+func (s *standIn) answer(req *dns.Msg) *dns.Msg {
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	q := req.Question[0]
+	name := dns.CanonicalName(q.Name)
+
+	if strings.HasSuffix(name, ".test.") {
+		switch name {
+		case "taken.test.":
+			resp.Ns = append(resp.Ns, mustRR("taken.test. 172800 IN NS ns1.hosting.net."), mustRR("taken.test. 172800 IN NS ns2.hosting.net."))
+		default:
+			resp.Rcode = dns.RcodeNameError
+			resp.Ns = append(resp.Ns, mustRR("test. 900 IN SOA ns1.test. admin.test. 1 1800 900 604800 86400"))
+		}
+		return resp
+	}
+
+	opt := req.IsEdns0()
+	withSigs := func(rrs []dns.RR) []dns.RR {
+		var list []dns.RR
+		for _, rr := range rrs {
+			if _, isSig := rr.(*dns.RRSIG); !isSig || (opt != nil && opt.Do()) {
+				list = append(list, rr)
+			}
+		}
+		return list
+	}
+
+	rrs, ok := s.records[key(name, q.Qtype)]
+	if !ok {
+		if denial, ok := s.denials[key(name, q.Qtype)]; ok {
+			resp.Ns = withSigs(denial)
+		} else {
+			resp.Rcode = dns.RcodeNameError
+		}
+		return resp
+	}
+	resp.Answer = withSigs(rrs)
+	if q.Qtype == dns.TypeNS && name == "test." {
+		resp.Extra = append(resp.Extra, s.glue...)
+	}
+	return resp
+}
+
+// zoneKey 生成区域密钥，返回签名函数：签名附在记录集之后
+func zoneKey(t *testing.T, zone string) (*dns.DNSKEY, func(rrs ...dns.RR) []dns.RR) {
+	t.Helper()
+	dnskey := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: zone, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     257,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	priv, err := dnskey.Generate(256)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer := priv.(crypto.Signer)
+	return dnskey, func(rrs ...dns.RR) []dns.RR {
+		sig := &dns.RRSIG{
+			Hdr:        dns.RR_Header{Name: rrs[0].Header().Name, Rrtype: dns.TypeRRSIG, Class: dns.ClassINET, Ttl: 3600},
+			KeyTag:     dnskey.KeyTag(),
+			SignerName: zone,
+			Algorithm:  dnskey.Algorithm,
+			Inception:  uint32(time.Now().Add(-time.Hour).Unix()),
+			Expiration: uint32(time.Now().Add(24 * time.Hour).Unix()),
+		}
+		if err := sig.Sign(signer, rrs); err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return append(rrs, sig)
+	}
+}
+
+// nsec 名称自身的 NSEC 记录
+func nsec(name string, types ...uint16) dns.RR {
+	types = append(types, dns.TypeRRSIG, dns.TypeNSEC)
+	slices.Sort(types)
+	return &dns.NSEC{
+		Hdr:        dns.RR_Header{Name: name, Rrtype: dns.TypeNSEC, Class: dns.ClassINET, Ttl: 3600},
+		NextDomain: "\\000." + name,
+		TypeBitMap: types,
+	}
+}
+
+// fixture 替身的监听地址和信任锚
+type fixture struct {
+	addr   string // UDP 和 TCP 共用
+	doh    string
+	port   string
+	anchor *dns.DS
+}
+
+func startStandIn(t *testing.T) fixture {
+	s := &standIn{records: map[string][]dns.RR{}, denials: map[string][]dns.RR{}}
+
+	dnskey, sign := zoneKey(t, "example.")
+	s.add(sign(dnskey)...)
+	s.add(sign(mustRR("shop.example. 300 IN A 192.0.2.10"))...)
+	s.add(sign(mustRR("shop.example. 300 IN MX 10 mail.shop.example."))...)
+	s.deny("shop.example.", sign(nsec("shop.example.", dns.TypeA, dns.TypeMX))...)
+	// 签名后篡改记录
+	tampered := sign(mustRR("tampered.example. 300 IN A 192.0.2.20"))
+	tampered[0].(*dns.A).A = net.ParseIP("198.51.100.1")
+	s.add(tampered...)
+	s.deny("tampered.example.", sign(nsec("tampered.example.", dns.TypeA))...)
+	// 没有签名，父区域证明它不是委派点
+	s.add(mustRR("stripped.example. 300 IN A 192.0.2.30"))
+	s.deny("stripped.example.", sign(nsec("stripped.example.", dns.TypeA))...)
+
+	// 未签名的委派：NSEC 否认 DS、NSEC3 opt-out、没有否认、否认的签名不是父区域的密钥
+	s.add(mustRR("www.insecure.example. 300 IN A 192.0.2.50"))
+	s.deny("insecure.example.", sign(nsec("insecure.example.", dns.TypeNS))...)
+
+	s.add(mustRR("www.optout.example. 300 IN A 192.0.2.51"))
+	apex := &dns.NSEC3{
+		Hdr:        dns.RR_Header{Rrtype: dns.TypeNSEC3, Class: dns.ClassINET, Ttl: 3600},
+		Hash:       dns.SHA1,
+		Flags:      1,
+		Iterations: 0,
+		HashLength: 20,
+		TypeBitMap: []uint16{dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG, dns.TypeDNSKEY, dns.TypeNSEC3PARAM},
+	}
+	// 区域中只有这一条 NSEC3：匹配顶点，覆盖其余所有哈希
+	apex.NextDomain = dns.HashName("example.", apex.Hash, apex.Iterations, apex.Salt)
+	apex.Hdr.Name = strings.ToLower(apex.NextDomain) + ".example."
+	s.deny("optout.example.", sign(apex)...)
+
+	s.add(mustRR("www.undenied.example. 300 IN A 192.0.2.52"))
+	s.deny("undenied.example.")
+
+	_, forge := zoneKey(t, "example.")
+	s.add(mustRR("www.forged.example. 300 IN A 192.0.2.53"))
+	s.deny("forged.example.", forge(nsec("forged.example.", dns.TypeNS))...)
+
+	// 未签名的 plain. 区域
+	s.add(mustRR("www.plain. 300 IN A 192.0.2.40"))
+
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) { w.WriteMsg(s.answer(req)) })
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	addr := pc.LocalAddr().String()
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		pc.Close()
+		t.Fatalf("listen tcp: %v", err)
+	}
+
+	// test. 的权威服务器就是替身本身；区域数据全部加好后才开始服务，处理查询时只读
+	_, port, _ := net.SplitHostPort(addr)
+	s.add(mustRR("test. 172800 IN NS ns1.test."))
+	s.glue = []dns.RR{mustRR("ns1.test. 172800 IN A 127.0.0.1")}
+
+	udp := &dns.Server{PacketConn: pc, Handler: handler}
+	tcp := &dns.Server{Listener: ln, Handler: handler}
+	go udp.ActivateAndServe()
+	go tcp.ActivateAndServe()
+	t.Cleanup(func() {
+		udp.Shutdown()
+		tcp.Shutdown()
+	})
+	doh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := new(dns.Msg)
+		if err := req.Unpack(body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		packed, _ := s.answer(req).Pack()
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(packed)
+	}))
+	t.Cleanup(doh.Close)
+
+	return fixture{addr: addr, doh: doh.URL, port: port, anchor: dnskey.ToDS(dns.SHA256)}
+}
+
+// resolvers 分别通过 UDP、TCP 和 DoH 查询替身的解析器
+func (f fixture) resolvers(t *testing.T) []*Resolver {
+	var list []*Resolver
+	for _, upstream := range []string{"udp://" + f.addr, "tcp://" + f.addr, f.doh} {
+		up, err := ParseUpstream(upstream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, New(Config{
+			Upstreams:         []Upstream{up},
+			DNSSEC:            true,
+			TrustAnchors:      []*dns.DS{f.anchor},
+			AuthoritativePort: f.port,
+		}))
+	}
+	return list
+}
+
+func TestLookupDNSSEC(t *testing.T) {
+	f := startStandIn(t)
+	tests := []struct {
+		name, want string
+		records    int
+	}{
+		{"shop.example", DNSSECSecure, 1},
+		{"tampered.example", DNSSECBogus, 1},
+		{"stripped.example", DNSSECBogus, 1},
+		{"www.insecure.example", DNSSECInsecure, 1},
+		{"www.optout.example", DNSSECInsecure, 1},
+		{"www.undenied.example", DNSSECIndeterminate, 1},
+		{"www.forged.example", DNSSECIndeterminate, 1},
+		{"www.plain", DNSSECInsecure, 1},
+		{"missing.example", DNSSECIndeterminate, 0},
+	}
+	for _, r := range f.resolvers(t) {
+		for _, tt := range tests {
+			a, err := r.Lookup(context.Background(), tt.name, dns.TypeA)
+			if err != nil {
+				t.Errorf("%s A %s: %v", r.cfg.Upstreams[0], tt.name, err)
+				continue
+			}
+			if a.DNSSEC != tt.want || len(a.Records) != tt.records {
+				t.Errorf("%s A %s: dnssec=%s records=%d, want %s/%d", r.cfg.Upstreams[0], tt.name, a.DNSSEC, len(a.Records), tt.want, tt.records)
+			}
+		}
+	}
+}
+
+func TestLookupMX(t *testing.T) {
+	for _, r := range startStandIn(t).resolvers(t) {
+		mx, err := r.LookupMX(context.Background(), "shop.example")
+		if err != nil || len(mx) != 1 || mx[0] != "mail.shop.example" {
+			t.Errorf("%s: LookupMX = %v, %v", r.cfg.Upstreams[0], mx, err)
+		}
+	}
+}
+
+func TestDelegation(t *testing.T) {
+	for _, r := range startStandIn(t).resolvers(t) {
+		d, err := r.Delegation(context.Background(), "taken.test")
+		if err != nil || !d.Referral || d.Rcode != "NOERROR" || d.Zone != "test" || len(d.NameServers) != 2 {
+			t.Errorf("%s: Delegation(taken.test) = %+v, %v", r.cfg.Upstreams[0], d, err)
+		}
+		d, err = r.Delegation(context.Background(), "free.test")
+		if err != nil || d.Referral || d.Rcode != "NXDOMAIN" {
+			t.Errorf("%s: Delegation(free.test) = %+v, %v", r.cfg.Upstreams[0], d, err)
+		}
+	}
+}
+
+func mustRR(s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		panic(err)
+	}
+	return rr
+}
@@ -0,0 +1,85 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/miekg/dns"
+)
+
+// dohContentType RFC 8484 的报文类型
+const dohContentType = "application/dns-message"
+
+// ExchangeWith 向指定的服务器发送一次查询
+//
+// UDP 响应被截断时自动用 TCP 重试。
+func (r *Resolver) ExchangeWith(ctx context.Context, up Upstream, m *dns.Msg) (*dns.Msg, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer cancel()
+
+	switch up.Network {
+	case NetworkHTTPS:
+		return r.exchangeDoH(ctx, up.Address, m)
+	case NetworkTLS:
+		c := &dns.Client{Net: "tcp-tls", Timeout: r.cfg.Timeout, TLSConfig: &tls.Config{ServerName: up.ServerName}}
+		return exchange(ctx, c, up, m)
+	case NetworkTCP:
+		return exchange(ctx, &dns.Client{Net: "tcp", Timeout: r.cfg.Timeout}, up, m)
+	default:
+		resp, err := exchange(ctx, &dns.Client{Net: "udp", Timeout: r.cfg.Timeout, UDPSize: 4096}, up, m)
+		if err == nil && resp.Truncated {
+			return exchange(ctx, &dns.Client{Net: "tcp", Timeout: r.cfg.Timeout}, up, m)
+		}
+		return resp, err
+	}
+}
+
+func exchange(ctx context.Context, c *dns.Client, up Upstream, m *dns.Msg) (*dns.Msg, error) {
+	resp, _, err := c.ExchangeContext(ctx, m, up.Address)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", up, err)
+	}
+	return resp, nil
+}
+
+// exchangeDoH 按 RFC 8484 用 POST 发送报文
+func (r *Resolver) exchangeDoH(ctx context.Context, endpoint string, m *dns.Msg) (*dns.Msg, error) {
+	// DoH 要求报文 ID 为 0，便于 HTTP 缓存
+	q := m.Copy()
+	q.Id = 0
+	packed, err := q.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", dohContentType)
+	req.Header.Set("Accept", dohContentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: HTTP %d", endpoint, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 65535))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", endpoint, err)
+	}
+	answer := new(dns.Msg)
+	if err := answer.Unpack(body); err != nil {
+		return nil, fmt.Errorf("%s: unpack response: %w", endpoint, err)
+	}
+	answer.Id = m.Id
+	return answer, nil
+}
@@ -0,0 +1,263 @@
+package scanner
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"domain-agent/backend/internal/lexicon"
+	"domain-agent/backend/internal/types"
+)
+
+// 替代域名的变体类型
+const (
+	VariantTLDSwap = "tld_swap"
+	VariantPrefix  = "prefix"
+	VariantSuffix  = "suffix"
+	VariantPlural  = "plural"
+	VariantHyphen  = "hyphen"
+	VariantSynonym = "synonym"
+)
+
+// maxAlternativeCandidates 单次最多检查的候选数，避免触发 WHOIS 限流
+const maxAlternativeCandidates = 40
+
+// maxAlternatives 单次最多返回的替代数
+const maxAlternatives = 50
+
+var (
+	alternativeTLDs     = []string{".com", ".io", ".ai", ".co", ".app", ".dev", ".net", ".cn", ".tech"}
+	alternativePrefixes = []string{"get", "try", "use", "go", "my"}
+	alternativeSuffixes = []string{"hq", "app", "hub", "labs", "now"}
+)
+
+// GenerateVariants 为一个域名生成相近的变体（不含原域名）
+//
+// 包括后缀替换、前缀（get/try/use）、后缀词（hq/app）、单复数、连字符和近义词替换。
+func GenerateVariants(domain string, tlds []string) []types.AlternativeDomain {
+	label, tld := splitDomain(domain)
+	if len(tlds) == 0 {
+		tlds = alternativeTLDs
+	}
+
+	var variants []types.AlternativeDomain
+	seen := map[string]bool{domain: true}
+	add := func(l, t, kind string) {
+		if l == "" || len(l) > 63 || strings.HasPrefix(l, "-") || strings.HasSuffix(l, "-") {
+			return
+		}
+		d := l + t
+		if seen[d] {
+			return
+		}
+		seen[d] = true
+		variants = append(variants, types.AlternativeDomain{
+			Domain:     d,
+			Variant:    kind,
+			Similarity: domainSimilarity(domain, d),
+			Score:      calculateScore(d),
+		})
+	}
+
+	// 1. 后缀替换
+	for _, t := range tlds {
+		add(label, normalizeTLD(t), VariantTLDSwap)
+	}
+
+	// 2. 前缀和后缀词
+	for _, p := range alternativePrefixes {
+		add(p+label, tld, VariantPrefix)
+	}
+	for _, s := range alternativeSuffixes {
+		add(label+s, tld, VariantSuffix)
+	}
+
+	// 3. 单复数
+	switch {
+	case strings.HasSuffix(label, "ies") && len(label) > 4:
+		add(strings.TrimSuffix(label, "ies")+"y", tld, VariantPlural)
+	case strings.HasSuffix(label, "s") && len(label) > 3:
+		add(strings.TrimSuffix(label, "s"), tld, VariantPlural)
+	case strings.HasSuffix(label, "y") && len(label) > 2 && !strings.ContainsRune("aeiou", rune(label[len(label)-2])):
+		add(strings.TrimSuffix(label, "y")+"ies", tld, VariantPlural)
+	case strings.HasSuffix(label, "sh") || strings.HasSuffix(label, "ch") || strings.HasSuffix(label, "x"):
+		add(label+"es", tld, VariantPlural)
+	default:
+		add(label+"s", tld, VariantPlural)
+	}
+
+	// 4. 连字符：在可识别的词边界处断开
+	for _, split := range wordSplits(label) {
+		add(split[0]+"-"+split[1], tld, VariantHyphen)
+	}
+
+	// 5. 近义词替换
+	for _, word := range lexicon.Headwords() {
+		if !strings.Contains(label, word) {
+			continue
+		}
+		for _, syn := range lexicon.Synonyms(word) {
+			add(strings.Replace(label, word, syn, 1), tld, VariantSynonym)
+		}
+	}
+
+	sort.SliceStable(variants, func(i, j int) bool {
+		return variants[i].Similarity > variants[j].Similarity
+	})
+	return variants
+}
+
+// FindAlternatives 为已注册的域名寻找可注册的替代
+//
+// 生成变体后批量检查可用性，只保留可注册的，按相似度和评分的综合分排序。
+func FindAlternatives(req types.AlternativesRequest) ([]types.AlternativeDomain, error) {
+	domain, err := Hostname(req.Domain)
+	if err != nil {
+		return nil, err
+	}
+	count := clampCount(req.Count, 10, maxAlternatives)
+
+	// 请求中的后缀可能带有无效字符，拼出的候选在检查之前丢弃
+	variants := slices.DeleteFunc(GenerateVariants(domain, req.TLDs), func(v types.AlternativeDomain) bool {
+		return !validHostname(v.Domain)
+	})
+	if len(variants) > maxAlternativeCandidates {
+		variants = variants[:maxAlternativeCandidates]
+	}
+
+	candidates := make([]string, len(variants))
+	byDomain := make(map[string]*types.AlternativeDomain, len(variants))
+	for i := range variants {
+		candidates[i] = variants[i].Domain
+		byDomain[variants[i].Domain] = &variants[i]
+	}
+
+	results, err := CheckDomains(candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	alternatives := []types.AlternativeDomain{}
+	for _, r := range results {
+		v, ok := byDomain[r.Domain]
+		if !ok || !r.Available {
+			continue
+		}
+		v.Available = true
+		v.Rank = rankAlternative(v.Similarity, v.Score)
+		alternatives = append(alternatives, *v)
+	}
+
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		return alternatives[i].Rank > alternatives[j].Rank
+	})
+	if len(alternatives) > count {
+		alternatives = alternatives[:count]
+	}
+	return alternatives, nil
+}
+
+// clampCount 请求的数量：0 使用默认值，其余限制在 1 到 limit 之间
+func clampCount(count, def, limit int) int {
+	if count == 0 {
+		return def
+	}
+	return min(max(count, 1), limit)
+}
+
+// rankAlternative 综合排序分：相似度占 60%，评分（归一化到 0-1）占 40%
+func rankAlternative(similarity, score float64) float64 {
+	return 0.6*similarity + 0.4*normalizeScore(score)
+}
+
+// domainSimilarity 域名相似度：名称的编辑距离相似度占 70%，后缀相同占 30%
+func domainSimilarity(a, b string) float64 {
+	labelA, tldA := splitDomain(a)
+	labelB, tldB := splitDomain(b)
+
+	similarity := 0.7 * stringSimilarity(labelA, labelB)
+	if tldA == tldB {
+		similarity += 0.3
+	}
+	return similarity
+}
+
+// stringSimilarity 基于编辑距离的相似度（0-1）
+func stringSimilarity(a, b string) float64 {
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// levenshtein 编辑距离
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// wordSplits 找出名称中两段都是已知词的断点
+func wordSplits(label string) [][2]string {
+	var splits [][2]string
+	for i := 2; i <= len(label)-2; i++ {
+		left, right := label[:i], label[i:]
+		if isKnownWord(left) && isKnownWord(right) {
+			splits = append(splits, [2]string{left, right})
+		}
+	}
+	return splits
+}
+
+// isKnownWord 是否为近义词表或前后缀表中的词
+func isKnownWord(word string) bool {
+	if lexicon.IsWord(word) {
+		return true
+	}
+	for _, list := range [][]string{alternativePrefixes, alternativeSuffixes} {
+		for _, w := range list {
+			if w == word {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitDomain 拆分为名称和后缀（后缀带点，如 ".com"、".com.cn"）
+func splitDomain(domain string) (string, string) {
+	i := strings.Index(domain, ".")
+	if i == -1 {
+		return domain, ".com"
+	}
+	return domain[:i], domain[i:]
+}
+
+// normalizeTLD 统一后缀格式为带点的小写形式
+func normalizeTLD(tld string) string {
+	tld = strings.ToLower(strings.TrimSpace(tld))
+	if !strings.HasPrefix(tld, ".") {
+		tld = "." + tld
+	}
+	return tld
+}
@@ -0,0 +1,76 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	"domain-agent/backend/internal/scanner/scannertest"
+	"domain-agent/backend/internal/types"
+)
+
+func TestGenerateVariants(t *testing.T) {
+	variants := GenerateVariants("kitleaf.com", []string{"io", ".ai"})
+	kinds := map[string]string{}
+	for _, v := range variants {
+		if v.Domain == "kitleaf.com" {
+			t.Errorf("variants include the original domain")
+		}
+		kinds[v.Domain] = v.Variant
+	}
+	for domain, kind := range map[string]string{
+		"kitleaf.io":     VariantTLDSwap,
+		"kitleaf.ai":     VariantTLDSwap,
+		"getkitleaf.com": VariantPrefix,
+		"kitleafhq.com":  VariantSuffix,
+		"kitleafs.com":   VariantPlural,
+	} {
+		if kinds[domain] != kind {
+			t.Errorf("%s: variant %q, want %q", domain, kinds[domain], kind)
+		}
+	}
+	for i := 1; i < len(variants); i++ {
+		if variants[i].Similarity > variants[i-1].Similarity {
+			t.Fatalf("variants not sorted by similarity at %d", i)
+		}
+	}
+}
+
+func TestClampCount(t *testing.T) {
+	tests := []struct{ count, want int }{
+		{0, 10},
+		{-1, 1},
+		{5, 5},
+		{50, 50},
+		{1000, 50},
+	}
+	for _, tt := range tests {
+		if got := clampCount(tt.count, 10, 50); got != tt.want {
+			t.Errorf("clampCount(%d) = %d, want %d", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestFindAlternatives(t *testing.T) {
+	f := scannertest.Start(t, map[string]scannertest.Domain{
+		"kitleaf.test":    {NameServers: []string{"ns1.example.net"}, Addresses: []string{"192.0.2.1"}},
+		"getkitleaf.test": {NameServers: []string{"ns1.example.net"}},
+	})
+
+	for _, count := range []int{-1, 0, 3, 1000} {
+		found, err := FindAlternatives(types.AlternativesRequest{Domain: "kitleaf.test", TLDs: []string{"test"}, Count: count})
+		if err != nil {
+			t.Fatalf("count %d: %v", count, err)
+		}
+		if want := clampCount(count, 10, maxAlternatives); len(found) > want || len(found) == 0 {
+			t.Errorf("count %d: %d alternative(s), want 1..%d", count, len(found), want)
+		}
+		for _, a := range found {
+			if a.Domain == "getkitleaf.test" || !strings.HasSuffix(a.Domain, ".test") {
+				t.Errorf("count %d: unexpected alternative %s", count, a.Domain)
+			}
+		}
+	}
+	if f.Queries(scannertest.SourceDelegation, "getkitleaf.test") == 0 {
+		t.Errorf("registered variant was never checked")
+	}
+}
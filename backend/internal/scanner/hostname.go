@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ErrInvalidDomain 域名不是有效的主机名
+var ErrInvalidDomain = errors.New("invalid domain")
+
+// Hostname 把域名转为小写的 ASCII 形式（国际化域名转为 xn--），并检查是否是 LDH 主机名：
+// 至少两个标签，每个标签 1–63 个字母、数字或连字符，不以连字符开头或结尾，总长不超过 253
+//
+// 域名会原样写进 WHOIS 查询行、RDAP 地址和历史记录的键，所有入口都要在查询之前拒绝
+// 换行、空格、斜杠等字符。返回的错误包装 ErrInvalidDomain。
+func Hostname(s string) (string, error) {
+	ascii, err := idna.Lookup.ToASCII(strings.TrimSuffix(strings.TrimSpace(s), "."))
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %v", ErrInvalidDomain, s, err)
+	}
+	ascii = strings.ToLower(ascii)
+	if len(ascii) > 253 {
+		return "", fmt.Errorf("%w %q: longer than 253 characters", ErrInvalidDomain, s)
+	}
+	labels := strings.Split(ascii, ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("%w %q: needs a name and a suffix", ErrInvalidDomain, s)
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", fmt.Errorf("%w %q: invalid label %q", ErrInvalidDomain, s, label)
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return "", fmt.Errorf("%w %q: invalid character %q in label %q", ErrInvalidDomain, s, r, label)
+			}
+		}
+	}
+	return ascii, nil
+}
+
+// hostnames 规范化一批域名，有任何一个无效时返回错误
+func hostnames(domains []string) ([]string, error) {
+	names := make([]string, len(domains))
+	for i, d := range domains {
+		name, err := Hostname(d)
+		if err != nil {
+			return nil, err
+		}
+		names[i] = name
+	}
+	return names, nil
+}
+
+// validHostname 生成的候选是否是有效的主机名，用来在检查之前丢弃无效的候选
+func validHostname(domain string) bool {
+	_, err := Hostname(domain)
+	return err == nil
+}
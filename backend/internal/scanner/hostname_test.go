@@ -0,0 +1,56 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"domain-agent/backend/internal/scanner/scannertest"
+	"domain-agent/backend/internal/types"
+)
+
+func TestHostname(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Taken.Test.", "taken.test"},
+		{" taken.test ", "taken.test"},
+		{"Bücher.example", "xn--bcher-kva.example"},
+		{"xn--bcher-kva.example", "xn--bcher-kva.example"},
+		{"a-b.c1.test", "a-b.c1.test"},
+		{"taken.test\r\nevil", ""},
+		{"taken.test/evil", ""},
+		{"test", ""},
+		{"a-.test", ""},
+		{strings.Repeat("a", 64) + ".test", ""},
+	}
+	for _, tt := range tests {
+		got, err := Hostname(tt.in)
+		if got != tt.want || (err == nil) != (tt.want != "") || (err != nil && !errors.Is(err, ErrInvalidDomain)) {
+			t.Errorf("Hostname(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+// TestInvalidDomainsNotQueried 批量检查中有无效域名时整批拒绝；替代请求中无效的后缀只丢弃对应的候选
+func TestInvalidDomainsNotQueried(t *testing.T) {
+	tld := scannertest.Start(t, map[string]scannertest.Domain{
+		"kitleaf.test": {NameServers: []string{"ns1.example.net"}},
+	})
+	if _, err := CheckDomains([]string{"free.test", "free.test\r\nevil.test"}); !errors.Is(err, ErrInvalidDomain) {
+		t.Errorf("CheckDomains = %v, want ErrInvalidDomain", err)
+	}
+	if n := tld.Queries(scannertest.SourceRDAP, "free.test"); n != 0 {
+		t.Errorf("free.test queried %d time(s) in a rejected batch", n)
+	}
+
+	found, err := FindAlternatives(types.AlternativesRequest{Domain: "Kitleaf.Test", TLDs: []string{"test", "x\r\ny"}})
+	if err != nil || len(found) == 0 {
+		t.Fatalf("FindAlternatives = %+v, %v", found, err)
+	}
+	for _, a := range found {
+		if strings.ContainsAny(a.Domain, "\r\n") {
+			t.Errorf("alternative %q", a.Domain)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"domain-agent/backend/internal/aftermarket"
+	"domain-agent/backend/internal/evidence"
+	"domain-agent/backend/internal/history"
+	"domain-agent/backend/internal/rdap"
+	"domain-agent/backend/internal/tldwhois"
+	"domain-agent/backend/internal/types"
+
+	"github.com/miekg/dns"
+)
+
+// defaultLookupTTL 原始查询结果的默认缓存时间
+const defaultLookupTTL = 10 * time.Minute
+
+// maxLookupEntries 每种查询缓存的域名数超过该值时清理过期的条目
+const maxLookupEntries = 1000
+
+// lookupCache 按域名缓存原始查询结果
+type lookupCache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]lookupEntry[T]
+}
+
+type lookupEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+func (c *lookupCache[T]) get(domain string) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[domain]
+	if !ok || time.Now().After(e.expires) {
+		var zero T
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (c *lookupCache[T]) put(domain string, value T) {
+	ttl := lookupTTL()
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]lookupEntry[T]{}
+	}
+	if len(c.entries) >= maxLookupEntries {
+		now := time.Now()
+		for d, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, d)
+			}
+		}
+	}
+	c.entries[domain] = lookupEntry[T]{value: value, expires: time.Now().Add(ttl)}
+}
+
+var (
+	whoisLookups lookupCache[types.WHOISLookup]
+	rdapLookups  lookupCache[types.RDAPLookup]
+	dnsLookups   lookupCache[types.DNSLookup]
+
+	lookupTTLValue time.Duration
+	lookupTTLOnce  sync.Once
+)
+
+// lookupTTL 缓存时间，可以用 LOOKUP_CACHE_TTL 覆盖（Go duration 格式，0 为不缓存）
+func lookupTTL() time.Duration {
+	lookupTTLOnce.Do(func() {
+		lookupTTLValue = defaultLookupTTL
+		if v := os.Getenv("LOOKUP_CACHE_TTL"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil {
+				lookupTTLValue = d
+			} else {
+				fmt.Printf("Invalid LOOKUP_CACHE_TTL %q, using default: %v\n", v, err)
+			}
+		}
+	})
+	return lookupTTLValue
+}
+
+// LookupWHOIS 查询 WHOIS，返回原始文本，以及检查域名时对它的判断和解析出的注册信息
+//
+// 与 checkSingleDomain 使用相同的查询、判断和解析，用于排查某个域名为什么被判为已注册或可注册。
+// 结果按域名缓存；无法判断（多为限流或网络错误）时不缓存。refresh 为 true 时忽略缓存重新查询。
+func LookupWHOIS(domain string, refresh bool) types.WHOISLookup {
+	if !refresh {
+		if l, ok := whoisLookups.get(domain); ok {
+			l.Cached = true
+			return l
+		}
+	}
+
+	ev, raw := checkWHOIS(context.Background(), domain)
+	l := types.WHOISLookup{Domain: domain, Rule: "default", Raw: raw, Evidence: weigh(domain, ev)[0], FetchedAt: time.Now().UTC()}
+	if rule := tldwhois.Default().For(domain); rule.TLD != "" {
+		l.Rule, l.Server = rule.TLD, rule.Server
+	}
+	if raw != "" {
+		l.Registration = parseRegistration(domain, raw)
+	}
+	if ev.Indicates == evidence.Taken {
+		l.AftermarketSignals = aftermarket.WHOISSignals(registrantFields(raw))
+	}
+	if ev.Indicates != evidence.Unknown {
+		recordSnapshot(history.FromWHOIS(l.Registration))
+		whoisLookups.put(domain, l)
+	}
+	return l
+}
+
+// LookupRDAP 查询 RDAP，返回原始响应，以及检查域名时对它的判断和解析出的注册信息
+//
+// 缓存规则同 LookupWHOIS。
+func LookupRDAP(domain string, refresh bool) types.RDAPLookup {
+	if !refresh {
+		if l, ok := rdapLookups.get(domain); ok {
+			l.Cached = true
+			return l
+		}
+	}
+
+	ev, d := checkRDAP(context.Background(), domain)
+	l := types.RDAPLookup{Domain: domain, Evidence: weigh(domain, ev)[0], FetchedAt: time.Now().UTC()}
+	if d != nil {
+		l.Server = d.Server
+		l.Raw = d.Raw
+		l.Registration = registrationFromRDAP(domain, d)
+	}
+	if ev.Indicates != evidence.Unknown {
+		recordSnapshot(history.FromRDAP(domain, d))
+		rdapLookups.put(domain, l)
+	}
+	return l
+}
+
+// LookupDNS 查询后缀服务器的委派和上游解析器的 NS、A、AAAA、MX 记录，返回记录和对应的证据
+//
+// 与检查域名时的第一轮查询相同。区域数据覆盖该后缀时同时给出区域数据的证据。后缀服务器不可达时不缓存。
+func LookupDNS(domain string, refresh bool) types.DNSLookup {
+	if !refresh {
+		if l, ok := dnsLookups.get(domain); ok {
+			l.Cached = true
+			return l
+		}
+	}
+
+	l := types.DNSLookup{Domain: domain, Answers: []types.DNSAnswer{}, FetchedAt: time.Now().UTC()}
+	var found []types.Evidence
+	if ev, ok := zoneEvidence(domain); ok {
+		found = append(found, ev)
+	}
+	l.Delegation = checkDelegation(context.Background(), domain)
+	found = append(found, delegationEvidence(l.Delegation))
+	if l.Delegation != nil {
+		recordSnapshot(history.FromDelegation(domain, l.Delegation))
+	}
+
+	results := queryDNS(context.Background(), domain)
+	for _, r := range results {
+		l.Answers = append(l.Answers, dnsAnswer(r))
+	}
+	l.DNSSEC = results[0].DNSSEC()
+	l.Evidence = weigh(domain, append(found, dnsSignatures(results)...)...)
+
+	if l.Delegation != nil {
+		dnsLookups.put(domain, l)
+	}
+	return l
+}
+
+// weigh 按后缀的权重写入每条证据的权重，与合并结论时使用的相同
+func weigh(domain string, ev ...types.Evidence) []types.Evidence {
+	_, tld := splitDomain(strings.ToLower(domain))
+	evidence.Default().Combine(tld, ev)
+	return ev
+}
+
+// registrationFromRDAP 把 RDAP 域名对象转换为与 WHOIS 相同的注册信息结构
+func registrationFromRDAP(domain string, d *rdap.Domain) *types.Registration {
+	return &types.Registration{
+		Domain:      domain,
+		Registered:  true,
+		Registrar:   d.Registrar,
+		CreatedAt:   d.CreatedAt,
+		UpdatedAt:   d.UpdatedAt,
+		ExpiresAt:   d.ExpiresAt,
+		NameServers: d.NameServers,
+		Status:      d.Status,
+	}
+}
+
+// dnsAnswer 转换为接口返回的结构，记录为区域文件格式
+func dnsAnswer(r dnsResult) types.DNSAnswer {
+	a := types.DNSAnswer{Type: dns.TypeToString[r.qtype]}
+	if r.err != nil {
+		a.Error = r.err.Error()
+		return a
+	}
+	a.Rcode = dns.RcodeToString[r.answer.Rcode]
+	a.DNSSEC = r.answer.DNSSEC
+	for _, rr := range r.answer.Records {
+		a.Records = append(a.Records, rr.String())
+	}
+	return a
+}
@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"domain-agent/backend/internal/evidence"
+	"domain-agent/backend/internal/history"
+	"domain-agent/backend/internal/tldwhois"
+	"domain-agent/backend/internal/types"
+)
+
+// WHOIS 字段别名（小写），不同注册局的写法差异较大
+var (
+	registrarKeys  = []string{"registrar", "sponsoring registrar", "registrar name"}
+	registrantKeys = []string{"registrant organization", "registrant", "registrant name", "org"}
+	createdKeys    = []string{"creation date", "created", "created on", "registration time", "registered on", "domain registration date"}
+	updatedKeys    = []string{"updated date", "last updated", "last modified", "modified", "changed"}
+	expiresKeys    = []string{"registry expiry date", "registrar registration expiration date", "expiration date", "expiration time", "expiry date", "expires on", "expires", "paid-till"}
+	nameServerKeys = []string{"name server", "nameserver", "nserver", "name servers"}
+	statusKeys     = []string{"domain status", "status"}
+)
+
+// LookupRegistration 查询 WHOIS 并解析注册信息（注册商、持有者、注册/到期时间等），同时记录快照
+func LookupRegistration(domain string) (*types.Registration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	raw, err := tldwhois.Default().Query(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	reg := parseRegistration(domain, raw)
+	if indicates, _ := classifyWHOIS(domain, raw); indicates != evidence.Unknown {
+		recordSnapshot(history.FromWHOIS(reg))
+	}
+	return reg, nil
+}
+
+// RecordRegistration 查询 RDAP 和 WHOIS 并记录快照，查询的来源都失败时返回错误
+//
+// refresh 为 false 时只查询还没有快照或快照已过期的来源，两者都新鲜时不发请求。
+func RecordRegistration(domain string, refresh bool) error {
+	var errs []error
+	queried := 0
+	if refresh || history.Default().Stale(domain, history.SourceRDAP) {
+		queried++
+		if ev, d := checkRDAP(context.Background(), domain); ev.Indicates != evidence.Unknown {
+			recordSnapshot(history.FromRDAP(domain, d))
+		} else {
+			errs = append(errs, fmt.Errorf("rdap: %s", ev.Detail))
+		}
+	}
+	if refresh || history.Default().Stale(domain, history.SourceWHOIS) {
+		queried++
+		if _, err := LookupRegistration(domain); err != nil {
+			errs = append(errs, fmt.Errorf("whois: %w", err))
+		}
+	}
+	if queried > 0 && len(errs) == queried {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// recordSnapshot 记录注册信息快照，存储失败只打印提示，不影响检查结果
+func recordSnapshot(snapshot types.RegistrationSnapshot) {
+	if err := history.Default().Record(snapshot); err != nil {
+		fmt.Printf("Failed to record %s snapshot of %s: %v\n", snapshot.Source, snapshot.Domain, err)
+	}
+}
+
+// parseRegistration 从 WHOIS 文本中提取注册信息
+func parseRegistration(domain, raw string) *types.Registration {
+	reg := &types.Registration{
+		Domain:     domain,
+		Registered: raw != "" && !parseWHOISAvailability(domain, raw),
+	}
+
+	fields := parseFields(raw)
+	reg.Registrar = firstField(fields, registrarKeys)
+	reg.Registrant = firstField(fields, registrantKeys)
+	reg.CreatedAt = firstField(fields, createdKeys)
+	reg.UpdatedAt = firstField(fields, updatedKeys)
+	reg.ExpiresAt = firstField(fields, expiresKeys)
+
+	seen := map[string]bool{}
+	for _, key := range nameServerKeys {
+		for _, ns := range fields[key] {
+			ns = strings.ToLower(strings.TrimSuffix(strings.Fields(ns)[0], "."))
+			if !seen[ns] {
+				seen[ns] = true
+				reg.NameServers = append(reg.NameServers, ns)
+			}
+		}
+	}
+
+	for _, key := range statusKeys {
+		for _, status := range fields[key] {
+			// 去掉 EPP 状态后附带的 ICANN 链接
+			reg.Status = append(reg.Status, strings.Fields(status)[0])
+		}
+		if len(reg.Status) > 0 {
+			break
+		}
+	}
+
+	return reg
+}
+
+// parseFields 把 WHOIS 文本按 "键: 值" 拆成字段，键为小写，同名字段按出现顺序保留
+func parseFields(raw string) map[string][]string {
+	fields := map[string][]string{}
+	for _, line := range strings.Split(raw, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if key == "" || value == "" {
+			continue
+		}
+		fields[key] = append(fields[key], value)
+	}
+	return fields
+}
+
+// registrantFields WHOIS 中全部持有者字段的值，每行一个，用于查找出售信号
+func registrantFields(raw string) string {
+	fields := parseFields(raw)
+	var values []string
+	for _, key := range registrantKeys {
+		values = append(values, fields[key]...)
+	}
+	return strings.Join(values, "\n")
+}
+
+// firstField 按别名顺序返回第一个出现的字段值
+func firstField(fields map[string][]string, keys []string) string {
+	for _, key := range keys {
+		if values := fields[key]; len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
@@ -1,19 +1,45 @@
 package scanner
 
 import (
-	"crypto/tls"
+	"context"
+	"crypto/x509"
+	"domain-agent/backend/internal/aftermarket"
+	"domain-agent/backend/internal/certs"
+	"domain-agent/backend/internal/ecosystems"
+	"domain-agent/backend/internal/evidence"
+	"domain-agent/backend/internal/handles"
+	"domain-agent/backend/internal/history"
+	"domain-agent/backend/internal/lexicon"
+	"domain-agent/backend/internal/pinyin"
+	"domain-agent/backend/internal/rdap"
+	"domain-agent/backend/internal/resolver"
+	"domain-agent/backend/internal/tldwhois"
+	"domain-agent/backend/internal/trademark"
 	"domain-agent/backend/internal/types"
+	"domain-agent/backend/internal/valuation"
+	"domain-agent/backend/internal/website"
+	"domain-agent/backend/internal/zone"
+	"errors"
 	"fmt"
 	"net"
+	"os"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/likexian/whois"
+	"github.com/miekg/dns"
 )
 
 // CheckDomains 批量检查域名可用性
+//
+// 域名先规范化为小写 ASCII，有任何一个不是有效主机名时不做任何查询，返回 ErrInvalidDomain。
 func CheckDomains(domains []string) ([]types.DomainResult, error) {
+	domains, err := hostnames(domains)
+	if err != nil {
+		return nil, err
+	}
 	results := make([]types.DomainResult, 0, len(domains))
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -40,7 +66,137 @@ func CheckDomains(domains []string) ([]types.DomainResult, error) {
 	return results, nil
 }
 
+// CheckOptions 域名检查之外的附加检查
+type CheckOptions struct {
+	Handles    bool     // 检查同名社交账号
+	Platforms  []string // 社交平台，为空时检查全部
+	Ecosystems bool     // 检查同名包仓库名称
+	Registries []string // 包仓库，为空时检查全部
+	CT         bool     // 为已注册的域名查询证书透明度日志
+	Website    bool     // 为已注册的域名探测首页
+	// 为已注册的域名查询交易平台挂牌，同时探测首页
+	Aftermarket  bool
+	Marketplaces []string // 交易平台，为空时查询全部
+}
+
+// CheckDomainsWith 批量检查域名，并按选项检查每个域名名称对应的社交账号和包仓库名称，
+// 以及已注册域名的证书透明度日志、首页和二级市场挂牌
+//
+// 账号和包名检查与域名检查并行进行，结果按名称附加到每个域名上；CT 查询、首页探测和挂牌查询
+// 在域名检查之后，只针对已注册的域名。
+func CheckDomainsWith(domains []string, opts CheckOptions) ([]types.DomainResult, error) {
+	domains, err := hostnames(domains)
+	if err != nil {
+		return nil, err
+	}
+	labels := make([]string, len(domains))
+	for i, d := range domains {
+		labels[i], _ = splitDomain(d)
+	}
+
+	var handleResults map[string][]types.HandleResult
+	var ecosystemResults map[string][]types.EcosystemResult
+	var wg sync.WaitGroup
+	if opts.Handles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handleResults = handles.CheckAll(labels, opts.Platforms)
+		}()
+	}
+	if opts.Ecosystems {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ecosystemResults = ecosystems.CheckAll(labels, opts.Registries)
+		}()
+	}
+
+	results, err := CheckDomains(domains)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		label, _ := splitDomain(strings.ToLower(results[i].Domain))
+		results[i].Handles = handleResults[label]
+		results[i].Ecosystems = ecosystemResults[label]
+	}
+	if opts.CT || opts.Website || opts.Aftermarket {
+		inspectTaken(results, opts)
+	}
+	return results, nil
+}
+
+// takenConcurrency 同时为已注册域名做附加查询的数量，CT 搜索服务对频率限制较严
+const takenConcurrency = 3
+
+// inspectTaken 为已注册的域名查询证书透明度日志、探测首页和交易平台，说明域名何时开始、
+// 多活跃地被使用，是否只是停放或等待出售，以及售价
+func inspectTaken(results []types.DomainResult, opts CheckOptions) {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, takenConcurrency)
+	for i := range results {
+		if results[i].Available {
+			continue
+		}
+		wg.Add(1)
+		go func(r *types.DomainResult) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if opts.CT {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				summary, err := certs.DefaultCT().Search(ctx, r.Domain)
+				cancel()
+				if err != nil {
+					summary = &types.CTSummary{Error: err.Error()}
+				}
+				r.CT = summary
+			}
+			if opts.Website || opts.Aftermarket {
+				var nameServers []string
+				if r.Delegation != nil {
+					nameServers = r.Delegation.NameServers
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+				r.Website = website.Default().Probe(ctx, r.Domain, nameServers)
+				cancel()
+			}
+			if opts.Aftermarket {
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+				r.Aftermarket = aftermarket.Inspect(ctx, r.Domain, opts.Marketplaces, whoisForSale(ctx, r), r.Website)
+				cancel()
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+}
+
+// whoisForSale WHOIS 持有者中的出售信号
+//
+// 委派和 RDAP 一致时检查域名不会查询 WHOIS，这时补查一次；已查过的沿用检查时的结果。
+func whoisForSale(ctx context.Context, r *types.DomainResult) []string {
+	if slices.ContainsFunc(r.Evidence, func(e types.Evidence) bool { return e.Signal == evidence.SignalWHOIS }) {
+		if r.Aftermarket == nil {
+			return nil
+		}
+		return r.Aftermarket.Signals
+	}
+	ev, raw := checkWHOIS(ctx, r.Domain)
+	if ev.Indicates != evidence.Taken {
+		return nil
+	}
+	return aftermarket.WHOISSignals(registrantFields(raw))
+}
+
 // checkSingleDomain 检查单个域名（移植自 domain-scanner）
+//
+// 各项检查的结果记为证据，按后缀的权重合并为可注册的概率，而不是把任意一项
+// 签名当作已注册的证明、或者只相信一次 WHOIS 文本匹配。区域数据命中只是先验；
+// 只有委派和 RDAP 给出一致的结论、其余证据也不矛盾时才跳过 WHOIS。
 func checkSingleDomain(domain string) types.DomainResult {
 	result := types.DomainResult{
 		Domain:     domain,
@@ -49,133 +205,452 @@ func checkSingleDomain(domain string) types.DomainResult {
 		Score:      calculateScore(domain),
 		Price:      "standard",
 	}
+	result.TrademarkRisk, result.TrademarkMatches = screenTrademark(domain)
+	result.EstimatedValue = valuation.Default().Estimate(domain)
+
+	snapshots := collectEvidence(&result)
+	combineEvidence(&result)
+	// 只为判为已注册的域名记录快照：一次建议要检查上百个候选，可注册的名称不需要历史
+	if !result.Available {
+		for _, snapshot := range snapshots {
+			recordSnapshot(snapshot)
+		}
+	}
+	return result
+}
+
+// defaultCheckBudget 单个域名全部检查的默认时限
+const defaultCheckBudget = 30 * time.Second
+
+var (
+	checkBudgetValue time.Duration
+	checkBudgetOnce  sync.Once
+)
+
+// checkBudget 单个域名全部检查的时限，可以用 CHECK_BUDGET 覆盖（Go duration 格式）；
+// 超时后尚未完成的检查记为无法判断
+func checkBudget() time.Duration {
+	checkBudgetOnce.Do(func() {
+		checkBudgetValue = defaultCheckBudget
+		if v := os.Getenv("CHECK_BUDGET"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				checkBudgetValue = d
+			} else {
+				fmt.Printf("Invalid CHECK_BUDGET %q, using default: %v\n", v, err)
+			}
+		}
+	})
+	return checkBudgetValue
+}
+
+// collectEvidence 在时限内运行各项检查，把结果记为证据
+//
+// 第一轮并发询问后缀服务器的委派、RDAP 和递归 DNS；第二轮向解析到的地址做 TLS 握手，
+// 并在委派和 RDAP 没有给出一致结论、或已注册域名的 WHOIS 快照过期时查询 WHOIS。
+// 取得的委派、RDAP 和 WHOIS 数据规范化为注册信息快照返回，由调用方决定是否记录。
+func collectEvidence(result *types.DomainResult) []types.RegistrationSnapshot {
+	domain := result.Domain
+	ctx, cancel := context.WithTimeout(context.Background(), checkBudget())
+	defer cancel()
+
+	// 区域数据只是先验：快照之后域名可能已被删除，布隆过滤器也可能误判
+	if ev, ok := zoneEvidence(domain); ok {
+		result.Evidence = append(result.Evidence, ev)
+	}
 
-	// 检查域名签名（DNS、WHOIS、SSL）
-	signatures := checkDomainSignatures(domain)
-	result.Signatures = signatures
+	var (
+		wg           sync.WaitGroup
+		rdapEvidence types.Evidence
+		reg          *rdap.Domain
+		answers      []dnsResult
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		result.Delegation = checkDelegation(ctx, domain)
+	}()
+	go func() {
+		defer wg.Done()
+		rdapEvidence, reg = checkRDAP(ctx, domain)
+	}()
+	go func() {
+		defer wg.Done()
+		answers = queryDNS(ctx, domain)
+	}()
+	wg.Wait()
 
-	// 如果有任何签名，域名已被注册
-	if len(signatures) > 0 {
-		result.Available = false
-		return result
+	var snapshots []types.RegistrationSnapshot
+	result.Evidence = append(result.Evidence, delegationEvidence(result.Delegation))
+	if result.Delegation != nil {
+		snapshots = append(snapshots, history.FromDelegation(domain, result.Delegation))
 	}
+	result.Evidence = append(result.Evidence, rdapEvidence)
+	if rdapEvidence.Indicates != evidence.Unknown {
+		snapshots = append(snapshots, history.FromRDAP(domain, reg))
+	}
+	if ev, ok := rdapStatusEvidence(reg); ok {
+		result.Evidence = append(result.Evidence, ev)
+	}
+	result.Evidence = append(result.Evidence, dnsSignatures(answers)...)
+	result.DNSSEC = answers[0].DNSSEC()
+
+	var (
+		tlsEvidence   types.Evidence
+		whoisEvidence types.Evidence
+		raw           string
+	)
+	addresses := dnsAddresses(answers)
+	conclusion, settled := agreed(result.Evidence)
+	// 结论一致时 WHOIS 不影响判断，但已注册域名的 WHOIS 快照过期后仍查询一次，刷新持有者等历史
+	if settled && conclusion == evidence.Taken && history.Default().Stale(domain, history.SourceWHOIS) {
+		settled = false
+	}
+	if len(addresses) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tlsEvidence, result.Certificate = checkTLS(ctx, domain, addresses)
+		}()
+	}
+	if !settled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			whoisEvidence, raw = checkWHOIS(ctx, domain)
+		}()
+	}
+	wg.Wait()
 
-	// 最终 WHOIS 检查可用性
-	available, err := checkWHOISAvailability(domain)
-	if err != nil {
-		fmt.Printf("WHOIS check error for %s: %v\n", domain, err)
-		result.Available = false
-		return result
+	if len(addresses) > 0 {
+		result.Evidence = append(result.Evidence, tlsEvidence)
+	}
+	if settled {
+		return snapshots
 	}
+	result.Evidence = append(result.Evidence, whoisEvidence)
+	if whoisEvidence.Indicates != evidence.Unknown {
+		snapshots = append(snapshots, history.FromWHOIS(parseRegistration(domain, raw)))
+	}
+	// WHOIS 持有者中的出售字样或域名投资商，不需要额外请求即可记录
+	if whoisEvidence.Indicates == evidence.Taken {
+		if signals := aftermarket.WHOISSignals(registrantFields(raw)); len(signals) > 0 {
+			result.Aftermarket = &types.AftermarketInfo{ForSale: true, Signals: signals}
+		}
+	}
+	return snapshots
+}
 
-	result.Available = available
-	return result
+// agreed 委派和 RDAP 都给出了结论且相同，其余证据也没有相反的结论时返回该结论
+//
+// RDAP 状态（赎回期、待删除）只调整已注册的置信度，不算相反的结论。
+func agreed(ev []types.Evidence) (string, bool) {
+	conclusions := map[string]string{}
+	for _, e := range ev {
+		if e.Indicates != evidence.Unknown && e.Signal != evidence.SignalRDAPStatus {
+			conclusions[e.Signal] = e.Indicates
+		}
+	}
+	conclusion := conclusions[evidence.SignalDelegation]
+	if conclusion == "" || conclusions[evidence.SignalRDAP] != conclusion {
+		return "", false
+	}
+	for _, c := range conclusions {
+		if c != conclusion {
+			return "", false
+		}
+	}
+	return conclusion, true
 }
 
-// checkDomainSignatures 检查域名签名（移植自 domain-scanner）
-func checkDomainSignatures(domain string) []string {
-	var signatures []string
+// signatureNames 指向已注册的信号在 signatures 中的名称
+var signatureNames = map[string]string{
+	evidence.SignalZone:       "ZONE",
+	evidence.SignalDelegation: "TLD_NS",
+	evidence.SignalRDAP:       "RDAP",
+	evidence.SignalDNSNS:      "DNS_NS",
+	evidence.SignalDNSA:       "DNS_A",
+	evidence.SignalDNSMX:      "DNS_MX",
+	evidence.SignalTLS:        "SSL",
+	evidence.SignalWHOIS:      "WHOIS",
+}
 
-	// 1. 检查 DNS NS 记录
-	nsRecords, err := net.LookupNS(domain)
-	if err == nil && len(nsRecords) > 0 {
-		signatures = append(signatures, "DNS_NS")
+// combineEvidence 合并证据，写入结论、置信度和签名
+func combineEvidence(result *types.DomainResult) {
+	_, tld := splitDomain(strings.ToLower(result.Domain))
+	result.Available, result.Confidence = evidence.Default().Combine(tld, result.Evidence)
+	for _, ev := range result.Evidence {
+		if ev.Indicates == evidence.Taken {
+			result.Signatures = append(result.Signatures, signatureNames[ev.Signal])
+		}
 	}
+}
 
-	// 2. 检查 DNS A 记录
-	ipRecords, err := net.LookupIP(domain)
-	if err == nil && len(ipRecords) > 0 {
-		signatures = append(signatures, "DNS_A")
+// zoneEvidence 区域数据的证据，未加载或不覆盖该后缀时返回 false
+func zoneEvidence(domain string) (types.Evidence, bool) {
+	s := zone.Default()
+	if zone.Registered(s, domain) {
+		return types.Evidence{Signal: evidence.SignalZone, Indicates: evidence.Taken, Detail: "delegated in zone snapshot"}, true
+	}
+	if s == nil {
+		return types.Evidence{}, false
+	}
+	i := strings.LastIndexByte(domain, '.')
+	if i < 0 || !s.Covers(strings.ToLower(domain[i+1:])) {
+		return types.Evidence{}, false
 	}
+	return types.Evidence{Signal: evidence.SignalZone, Indicates: evidence.Available, Detail: "not in zone data"}, true
+}
+
+// checkDelegation 向后缀的权威服务器查询域名的委派（RD=0），不受递归解析器的否定缓存影响
+//
+// 查询失败（后缀服务器不可达、全部拒绝）时返回 nil，由其余检查决定结果。
+func checkDelegation(ctx context.Context, domain string) *types.DelegationEvidence {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
-	// 3. 检查 DNS MX 记录
-	mxRecords, err := net.LookupMX(domain)
-	if err == nil && len(mxRecords) > 0 {
-		signatures = append(signatures, "DNS_MX")
+	d, err := resolver.Default().Delegation(ctx, domain)
+	if err != nil {
+		fmt.Printf("Delegation check error for %s: %v\n", domain, err)
+		return nil
 	}
+	return &types.DelegationEvidence{
+		Zone:        d.Zone,
+		Server:      d.Server,
+		Rcode:       d.Rcode,
+		Referral:    d.Referral,
+		NameServers: d.NameServers,
+	}
+}
 
-	// 4. 检查 WHOIS 信息
-	whoisResult, err := whois.Whois(domain)
-	if err == nil && whoisResult != "" {
-		resultLower := strings.ToLower(whoisResult)
-		// 检查已注册指标
-		registeredIndicators := []string{
-			"registrar:", "creation date:", "expiration date:",
-			"updated date:", "name server:", "domain status:",
-		}
-		for _, indicator := range registeredIndicators {
-			if strings.Contains(resultLower, indicator) {
-				signatures = append(signatures, "WHOIS")
-				break
-			}
+// delegationEvidence 委派回答对应的证据：有委派为已注册，NXDOMAIN 为可注册，其余无法判断
+func delegationEvidence(d *types.DelegationEvidence) types.Evidence {
+	ev := types.Evidence{Signal: evidence.SignalDelegation, Indicates: evidence.Unknown}
+	switch {
+	case d == nil:
+		ev.Detail = "TLD nameservers unreachable"
+	case d.Referral:
+		ev.Indicates = evidence.Taken
+		ev.Detail = "referral to " + strings.Join(d.NameServers, ", ")
+	case d.Rcode == dns.RcodeToString[dns.RcodeNameError]:
+		ev.Indicates = evidence.Available
+		ev.Detail = "NXDOMAIN from " + d.Server
+	default:
+		ev.Detail = d.Rcode + " without referral from " + d.Server
+	}
+	return ev
+}
+
+// checkRDAP 查询注册局的 RDAP 服务：404 为可注册，返回域名对象为已注册
+func checkRDAP(ctx context.Context, domain string) (types.Evidence, *rdap.Domain) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	ev := types.Evidence{Signal: evidence.SignalRDAP, Indicates: evidence.Unknown}
+	d, err := rdap.Default().Lookup(ctx, domain)
+	switch {
+	case errors.Is(err, rdap.ErrNotFound):
+		ev.Indicates = evidence.Available
+		ev.Detail = "not found"
+	case err != nil:
+		ev.Detail = err.Error()
+	default:
+		ev.Indicates = evidence.Taken
+		ev.Detail = "registered"
+		if len(d.Status) > 0 {
+			ev.Detail += " (" + strings.Join(d.Status, ", ") + ")"
 		}
 	}
+	return ev, d
+}
 
-	// 5. 检查 SSL 证书
-	conn, err := tls.DialWithDialer(&net.Dialer{
-		Timeout: 5 * time.Second,
-	}, "tcp", domain+":443", &tls.Config{
-		InsecureSkipVerify: true,
-	})
-	if err == nil {
-		defer conn.Close()
-		state := conn.ConnectionState()
-		if len(state.PeerCertificates) > 0 {
-			signatures = append(signatures, "SSL")
+// lapsingStatuses 注册已结束、域名即将被删除的状态（RDAP 的写法去掉空格后小写，
+// 同时兼容 EPP 的 redemptionPeriod、pendingDelete）
+var lapsingStatuses = map[string]bool{"redemptionperiod": true, "pendingdelete": true}
+
+// rdapStatusEvidence 域名处于赎回期或待删除时的证据：仍不可注册，但即将释放
+func rdapStatusEvidence(d *rdap.Domain) (types.Evidence, bool) {
+	if d == nil {
+		return types.Evidence{}, false
+	}
+	var lapsing []string
+	for _, status := range d.Status {
+		if lapsingStatuses[strings.ToLower(strings.ReplaceAll(status, " ", ""))] {
+			lapsing = append(lapsing, status)
 		}
 	}
+	if len(lapsing) == 0 {
+		return types.Evidence{}, false
+	}
+	return types.Evidence{Signal: evidence.SignalRDAPStatus, Indicates: evidence.Available,
+		Detail: strings.Join(lapsing, ", ")}, true
+}
+
+// dnsQueryTypes 检查的记录类型，NS 在第一位（DNSSEC 状态取自它）
+var dnsQueryTypes = []uint16{dns.TypeNS, dns.TypeA, dns.TypeAAAA, dns.TypeMX}
 
-	return signatures
+// dnsResult 一种记录类型的查询结果
+type dnsResult struct {
+	qtype  uint16
+	answer *resolver.Answer
+	err    error
 }
 
-// checkWHOISAvailability 通过 WHOIS 检查域名可用性（移植自 domain-scanner）
-func checkWHOISAvailability(domain string) (bool, error) {
-	result, err := whois.Whois(domain)
-	if err != nil {
-		return false, err
+// DNSSEC 验证状态，查询失败时为空
+func (r dnsResult) DNSSEC() string {
+	if r.answer == nil {
+		return ""
 	}
+	return r.answer.DNSSEC
+}
 
-	if result == "" {
-		return false, nil
+// queryDNS 通过配置的上游（而不是系统解析器）依次查询 dnsQueryTypes 中的记录
+//
+// 开启 DNSSEC 验证时 NS 回答带有验证状态。
+func queryDNS(ctx context.Context, domain string) []dnsResult {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	r := resolver.Default()
+
+	results := make([]dnsResult, len(dnsQueryTypes))
+	for i, qtype := range dnsQueryTypes {
+		results[i].qtype = qtype
+		results[i].answer, results[i].err = r.Lookup(ctx, domain, qtype)
 	}
+	return results
+}
 
-	resultLower := strings.ToLower(result)
+// dnsSignatures 把查询到的记录转换为证据：NS、地址（A/AAAA）、MX 各一条
+//
+// 有记录时为已注册的证据；没有记录不能说明可注册，不记为证据。
+func dnsSignatures(results []dnsResult) []types.Evidence {
+	var ns, addresses int
+	var mx []string
+	for _, r := range results {
+		if r.answer == nil {
+			continue
+		}
+		for _, rr := range r.answer.Records {
+			switch v := rr.(type) {
+			case *dns.NS:
+				ns++
+			case *dns.A, *dns.AAAA:
+				addresses++
+			case *dns.MX:
+				mx = append(mx, strings.TrimSuffix(v.Mx, "."))
+			}
+		}
+	}
 
-	// 检查可用指标
-	availableIndicators := []string{
-		"status: free",
-		"not found",
-		"no match",
-		"status: available",
-		"no data found",
-		"is available",
-		"not registered",
-		"no entries found",
+	var found []types.Evidence
+	if ns > 0 {
+		found = append(found, types.Evidence{Signal: evidence.SignalDNSNS, Indicates: evidence.Taken,
+			Detail: fmt.Sprintf("%d NS record(s)", ns)})
 	}
+	if addresses > 0 {
+		found = append(found, types.Evidence{Signal: evidence.SignalDNSA, Indicates: evidence.Taken,
+			Detail: fmt.Sprintf("%d address(es)", addresses)})
+	}
+	if len(mx) > 0 {
+		found = append(found, types.Evidence{Signal: evidence.SignalDNSMX, Indicates: evidence.Taken,
+			Detail: strings.Join(mx, ", ")})
+	}
+	return found
+}
 
-	for _, indicator := range availableIndicators {
-		if strings.Contains(resultLower, indicator) {
-			return true, nil
+// dnsAddresses 查询到的 A/AAAA 地址
+func dnsAddresses(results []dnsResult) []string {
+	var addresses []string
+	for _, r := range results {
+		if r.answer == nil {
+			continue
+		}
+		for _, rr := range r.answer.Records {
+			switch v := rr.(type) {
+			case *dns.A:
+				addresses = append(addresses, v.A.String())
+			case *dns.AAAA:
+				addresses = append(addresses, v.AAAA.String())
+			}
 		}
 	}
+	return addresses
+}
 
-	// 检查不可用指标
-	unavailableIndicators := []string{
-		"registrar:",
-		"creation date:",
-		"expiration date:",
-		"domain status:",
-		"name server:",
-	}
+// 读取证书的端口和验证证书链的根证书（nil 为系统根证书），测试中替换为 TLS 替身的
+var (
+	tlsPort  = "443"
+	tlsRoots *x509.CertPool
+)
 
-	for _, indicator := range unavailableIndicators {
-		if strings.Contains(resultLower, indicator) {
-			return false, nil
+// tlsAttempts 最多尝试握手的地址数
+const tlsAttempts = 2
+
+// checkTLS 依次连接解析到的地址读取证书；证书名称包含该域名时为已注册的证据
+//
+// 地址来自配置的上游而不是系统解析器。共享主机返回的其它域名的证书只说明地址上
+// 有服务，不记为证据（DNS 记录已经说明）。
+func checkTLS(ctx context.Context, domain string, addresses []string) (types.Evidence, *types.CertificateInfo) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	ev := types.Evidence{Signal: evidence.SignalTLS, Indicates: evidence.Unknown}
+	var cert *types.CertificateInfo
+	var err error
+	for _, addr := range addresses[:min(len(addresses), tlsAttempts)] {
+		if cert, err = certs.Inspect(ctx, domain, net.JoinHostPort(addr, tlsPort), tlsRoots); err == nil && cert != nil {
+			break
 		}
 	}
+	switch {
+	case err != nil || cert == nil:
+		ev.Detail = "no TLS endpoint"
+	case !cert.Matches:
+		ev.Detail = "certificate for other names: " + cert.Subject
+	default:
+		ev.Indicates = evidence.Taken
+		ev.Detail = "certificate issued by " + cert.Issuer
+		if !cert.Trusted {
+			ev.Detail += " (untrusted)"
+		}
+	}
+	return ev, cert
+}
+
+// checkWHOIS 按后缀的配置查询 WHOIS 并判断（见 tldwhois），同时返回解码后的文本
+func checkWHOIS(ctx context.Context, domain string) (types.Evidence, string) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	ev := types.Evidence{Signal: evidence.SignalWHOIS}
+	raw, err := tldwhois.Default().Query(ctx, domain)
+	if err != nil {
+		ev.Indicates = evidence.Unknown
+		ev.Detail = err.Error()
+		return ev, ""
+	}
+	ev.Indicates, ev.Detail = classifyWHOIS(domain, raw)
+	return ev, raw
+}
+
+// classifyWHOIS 按后缀的规则判断 WHOIS 文本：taken / available / unknown，并返回匹配到的文字
+func classifyWHOIS(domain, result string) (string, string) {
+	return tldwhois.Default().Classify(domain, result)
+}
+
+// parseWHOISAvailability 根据 WHOIS 文本判断是否可注册，无法确定时按不可用处理
+func parseWHOISAvailability(domain, result string) bool {
+	indicates, _ := classifyWHOIS(domain, result)
+	return indicates == evidence.Available
+}
 
-	// 保守策略：无法确定时默认为不可用
-	return false, nil
+// screenTrademark 筛查域名名称与注册商标的冲突，未加载商标数据时返回空
+func screenTrademark(domain string) (string, []types.TrademarkMatch) {
+	idx := trademark.Default()
+	if idx.Len() == 0 {
+		return "", nil
+	}
+	label, _ := splitDomain(domain)
+	return idx.Screen(label)
 }
 
 // calculateScore 计算域名评分
@@ -209,9 +684,108 @@ func calculateScore(domain string) float64 {
 	return score
 }
 
-// GenerateSuggestions 生成域名建议
+// pinyinBonus 拼音加分：能切分为拼音的名称按自然度加分，在中文后缀下加分更多
+//
+// 只用于中文输入生成的候选；ten、man、long 这样的英文词也能切分为拼音，不应加分。
+func pinyinBonus(domain string) float64 {
+	name := strings.Split(domain, ".")[0]
+	if _, ok := pinyin.Split(name); !ok {
+		return 0
+	}
+	weight := 10.0
+	if isChineseTLD(domain[len(name):]) {
+		weight = 20
+	}
+	return pinyin.Naturalness(name) * weight
+}
+
+// isChineseTLD 是否为面向中文用户的后缀
+func isChineseTLD(tld string) bool {
+	switch strings.ToLower(tld) {
+	case ".cn", ".com.cn", ".net.cn", ".org.cn", ".中国":
+		return true
+	}
+	return false
+}
+
+// maxKeywords 参与组合的关键词上限，避免扩展后排列组合数量爆炸
+const maxKeywords = 8
+
+// maxSuggestionChecks only_available 时最多检查的候选数，避免触发 WHOIS 限流
+const maxSuggestionChecks = 100
+
+// suggestionBatch only_available 时每批检查的候选数，与 CheckDomains 的并发数一致
+const suggestionBatch = 10
+
+// GenerateSuggestions 生成域名建议，按综合排序分从高到低排列
 func GenerateSuggestions(req types.SuggestDomainsRequest) []types.DomainSuggestion {
+	suggestions := rankedSuggestions(req)
+
+	// 限制返回数量
+	count := suggestionCount(req)
+	if len(suggestions) > count {
+		suggestions = suggestions[:count]
+	}
+
+	return suggestions
+}
+
+// FindAvailableSuggestions 生成域名建议并检查可用性，只返回可注册的
+//
+// 按综合排序分从高到低分批检查，找到 Count 个可注册的域名或检查数达到上限时停止。
+func FindAvailableSuggestions(req types.SuggestDomainsRequest) ([]types.DomainSuggestion, error) {
+	candidates := slices.DeleteFunc(rankedSuggestions(req), func(s types.DomainSuggestion) bool {
+		return !validHostname(s.Domain)
+	})
+	if len(candidates) > maxSuggestionChecks {
+		candidates = candidates[:maxSuggestionChecks]
+	}
+	count := suggestionCount(req)
+
+	available := []types.DomainSuggestion{}
+	for start := 0; start < len(candidates) && len(available) < count; start += suggestionBatch {
+		batch := candidates[start:min(start+suggestionBatch, len(candidates))]
+		domains := make([]string, len(batch))
+		for i, s := range batch {
+			domains[i] = s.Domain
+		}
+
+		results, err := CheckDomains(domains)
+		if err != nil {
+			return nil, err
+		}
+		free := make(map[string]bool, len(results))
+		for _, r := range results {
+			free[r.Domain] = r.Available
+		}
+
+		// 按批内的排序顺序收集，保持整体有序
+		for _, s := range batch {
+			if free[s.Domain] {
+				s.Available = true
+				available = append(available, s)
+			}
+		}
+	}
+
+	if len(available) > count {
+		available = available[:count]
+	}
+	return available, nil
+}
+
+// maxSuggestions 单次最多返回的建议数
+const maxSuggestions = 100
+
+// suggestionCount 请求的建议数量，默认 20，限制在 1 到 maxSuggestions 之间
+func suggestionCount(req types.SuggestDomainsRequest) int {
+	return clampCount(req.Count, 20, maxSuggestions)
+}
+
+// rankedSuggestions 按策略生成全部候选，跨策略去重后按综合排序分排序
+func rankedSuggestions(req types.SuggestDomainsRequest) []types.DomainSuggestion {
 	suggestions := []types.DomainSuggestion{}
+	index := map[string]int{}
 
 	// 默认 TLDs
 	tlds := req.TLDs
@@ -229,67 +803,93 @@ func GenerateSuggestions(req types.SuggestDomainsRequest) []types.DomainSuggesti
 		minLen = 3
 	}
 
-	// 生成策略
-	for _, keyword := range req.Keywords {
-		// 1. 直接使用关键词
-		for _, tld := range tlds {
-			domain := keyword + tld
-			if len(keyword) >= minLen && len(keyword) <= maxLen {
-				suggestions = append(suggestions, types.DomainSuggestion{
-					Domain:       domain,
-					Score:        calculateScore(domain),
-					Reason:       "直接使用关键词",
-					Length:       len(keyword),
-					Memorability: 0.9,
-				})
-			}
-		}
+	// 关键词翻译为可用于域名的英文词（中文分词后取译名和拼音），按需追加近义词
+	var keywords []string
+	if req.Expand {
+		keywords = lexicon.Expand(req.Keywords)
+	} else {
+		keywords = lexicon.Translate(req.Keywords)
+	}
+	if len(keywords) > maxKeywords {
+		keywords = keywords[:maxKeywords]
+	}
 
-		// 2. 关键词缩写
-		if len(keyword) > 3 {
-			abbr := generateAbbreviation(keyword)
-			for _, tld := range tlds {
-				domain := abbr + tld
-				suggestions = append(suggestions, types.DomainSuggestion{
-					Domain:       domain,
-					Score:        calculateScore(domain),
-					Reason:       fmt.Sprintf("'%s' 的缩写", keyword),
-					Length:       len(abbr),
-					Memorability: 0.7,
-				})
-			}
+	// 关键词中有中文时，候选可能是拼音，按拼音的自然度加分
+	hanInput := slices.ContainsFunc(req.Keywords, pinyin.HasHan)
+
+	// 按策略生成候选，再展开到各个后缀
+	for _, strategy := range selectStrategies(req.Strategies) {
+		input := keywords
+		if _, ok := strategy.(sourceStrategy); ok {
+			input = req.Keywords
 		}
-	}
+		for _, c := range strategy.Generate(input) {
+			if len(c.Label) < minLen || len(c.Label) > maxLen {
+				continue
+			}
 
-	// 3. 组合关键词
-	if len(req.Keywords) >= 2 {
-		combined := strings.Join(req.Keywords[:2], "")
-		if len(combined) <= maxLen {
-			for _, tld := range tlds {
-				domain := combined + tld
-				suggestions = append(suggestions, types.DomainSuggestion{
+			candidateTLDs := tlds
+			if c.TLD != "" {
+				candidateTLDs = []string{c.TLD}
+			}
+			for _, tld := range candidateTLDs {
+				domain := c.Label + normalizeTLD(tld)
+				score := calculateScore(domain)
+				if hanInput {
+					score += pinyinBonus(domain)
+				}
+				s := types.DomainSuggestion{
 					Domain:       domain,
-					Score:        calculateScore(domain),
-					Reason:       "关键词组合",
-					Length:       len(combined),
-					Memorability: 0.8,
-				})
+					Score:        score,
+					Rank:         rankSuggestion(score, c.Memorability),
+					Reason:       c.Reason,
+					Length:       len(c.Label),
+					Memorability: c.Memorability,
+					Strategy:     strategy.Name(),
+				}
+
+				// 不同策略生成了同一个域名时保留排序分更高的
+				if i, exists := index[domain]; exists {
+					if s.Rank > suggestions[i].Rank {
+						suggestions[i] = s
+					}
+					continue
+				}
+				index[domain] = len(suggestions)
+				suggestions = append(suggestions, s)
 			}
 		}
 	}
 
-	// 限制返回数量
-	count := req.Count
-	if count == 0 {
-		count = 20
-	}
-	if len(suggestions) > count {
-		suggestions = suggestions[:count]
+	// 商标筛查按名称进行，同一名称的不同后缀共用结果；估价与后缀有关，逐个计算
+	screened := map[string]types.DomainSuggestion{}
+	for i := range suggestions {
+		label, _ := splitDomain(suggestions[i].Domain)
+		r, ok := screened[label]
+		if !ok {
+			r.TrademarkRisk, r.TrademarkMatches = screenTrademark(suggestions[i].Domain)
+			screened[label] = r
+		}
+		suggestions[i].TrademarkRisk, suggestions[i].TrademarkMatches = r.TrademarkRisk, r.TrademarkMatches
+		suggestions[i].EstimatedValue = valuation.Default().Estimate(suggestions[i].Domain)
 	}
 
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Rank > suggestions[j].Rank
+	})
 	return suggestions
 }
 
+// rankSuggestion 综合排序分：评分（归一化到 0-1）占 60%，易记程度占 40%
+func rankSuggestion(score, memorability float64) float64 {
+	return 0.6*normalizeScore(score) + 0.4*memorability
+}
+
+// normalizeScore 把 calculateScore 的评分归一化到 0-1
+func normalizeScore(score float64) float64 {
+	return min(max(score/170, 0), 1)
+}
+
 // generateAbbreviation 生成缩写
 func generateAbbreviation(word string) string {
 	if len(word) <= 3 {
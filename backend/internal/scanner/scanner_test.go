@@ -0,0 +1,388 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"domain-agent/backend/internal/aftermarket"
+	"domain-agent/backend/internal/evidence"
+	"domain-agent/backend/internal/history"
+	"domain-agent/backend/internal/scanner/scannertest"
+	"domain-agent/backend/internal/store"
+	"domain-agent/backend/internal/types"
+	"domain-agent/backend/internal/website"
+	"domain-agent/backend/internal/zone"
+)
+
+func TestGenerateSuggestionsRanking(t *testing.T) {
+	suggestions := GenerateSuggestions(types.SuggestDomainsRequest{Keywords: []string{"cloud", "kit"}, TLDs: []string{".com", ".io"}, Count: 100})
+	if len(suggestions) == 0 {
+		t.Fatal("no suggestions")
+	}
+	seen := map[string]bool{}
+	for i, s := range suggestions {
+		if seen[s.Domain] {
+			t.Errorf("duplicate suggestion %s", s.Domain)
+		}
+		seen[s.Domain] = true
+		if i > 0 && s.Rank > suggestions[i-1].Rank {
+			t.Errorf("suggestions not sorted by rank at %d", i)
+		}
+		if want := rankSuggestion(s.Score, s.Memorability); s.Rank != want {
+			t.Errorf("%s: rank %v, want %v", s.Domain, s.Rank, want)
+		}
+		if s.Length < 3 || s.Length > 8 {
+			t.Errorf("%s: length %d outside the default 3-8", s.Domain, s.Length)
+		}
+	}
+
+	// permutation（易记 0.8）和 affix（cloud + 后缀 kit，易记 0.75）都能生成 cloudkit.com，保留排序分更高的
+	for _, s := range suggestions {
+		if s.Domain == "cloudkit.com" && s.Strategy != "permutation" {
+			t.Errorf("cloudkit.com kept from %s, want permutation", s.Strategy)
+		}
+	}
+	if !seen["cloudkit.com"] {
+		t.Errorf("missing cloudkit.com")
+	}
+}
+
+func TestSuggestionCount(t *testing.T) {
+	tests := []struct{ count, want int }{
+		{0, 20},
+		{-1, 1},
+		{5, 5},
+		{1000, maxSuggestions},
+	}
+	for _, tt := range tests {
+		if got := suggestionCount(types.SuggestDomainsRequest{Count: tt.count}); got != tt.want {
+			t.Errorf("suggestionCount(%d) = %d, want %d", tt.count, got, tt.want)
+		}
+		got := GenerateSuggestions(types.SuggestDomainsRequest{Keywords: []string{"cloud"}, Count: tt.count})
+		if len(got) > tt.want || len(got) == 0 {
+			t.Errorf("GenerateSuggestions(count %d) returned %d", tt.count, len(got))
+		}
+	}
+}
+
+func TestFindAvailableSuggestions(t *testing.T) {
+	scannertest.Start(t, map[string]scannertest.Domain{
+		"cloud.test":    {NameServers: []string{"ns1.example.net"}},
+		"getcloud.test": {NameServers: []string{"ns1.example.net"}},
+	})
+
+	for _, count := range []int{-1, 3} {
+		found, err := FindAvailableSuggestions(types.SuggestDomainsRequest{
+			Keywords:   []string{"cloud"},
+			TLDs:       []string{".test"},
+			Strategies: []string{"keyword", "affix"},
+			Count:      count,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := suggestionCount(types.SuggestDomainsRequest{Count: count}); len(found) != want {
+			t.Errorf("count %d: %d suggestion(s), want %d", count, len(found), want)
+		}
+		for i, s := range found {
+			if !s.Available || s.Domain == "cloud.test" || s.Domain == "getcloud.test" {
+				t.Errorf("count %d: unexpected suggestion %+v", count, s)
+			}
+			if i > 0 && s.Rank > found[i-1].Rank {
+				t.Errorf("count %d: suggestions not sorted by rank at %d", count, i)
+			}
+		}
+	}
+}
+
+func TestZoneHitIsPrior(t *testing.T) {
+	tld := scannertest.Start(t, map[string]scannertest.Domain{
+		"cloud.test": {NameServers: []string{"ns1.example.net"}},
+	})
+	// 快照中的 gone.test 之后被删除
+	snapshot := zone.NewBloom(10, 1e-9)
+	snapshot.Add("cloud.test")
+	snapshot.Add("gone.test")
+	zone.SetDefault(snapshot)
+
+	results, err := CheckDomains([]string{"cloud.test", "gone.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if len(r.Evidence) == 0 || r.Evidence[0].Signal != evidence.SignalZone || r.Evidence[0].Indicates != evidence.Taken {
+			t.Errorf("%s: evidence %+v does not start with the zone hit", r.Domain, r.Evidence)
+		}
+		for _, source := range []string{scannertest.SourceDelegation, scannertest.SourceRDAP, scannertest.SourceDNS} {
+			if tld.Queries(source, r.Domain) == 0 {
+				t.Errorf("%s: zone hit skipped the %s check", r.Domain, source)
+			}
+		}
+		if want := r.Domain == "gone.test"; r.Available != want {
+			t.Errorf("%s: available %v, want %v (evidence %+v)", r.Domain, r.Available, want, r.Evidence)
+		}
+	}
+	// 区域数据与委派、RDAP 矛盾时继续查询 WHOIS
+	if tld.Queries(scannertest.SourceWHOIS, "gone.test") == 0 {
+		t.Error("gone.test: conflicting zone hit skipped WHOIS")
+	}
+}
+
+// useTLS 让 TLS 检查连接替身的端口、信任替身的根证书，测试结束时恢复
+func useTLS(t *testing.T, tld *scannertest.TLD) {
+	_, port, _ := net.SplitHostPort(tld.TLSAddr)
+	previousPort, previousRoots := tlsPort, tlsRoots
+	tlsPort, tlsRoots = port, tld.TLSRoots
+	t.Cleanup(func() { tlsPort, tlsRoots = previousPort, previousRoots })
+}
+
+// useHistory 换成内存中的快照记录器，fresh 中的域名已有新鲜的 WHOIS 快照，测试结束时恢复
+func useHistory(t *testing.T, fresh ...string) *history.Recorder {
+	previous := history.Default()
+	r := history.New(store.NewMemoryHistory())
+	history.SetDefault(r)
+	t.Cleanup(func() { history.SetDefault(previous) })
+	for _, domain := range fresh {
+		if err := r.Record(types.RegistrationSnapshot{Domain: domain, Source: history.SourceWHOIS, Registered: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func TestCollectEvidenceSources(t *testing.T) {
+	tld := scannertest.Start(t, map[string]scannertest.Domain{
+		"taken.test":   {NameServers: []string{"ns1.example.net"}, Addresses: []string{"127.0.0.1"}},
+		"held.test":    {Registered: true, Status: []string{"server hold"}},
+		"limited.test": {NameServers: []string{"ns1.example.net"}, RateLimited: true},
+	})
+	useTLS(t, tld)
+	useHistory(t, "taken.test")
+
+	all := []string{scannertest.SourceDelegation, scannertest.SourceRDAP, scannertest.SourceDNS, scannertest.SourceTLS, scannertest.SourceWHOIS}
+	tests := []struct {
+		domain    string
+		available bool
+		sources   []string // 应当查询的来源，其余来源不应查询
+	}{
+		// 委派、RDAP 和 DNS 一致且 WHOIS 快照新鲜，不再查询 WHOIS；有地址时读取证书
+		{"taken.test", false, []string{scannertest.SourceDelegation, scannertest.SourceRDAP, scannertest.SourceDNS, scannertest.SourceTLS}},
+		{"free.test", true, []string{scannertest.SourceDelegation, scannertest.SourceRDAP, scannertest.SourceDNS}},
+		// 已注册但没有委派：委派与 RDAP 矛盾
+		{"held.test", false, []string{scannertest.SourceDelegation, scannertest.SourceRDAP, scannertest.SourceDNS, scannertest.SourceWHOIS}},
+		// RDAP 被限流，没有一致的结论
+		{"limited.test", false, []string{scannertest.SourceDelegation, scannertest.SourceRDAP, scannertest.SourceDNS, scannertest.SourceWHOIS}},
+	}
+	for _, tt := range tests {
+		r := checkSingleDomain(tt.domain)
+		if r.Available != tt.available {
+			t.Errorf("%s: available %v, want %v (evidence %+v)", tt.domain, r.Available, tt.available, r.Evidence)
+		}
+		for _, source := range all {
+			if got, want := tld.Queries(source, tt.domain) > 0, slices.Contains(tt.sources, source); got != want {
+				t.Errorf("%s: queried %s = %v, want %v", tt.domain, source, got, want)
+			}
+		}
+	}
+
+	r := checkSingleDomain("taken.test")
+	if !slices.Contains(r.Signatures, "SSL") || !slices.Contains(r.Signatures, "TLD_NS") {
+		t.Errorf("taken.test: signatures %v", r.Signatures)
+	}
+}
+
+func TestCertificateForRegisteredDomain(t *testing.T) {
+	tld := scannertest.Start(t, map[string]scannertest.Domain{
+		"taken.test":  {NameServers: []string{"ns1.example.net"}, Addresses: []string{"127.0.0.1"}},
+		"parked.test": {NameServers: []string{"ns1.example.net"}},
+	})
+	useTLS(t, tld)
+
+	results, err := CheckDomains([]string{"taken.test", "parked.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		switch r.Domain {
+		case "taken.test":
+			c := r.Certificate
+			if c == nil || c.Subject != "*.test" || !c.Matches || !c.Trusted || c.Issuer != "scannertest (Test Root)" {
+				t.Errorf("taken.test: certificate %+v, want the trusted *.test stand-in certificate", c)
+			}
+		case "parked.test":
+			// 没有地址，不做 TLS 握手
+			if r.Certificate != nil || tld.Queries(scannertest.SourceTLS, r.Domain) != 0 {
+				t.Errorf("parked.test: certificate %+v without addresses", r.Certificate)
+			}
+		}
+	}
+}
+
+func TestLapsingStatusLowersConfidence(t *testing.T) {
+	scannertest.Start(t, map[string]scannertest.Domain{
+		"active.test":    {NameServers: []string{"ns1.example.net"}},
+		"lapsing.test":   {NameServers: []string{"ns1.example.net"}, Status: []string{"client transfer prohibited", "pending delete"}},
+		"redeeming.test": {Registered: true, Status: []string{"redemption period"}},
+	})
+
+	// 两者的其余证据相同（委派、RDAP、NS 记录），待删除只抵消一部分已注册的权重
+	logit := func(r types.DomainResult) float64 {
+		sum := 0.0
+		for _, e := range r.Evidence {
+			sum += e.Weight
+		}
+		return sum
+	}
+	active, lapsing := checkSingleDomain("active.test"), checkSingleDomain("lapsing.test")
+	if lapsing.Available || logit(lapsing) <= logit(active) {
+		t.Errorf("lapsing.test: available=%v log-odds %.1f, want taken above active.test's %.1f", lapsing.Available, logit(lapsing), logit(active))
+	}
+
+	for _, domain := range []string{"lapsing.test", "redeeming.test"} {
+		r := checkSingleDomain(domain)
+		if r.Available {
+			t.Errorf("%s: available, want taken (evidence %+v)", domain, r.Evidence)
+		}
+		if !slices.ContainsFunc(r.Evidence, func(e types.Evidence) bool {
+			return e.Signal == evidence.SignalRDAPStatus && e.Indicates == evidence.Available && e.Weight > 0
+		}) {
+			t.Errorf("%s: no weighted rdap_status evidence in %+v", domain, r.Evidence)
+		}
+	}
+}
+
+// stubMarketplace 没有任何挂牌的交易平台
+type stubMarketplace struct{}
+
+func (stubMarketplace) Name() string { return "stub" }
+
+func (stubMarketplace) Lookup(ctx context.Context, domain string) (types.Listing, error) {
+	return types.Listing{Status: aftermarket.StatusNotListed}, nil
+}
+
+func TestAftermarketQueriesWHOIS(t *testing.T) {
+	tld := scannertest.Start(t, map[string]scannertest.Domain{
+		"investor.test": {NameServers: []string{"ns1.example.net"}, Registrant: "HugeDomains.com"},
+		"business.test": {NameServers: []string{"ns1.example.net"}, Registrant: "Acme Tools Inc.", Registrar: "BuyDomains Registrar LLC"},
+	})
+	useHistory(t, "investor.test", "business.test")
+	aftermarket.Register(stubMarketplace{})
+	// 首页探测直接失败，不访问网络
+	previous := website.Default()
+	prober := website.New(nil)
+	prober.HTTP.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+	website.SetDefault(prober)
+	t.Cleanup(func() { website.SetDefault(previous) })
+
+	results, err := CheckDomainsWith([]string{"investor.test", "business.test"}, CheckOptions{Aftermarket: true, Marketplaces: []string{"stub"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		// 委派和 RDAP 一致，检查时没有查询 WHOIS，挂牌查询时补查
+		if tld.Queries(scannertest.SourceWHOIS, r.Domain) != 1 {
+			t.Errorf("%s: %d WHOIS queries, want 1", r.Domain, tld.Queries(scannertest.SourceWHOIS, r.Domain))
+		}
+		if r.Aftermarket == nil {
+			t.Fatalf("%s: no aftermarket result", r.Domain)
+		}
+		// 注册商不是持有者，不算投资商信号
+		if want := r.Domain == "investor.test"; r.Aftermarket.ForSale != want {
+			t.Errorf("%s: for_sale=%v signals=%v, want %v", r.Domain, r.Aftermarket.ForSale, r.Aftermarket.Signals, want)
+		}
+	}
+}
+
+// TestSnapshotsOnlyForTaken 检查只为已注册的域名记录快照，可注册的候选不占用存储
+func TestSnapshotsOnlyForTaken(t *testing.T) {
+	scannertest.Start(t, map[string]scannertest.Domain{
+		"taken.test": {NameServers: []string{"ns1.example.net"}},
+	})
+	r := useHistory(t)
+
+	for _, domain := range []string{"taken.test", "free.test"} {
+		checkSingleDomain(domain)
+	}
+	if snapshots, _, _ := r.History("taken.test"); len(snapshots) == 0 {
+		t.Error("taken.test: no snapshots recorded")
+	}
+	if snapshots, _, _ := r.History("free.test"); len(snapshots) != 0 {
+		t.Errorf("free.test: recorded %+v", snapshots)
+	}
+}
+
+func TestStaleWHOISSnapshotRefreshed(t *testing.T) {
+	tld := scannertest.Start(t, map[string]scannertest.Domain{
+		"taken.test": {NameServers: []string{"ns1.example.net"}, Registrant: "Acme Tools Inc."},
+	})
+	r := useHistory(t)
+	now := time.Now()
+	r.Now = func() time.Time { return now }
+
+	// 委派和 RDAP 一致，但还没有 WHOIS 快照：查询一次并记录持有者
+	checkSingleDomain("taken.test")
+	if n := tld.Queries(scannertest.SourceWHOIS, "taken.test"); n != 1 {
+		t.Fatalf("first check: %d WHOIS queries, want 1", n)
+	}
+	snapshots, _, err := r.History("taken.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := map[string]types.RegistrationSnapshot{}
+	for _, s := range snapshots {
+		sources[s.Source] = s
+	}
+	if sources[history.SourceWHOIS].Registrant != "Acme Tools Inc." || sources[history.SourceRDAP].ExpiresAt == "" ||
+		!sources[history.SourceDelegation].Registered {
+		t.Errorf("snapshots = %+v, want whois, rdap and delegation", snapshots)
+	}
+
+	// 快照新鲜时不再查询
+	checkSingleDomain("taken.test")
+	if n := tld.Queries(scannertest.SourceWHOIS, "taken.test"); n != 1 {
+		t.Errorf("fresh snapshot: %d WHOIS queries, want 1", n)
+	}
+
+	// 过期后刷新
+	now = now.Add(r.MaxAge + time.Hour)
+	checkSingleDomain("taken.test")
+	if n := tld.Queries(scannertest.SourceWHOIS, "taken.test"); n != 2 {
+		t.Errorf("stale snapshot: %d WHOIS queries, want 2", n)
+	}
+}
+
+func TestRecordRegistration(t *testing.T) {
+	tld := scannertest.Start(t, map[string]scannertest.Domain{
+		"taken.test": {NameServers: []string{"ns1.example.net"}, Registrar: "Example Registrar"},
+	})
+	r := useHistory(t)
+
+	if err := RecordRegistration("taken.test", false); err != nil {
+		t.Fatal(err)
+	}
+	// 两个来源都新鲜，不再查询
+	if err := RecordRegistration("taken.test", false); err != nil {
+		t.Fatal(err)
+	}
+	if w, d := tld.Queries(scannertest.SourceWHOIS, "taken.test"), tld.Queries(scannertest.SourceRDAP, "taken.test"); w != 1 || d != 1 {
+		t.Errorf("whois=%d rdap=%d queries, want 1 each", w, d)
+	}
+	if err := RecordRegistration("taken.test", true); err != nil {
+		t.Fatal(err)
+	}
+	if w, d := tld.Queries(scannertest.SourceWHOIS, "taken.test"), tld.Queries(scannertest.SourceRDAP, "taken.test"); w != 2 || d != 2 {
+		t.Errorf("refresh: whois=%d rdap=%d queries, want 2 each", w, d)
+	}
+	if snapshots, _, _ := r.History("taken.test"); len(snapshots) != 2 || snapshots[0].Registrar != "Example Registrar" {
+		t.Errorf("snapshots = %+v", snapshots)
+	}
+}
@@ -0,0 +1,388 @@
+// Package scannertest 为后缀 test 启动 WHOIS、RDAP 和 DNS 的本地替身，供测试运行扫描器
+//
+// Start 把 rdap、resolver、history 的默认实例和 WHOIS 客户端的连接换成替身，关闭区域数据，
+// 测试结束时恢复。替身按 Domains 回答：有名称服务器的域名在后缀服务器上有委派，
+// Registered 的域名在 RDAP 和 WHOIS 中有记录，其余返回 NXDOMAIN、404 和 "No match"。
+// 每个来源收到的查询按域名计数，用于检查扫描器实际运行了哪些检查。
+package scannertest
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"domain-agent/backend/internal/history"
+	"domain-agent/backend/internal/rdap"
+	"domain-agent/backend/internal/resolver"
+	"domain-agent/backend/internal/store"
+	"domain-agent/backend/internal/zone"
+
+	"github.com/likexian/whois"
+	"github.com/miekg/dns"
+)
+
+// 查询来源，见 TLD.Queries
+const (
+	SourceWHOIS      = "whois"
+	SourceRDAP       = "rdap"
+	SourceDelegation = "delegation" // 向后缀服务器查询委派（RD=0）
+	SourceDNS        = "dns"        // 向递归上游查询记录（RD=1）
+	SourceTLS        = "tls"        // TLS 握手（SNI 为该域名）
+)
+
+// Domain 一个域名在替身中的数据
+type Domain struct {
+	Registered  bool     // RDAP 和 WHOIS 中有记录；有 NameServers 时视为已注册
+	NameServers []string // 后缀服务器给出的委派，递归上游同样返回这些 NS 记录
+	Addresses   []string // A 记录
+	MX          []string
+	Status      []string // RDAP 状态，为空时为 active
+	Registrar   string
+	Registrant  string // WHOIS 中的 Registrant Organization
+	Expires     string // 到期时间（RFC 3339），为空时为 2030-01-01
+	WHOIS       string // WHOIS 回答，为空时按以上字段生成
+	RateLimited bool   // RDAP 返回 429，WHOIS 返回限流文字
+}
+
+// TLD 后缀 test 的替身
+type TLD struct {
+	// TLSAddr TLS 替身的地址，证书由 TLSRoots 中的根证书签发，适用于 *.test
+	TLSAddr  string
+	TLSRoots *x509.CertPool
+
+	mu      sync.Mutex
+	domains map[string]Domain
+	queries map[string]int
+}
+
+// Start 启动替身并替换默认实例
+func Start(t testing.TB, domains map[string]Domain) *TLD {
+	t.Helper()
+	f := &TLD{domains: map[string]Domain{}, queries: map[string]int{}}
+	for name, d := range domains {
+		f.Set(name, d)
+	}
+
+	f.startWHOIS(t)
+	f.startRDAP(t)
+	f.startDNS(t)
+	f.startTLS(t)
+
+	zone.SetDefault(nil)
+	history.SetDefault(history.New(store.NewMemoryHistory()))
+	return f
+}
+
+// Set 增加或修改域名
+func (f *TLD) Set(name string, d Domain) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(d.NameServers) > 0 {
+		d.Registered = true
+	}
+	f.domains[strings.ToLower(name)] = d
+}
+
+// Queries 来源收到的关于该域名的查询次数
+func (f *TLD) Queries(source, domain string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queries[source+" "+strings.ToLower(domain)]
+}
+
+// lookup 记录一次查询，返回域名的数据
+func (f *TLD) lookup(source, name string) (Domain, bool) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries[source+" "+name]++
+	d, ok := f.domains[name]
+	return d, ok
+}
+
+// redirectDialer 把 WHOIS 客户端的全部连接转到替身
+type redirectDialer struct{ addr string }
+
+func (d redirectDialer) Dial(network, _ string) (net.Conn, error) {
+	return net.Dial(network, d.addr)
+}
+
+// startWHOIS WHOIS 替身：向 IANA 查询后缀 test 时给出转介，其余按域名回答
+func (f *TLD) startWHOIS(t testing.TB) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				query, _ := bufio.NewReader(conn).ReadString('\n')
+				query = strings.ToLower(strings.TrimSpace(query))
+				if query == "test" {
+					fmt.Fprint(conn, "domain:       TEST\nrefer:        whois.nic.test\n")
+					return
+				}
+				d, ok := f.lookup(SourceWHOIS, query)
+				fmt.Fprint(conn, whoisText(query, d, ok))
+			}()
+		}
+	}()
+	whois.DefaultClient.SetDialer(redirectDialer{ln.Addr().String()})
+	t.Cleanup(func() { whois.DefaultClient.SetDialer(&net.Dialer{Timeout: 30 * time.Second}) })
+}
+
+func whoisText(name string, d Domain, ok bool) string {
+	switch {
+	case ok && d.RateLimited:
+		return "Query rate limit exceeded, please try again later.\n"
+	case ok && d.WHOIS != "":
+		return d.WHOIS
+	case !ok || !d.Registered:
+		return fmt.Sprintf("No match for %q.\n", strings.ToUpper(name))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Domain Name: %s\n", strings.ToUpper(name))
+	fmt.Fprintf(&b, "Registrar: %s\n", registrar(d))
+	fmt.Fprintf(&b, "Creation Date: 2015-03-01T00:00:00Z\n")
+	fmt.Fprintf(&b, "Registry Expiry Date: %s\n", expires(d))
+	if d.Registrant != "" {
+		fmt.Fprintf(&b, "Registrant Organization: %s\n", d.Registrant)
+	}
+	for _, ns := range d.NameServers {
+		fmt.Fprintf(&b, "Name Server: %s\n", strings.ToUpper(ns))
+	}
+	for _, s := range statuses(d) {
+		fmt.Fprintf(&b, "Domain Status: %s\n", strings.ReplaceAll(s, " ", ""))
+	}
+	return b.String()
+}
+
+func registrar(d Domain) string {
+	if d.Registrar == "" {
+		return "Example Registrar, Inc."
+	}
+	return d.Registrar
+}
+
+func expires(d Domain) string {
+	if d.Expires == "" {
+		return "2030-01-01T00:00:00Z"
+	}
+	return d.Expires
+}
+
+func statuses(d Domain) []string {
+	if len(d.Status) == 0 {
+		return []string{"active"}
+	}
+	return d.Status
+}
+
+// startRDAP RDAP 替身和引导文件
+func (f *TLD) startRDAP(t testing.TB) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bootstrap.json" {
+			fmt.Fprintf(w, `{"version": "1.0", "services": [[["test"], ["%s/rdap/"]]]}`, srv.URL)
+			return
+		}
+		name, ok := strings.CutPrefix(r.URL.Path, "/rdap/domain/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		d, ok := f.lookup(SourceRDAP, name)
+		switch {
+		case ok && d.RateLimited:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case !ok || !d.Registered:
+			http.NotFound(w, r)
+		default:
+			w.Header().Set("Content-Type", "application/rdap+json")
+			json.NewEncoder(w).Encode(rdapDomain(name, d))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	previous := rdap.Default()
+	rdap.SetDefault(rdap.New(srv.URL + "/bootstrap.json"))
+	t.Cleanup(func() { rdap.SetDefault(previous) })
+}
+
+func rdapDomain(name string, d Domain) map[string]any {
+	var nameServers []map[string]string
+	for _, ns := range d.NameServers {
+		nameServers = append(nameServers, map[string]string{"ldhName": ns})
+	}
+	return map[string]any{
+		"objectClassName": "domain",
+		"ldhName":         strings.ToUpper(name),
+		"status":          statuses(d),
+		"events": []map[string]string{
+			{"eventAction": "registration", "eventDate": "2015-03-01T00:00:00Z"},
+			{"eventAction": "expiration", "eventDate": expires(d)},
+		},
+		"nameservers": nameServers,
+		"entities": []any{map[string]any{
+			"roles":      []string{"registrar"},
+			"vcardArray": []any{"vcard", []any{[]any{"fn", map[string]any{}, "text", registrar(d)}}},
+		}},
+	}
+}
+
+// startDNS DNS 替身：同时充当递归上游（RD=1）和 test. 的权威服务器（RD=0）
+func (f *TLD) startDNS(t testing.TB) {
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		q := req.Question[0]
+		name := dns.CanonicalName(q.Name)
+		soa := mustRR("test. 900 IN SOA ns1.test. admin.test. 1 1800 900 604800 86400")
+
+		if name == "test." {
+			if q.Qtype == dns.TypeNS {
+				resp.Answer = append(resp.Answer, mustRR("test. 172800 IN NS ns1.test."))
+				resp.Extra = append(resp.Extra, mustRR("ns1.test. 172800 IN A 127.0.0.1"))
+			} else {
+				resp.Ns = append(resp.Ns, soa)
+			}
+			w.WriteMsg(resp)
+			return
+		}
+
+		source := SourceDNS
+		if !req.RecursionDesired {
+			source = SourceDelegation
+		}
+		d, ok := f.lookup(source, name)
+		switch {
+		case !ok || len(d.NameServers) == 0:
+			resp.Rcode = dns.RcodeNameError
+			resp.Ns = append(resp.Ns, soa)
+		case !req.RecursionDesired:
+			for _, ns := range d.NameServers {
+				resp.Ns = append(resp.Ns, mustRR(fmt.Sprintf("%s 172800 IN NS %s.", name, ns)))
+			}
+		default:
+			switch q.Qtype {
+			case dns.TypeNS:
+				for _, ns := range d.NameServers {
+					resp.Answer = append(resp.Answer, mustRR(fmt.Sprintf("%s 300 IN NS %s.", name, ns)))
+				}
+			case dns.TypeA:
+				for _, a := range d.Addresses {
+					resp.Answer = append(resp.Answer, mustRR(fmt.Sprintf("%s 300 IN A %s", name, a)))
+				}
+			case dns.TypeMX:
+				for _, mx := range d.MX {
+					resp.Answer = append(resp.Answer, mustRR(fmt.Sprintf("%s 300 IN MX 10 %s.", name, mx)))
+				}
+			}
+		}
+		w.WriteMsg(resp)
+	})
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &dns.Server{PacketConn: pc, Handler: handler}
+	go srv.ActivateAndServe()
+	t.Cleanup(func() { srv.Shutdown() })
+
+	addr := pc.LocalAddr().String()
+	_, port, _ := net.SplitHostPort(addr)
+	up, _ := resolver.ParseUpstream("udp://" + addr)
+	previous := resolver.Default()
+	resolver.SetDefault(resolver.New(resolver.Config{Upstreams: []resolver.Upstream{up}, AuthoritativePort: port}))
+	t.Cleanup(func() { resolver.SetDefault(previous) })
+}
+
+// startTLS TLS 替身：用测试根证书签发的 *.test 证书完成握手，按 SNI 计数
+func (f *TLD) startTLS(t testing.TB) {
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	root := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root", Organization: []string{"scannertest"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, root, root, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _ = x509.ParseCertificate(rootDER)
+	f.TLSRoots = x509.NewCertPool()
+	f.TLSRoots.AddCert(root)
+
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leaf := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "*.test"},
+		DNSNames:     []string{"*.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leaf, root, &leafKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := tls.Certificate{Certificate: [][]byte{leafDER}, PrivateKey: leafKey}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			f.lookup(SourceTLS, hello.ServerName)
+			return &cert, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+	f.TLSAddr = ln.Addr().String()
+}
+
+func mustRR(s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		panic(fmt.Sprintf("scannertest: parse %q: %v", s, err))
+	}
+	return rr
+}
@@ -0,0 +1,451 @@
+package scanner
+
+import (
+	"domain-agent/backend/internal/pinyin"
+	"fmt"
+	"strings"
+)
+
+// Candidate 策略生成的候选名称
+type Candidate struct {
+	Label        string  // 名称（不含后缀）
+	TLD          string  // 非空时只使用该后缀（如 TLD hack），否则按请求的后缀展开
+	Reason       string  // 推荐理由
+	Memorability float64 // 易记程度（0-1）
+}
+
+// Strategy 域名生成策略
+type Strategy interface {
+	Name() string
+	Description() string
+	Generate(keywords []string) []Candidate
+}
+
+var (
+	strategies     = map[string]Strategy{}
+	strategyOrder  []string
+	defaultTLDHack = []string{"us", "ly", "io", "me", "it", "at", "in", "is", "co", "ai", "am", "es", "to", "so", "sh", "fm", "tv", "la", "st", "er"}
+
+	// 前缀、后缀词典
+	affixPrefixes = []string{"get", "try", "use", "go", "my", "the", "hey", "on", "i", "we"}
+	affixSuffixes = []string{"hq", "app", "ly", "ify", "hub", "lab", "io", "able", "ster", "wise", "verse", "kit", "base", "now", "box", "zen"}
+)
+
+func init() {
+	RegisterStrategy(keywordStrategy{})
+	RegisterStrategy(abbreviationStrategy{})
+	RegisterStrategy(permutationStrategy{})
+	RegisterStrategy(affixStrategy{})
+	RegisterStrategy(portmanteauStrategy{})
+	RegisterStrategy(vowelDropStrategy{})
+	RegisterStrategy(letterDoubleStrategy{})
+	RegisterStrategy(tldHackStrategy{})
+	RegisterStrategy(pinyinStrategy{})
+}
+
+// RegisterStrategy 注册生成策略，同名策略会被覆盖
+func RegisterStrategy(s Strategy) {
+	if _, exists := strategies[s.Name()]; !exists {
+		strategyOrder = append(strategyOrder, s.Name())
+	}
+	strategies[s.Name()] = s
+}
+
+// Strategies 返回已注册的策略，按注册顺序
+func Strategies() []Strategy {
+	list := make([]Strategy, 0, len(strategyOrder))
+	for _, name := range strategyOrder {
+		list = append(list, strategies[name])
+	}
+	return list
+}
+
+// ValidateStrategies 检查策略名称是否都已注册
+func ValidateStrategies(names []string) error {
+	var unknown []string
+	for _, name := range names {
+		if _, exists := strategies[name]; !exists {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown strategies: %s (available: %s)", strings.Join(unknown, ", "), strings.Join(strategyOrder, ", "))
+	}
+	return nil
+}
+
+// sourceStrategy 使用原始关键词（未经翻译）的策略，如拼音需要中文原文
+type sourceStrategy interface {
+	Strategy
+	usesSource()
+}
+
+// selectStrategies 按名称选择策略，为空时使用全部
+func selectStrategies(names []string) []Strategy {
+	if len(names) == 0 {
+		return Strategies()
+	}
+	var list []Strategy
+	for _, name := range names {
+		if s, exists := strategies[name]; exists {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// keywordStrategy 直接使用关键词
+type keywordStrategy struct{}
+
+func (keywordStrategy) Name() string        { return "keyword" }
+func (keywordStrategy) Description() string { return "直接使用关键词" }
+
+func (keywordStrategy) Generate(keywords []string) []Candidate {
+	var candidates []Candidate
+	for _, k := range keywords {
+		candidates = append(candidates, Candidate{Label: k, Reason: "直接使用关键词", Memorability: 0.9})
+	}
+	return candidates
+}
+
+// abbreviationStrategy 关键词缩写
+type abbreviationStrategy struct{}
+
+func (abbreviationStrategy) Name() string        { return "abbreviation" }
+func (abbreviationStrategy) Description() string { return "关键词缩写" }
+
+func (abbreviationStrategy) Generate(keywords []string) []Candidate {
+	var candidates []Candidate
+	for _, k := range keywords {
+		if len(k) > 3 {
+			candidates = append(candidates, Candidate{
+				Label:        generateAbbreviation(k),
+				Reason:       fmt.Sprintf("'%s' 的缩写", k),
+				Memorability: 0.7,
+			})
+		}
+	}
+	// 多个关键词时取首字母
+	if len(keywords) >= 2 {
+		var initials strings.Builder
+		for _, k := range keywords {
+			initials.WriteByte(k[0])
+		}
+		candidates = append(candidates, Candidate{Label: initials.String(), Reason: "关键词首字母", Memorability: 0.6})
+	}
+	return candidates
+}
+
+// permutationStrategy 关键词的所有排列组合（两个或三个词）
+type permutationStrategy struct{}
+
+func (permutationStrategy) Name() string        { return "permutation" }
+func (permutationStrategy) Description() string { return "关键词排列组合" }
+
+func (permutationStrategy) Generate(keywords []string) []Candidate {
+	var candidates []Candidate
+	maxWords := 3
+	if len(keywords) < maxWords {
+		maxWords = len(keywords)
+	}
+	for size := 2; size <= maxWords; size++ {
+		permute(keywords, size, func(words []string) {
+			candidates = append(candidates, Candidate{
+				Label:        strings.Join(words, ""),
+				Reason:       "关键词组合：" + strings.Join(words, " + "),
+				Memorability: 0.8 - 0.1*float64(size-2),
+			})
+		})
+	}
+	return candidates
+}
+
+// permute 枚举从 items 中取 size 个的所有有序排列
+func permute(items []string, size int, visit func([]string)) {
+	used := make([]bool, len(items))
+	current := make([]string, 0, size)
+	var walk func()
+	walk = func() {
+		if len(current) == size {
+			visit(append([]string(nil), current...))
+			return
+		}
+		for i, item := range items {
+			if used[i] {
+				continue
+			}
+			used[i] = true
+			current = append(current, item)
+			walk()
+			current = current[:len(current)-1]
+			used[i] = false
+		}
+	}
+	walk()
+}
+
+// affixStrategy 常见品牌前缀和后缀
+type affixStrategy struct{}
+
+func (affixStrategy) Name() string { return "affix" }
+func (affixStrategy) Description() string {
+	return "加常见前缀（get/try/use）或后缀（hq/app/ly/ify）"
+}
+
+func (affixStrategy) Generate(keywords []string) []Candidate {
+	var candidates []Candidate
+	for _, k := range keywords {
+		for _, p := range affixPrefixes {
+			candidates = append(candidates, Candidate{Label: p + k, Reason: fmt.Sprintf("前缀 '%s' + 关键词", p), Memorability: 0.75})
+		}
+		for _, s := range affixSuffixes {
+			label := k + s
+			// ly/ify 接在 y 或 e 结尾的词后面时去掉重复的元音
+			if (s == "ify" || s == "ly") && strings.HasSuffix(k, "y") {
+				label = strings.TrimSuffix(k, "y") + s
+			}
+			if s == "ify" && strings.HasSuffix(k, "e") {
+				label = strings.TrimSuffix(k, "e") + s
+			}
+			candidates = append(candidates, Candidate{Label: label, Reason: fmt.Sprintf("关键词 + 后缀 '%s'", s), Memorability: 0.75})
+		}
+	}
+	return candidates
+}
+
+// portmanteauStrategy 在音节边界把两个关键词融合成新词（如 brunch、motel）
+type portmanteauStrategy struct{}
+
+func (portmanteauStrategy) Name() string        { return "portmanteau" }
+func (portmanteauStrategy) Description() string { return "在音节边界融合两个关键词" }
+
+func (portmanteauStrategy) Generate(keywords []string) []Candidate {
+	var candidates []Candidate
+	permute(keywords, 2, func(pair []string) {
+		a, b := pair[0], pair[1]
+		seen := map[string]bool{a: true, b: true, a + b: true}
+		add := func(label string) {
+			if len(label) < 4 || seen[label] {
+				return
+			}
+			seen[label] = true
+			candidates = append(candidates, Candidate{
+				Label:        label,
+				Reason:       fmt.Sprintf("'%s' 与 '%s' 融合", a, b),
+				Memorability: 0.7,
+			})
+		}
+
+		// 重叠融合：a 的结尾与 b 的开头相同
+		for n := min(len(a), len(b)) - 1; n >= 2; n-- {
+			if strings.HasSuffix(a, b[:n]) {
+				add(a + b[n:])
+				break
+			}
+		}
+
+		// 音节融合：a 的前几个音节 + b 的后几个音节
+		sa, sb := syllables(a), syllables(b)
+		for i := 1; i <= len(sa); i++ {
+			head := strings.Join(sa[:i], "")
+			for j := 1; j < len(sb); j++ {
+				add(head + strings.Join(sb[j:], ""))
+			}
+		}
+	})
+	return candidates
+}
+
+// vowelDropStrategy 去掉元音（flickr、tumblr 风格）
+type vowelDropStrategy struct{}
+
+func (vowelDropStrategy) Name() string        { return "vowel_drop" }
+func (vowelDropStrategy) Description() string { return "去掉元音（flickr 风格）" }
+
+func (vowelDropStrategy) Generate(keywords []string) []Candidate {
+	var candidates []Candidate
+	for _, k := range keywords {
+		seen := map[string]bool{k: true}
+		add := func(label, reason string) {
+			if len(label) >= 3 && !seen[label] {
+				seen[label] = true
+				candidates = append(candidates, Candidate{Label: label, Reason: reason, Memorability: 0.6})
+			}
+		}
+
+		// 结尾的 er/or/ar 去掉元音：flicker → flickr
+		for _, ending := range []string{"er", "or", "ar"} {
+			if strings.HasSuffix(k, ending) && len(k) > 4 {
+				add(strings.TrimSuffix(k, ending)+"r", fmt.Sprintf("'%s' 去掉结尾元音", k))
+			}
+		}
+
+		// 去掉首字母以外的元音：tumbler → tmblr
+		var b strings.Builder
+		for i, r := range k {
+			if i == 0 || !isVowel(byte(r)) {
+				b.WriteRune(r)
+			}
+		}
+		add(b.String(), fmt.Sprintf("'%s' 去掉元音", k))
+	}
+	return candidates
+}
+
+// letterDoubleStrategy 双写字母（digg、flipp 风格）
+type letterDoubleStrategy struct{}
+
+func (letterDoubleStrategy) Name() string { return "letter_double" }
+func (letterDoubleStrategy) Description() string {
+	return "双写结尾辅音或元音（digg 风格）"
+}
+
+func (letterDoubleStrategy) Generate(keywords []string) []Candidate {
+	var candidates []Candidate
+	for _, k := range keywords {
+		if len(k) < 3 {
+			continue
+		}
+		last := k[len(k)-1]
+		if !isVowel(last) && last != k[len(k)-2] && strings.IndexByte("shwxjqc", last) < 0 {
+			candidates = append(candidates, Candidate{Label: k + string(last), Reason: fmt.Sprintf("'%s' 双写结尾字母", k), Memorability: 0.55})
+		}
+		// 双写第一个单独出现的 o 或 e（已经是双写的如 look 不再处理）
+		for i := 1; i < len(k)-1; i++ {
+			if (k[i] == 'o' || k[i] == 'e') && k[i-1] != k[i] && k[i+1] != k[i] {
+				candidates = append(candidates, Candidate{Label: k[:i+1] + k[i:], Reason: fmt.Sprintf("'%s' 双写元音", k), Memorability: 0.55})
+				break
+			}
+		}
+	}
+	return candidates
+}
+
+// tldHackStrategy 用后缀拼出完整单词（delicio.us、bit.ly 风格）
+type tldHackStrategy struct{}
+
+func (tldHackStrategy) Name() string { return "tld_hack" }
+func (tldHackStrategy) Description() string {
+	return "用后缀拼出单词（delicio.us、bit.ly 风格）"
+}
+
+func (tldHackStrategy) Generate(keywords []string) []Candidate {
+	var candidates []Candidate
+	words := append([]string(nil), keywords...)
+	if len(keywords) >= 2 {
+		words = append(words, strings.Join(keywords[:2], ""))
+	}
+
+	for _, k := range words {
+		for _, tld := range defaultTLDHack {
+			if !strings.HasSuffix(k, tld) || len(k)-len(tld) < 2 {
+				continue
+			}
+			label := strings.TrimSuffix(k, tld)
+			candidates = append(candidates, Candidate{
+				Label:        label,
+				TLD:          "." + tld,
+				Reason:       fmt.Sprintf("用 .%s 拼出 '%s'", tld, k),
+				Memorability: 0.65,
+			})
+
+			// deli.cio.us 式：名称较长时在音节边界拆出子域名
+			parts := syllables(label)
+			if len(parts) >= 2 && len(label) > 6 {
+				sld := parts[len(parts)-1]
+				if len(sld) >= 3 {
+					sub := strings.TrimSuffix(label, sld)
+					candidates = append(candidates, Candidate{
+						Label:        sld,
+						TLD:          "." + tld,
+						Reason:       fmt.Sprintf("可组成 %s.%s.%s", sub, sld, tld),
+						Memorability: 0.5,
+					})
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// pinyinStrategy 中文品牌名转换为拼音（阿里巴巴 → alibaba、albb）
+type pinyinStrategy struct{}
+
+func (pinyinStrategy) Name() string { return "pinyin" }
+func (pinyinStrategy) Description() string {
+	return "中文转拼音：全拼、首字母和音节组合（alibaba、albb）"
+}
+
+func (pinyinStrategy) usesSource() {}
+
+// pinyinReasons 各拼音组合方式的推荐理由
+var pinyinReasons = map[string]string{
+	pinyin.KindFull:     "'%s' 的全拼",
+	pinyin.KindInitials: "'%s' 的拼音首字母",
+	pinyin.KindMixed:    "'%s' 的拼音与首字母组合",
+	pinyin.KindPartial:  "'%s' 的部分音节",
+}
+
+func (pinyinStrategy) Generate(keywords []string) []Candidate {
+	var candidates []Candidate
+	for _, k := range keywords {
+		if !pinyin.HasHan(k) {
+			continue
+		}
+		for _, c := range pinyin.Combinations(pinyin.Syllables(k)) {
+			candidates = append(candidates, Candidate{
+				Label:        c.Label,
+				Reason:       fmt.Sprintf(pinyinReasons[c.Kind], k),
+				Memorability: max(pinyin.Naturalness(c.Label), 0.4), // 拼音与首字母混合的组合读不出来，但仍可记忆
+			})
+		}
+	}
+	return candidates
+}
+
+// syllables 按元音组粗略切分音节：V-CV 在辅音前断开，VC-CV 在两个辅音之间断开
+func syllables(word string) []string {
+	var parts []string
+	start := 0
+	i := 0
+	for i < len(word) {
+		// 跳过开头的辅音和元音组
+		for i < len(word) && !isVowel(word[i]) {
+			i++
+		}
+		for i < len(word) && isVowel(word[i]) {
+			i++
+		}
+		// 统计后面的辅音
+		j := i
+		for j < len(word) && !isVowel(word[j]) {
+			j++
+		}
+		if j == len(word) {
+			break
+		}
+		consonants := j - i
+		if consonants <= 1 {
+			parts = append(parts, word[start:i])
+			start = i
+		} else {
+			parts = append(parts, word[start:i+1])
+			start = i + 1
+		}
+		i = start
+	}
+	parts = append(parts, word[start:])
+
+	// 去掉空音节
+	result := parts[:0]
+	for _, p := range parts {
+		if p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func isVowel(c byte) bool {
+	return strings.IndexByte("aeiouy", c) >= 0
+}
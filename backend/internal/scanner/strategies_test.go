@@ -0,0 +1,148 @@
+package scanner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"domain-agent/backend/internal/types"
+)
+
+// labels 候选名称，TLD 非空时带上后缀
+func labels(candidates []Candidate) []string {
+	var list []string
+	for _, c := range candidates {
+		list = append(list, c.Label+c.TLD)
+	}
+	return list
+}
+
+func contains(list []string, want ...string) []string {
+	set := map[string]bool{}
+	for _, s := range list {
+		set[s] = true
+	}
+	var missing []string
+	for _, w := range want {
+		if !set[w] {
+			missing = append(missing, w)
+		}
+	}
+	return missing
+}
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		keywords []string
+		want     []string
+	}{
+		{"keyword", []string{"cloud", "kit"}, []string{"cloud", "kit"}},
+		{"abbreviation", []string{"cloud", "kit"}, []string{"ck"}},
+		{"permutation", []string{"cloud", "kit", "box"}, []string{"cloudkit", "kitcloud", "cloudkitbox", "boxkitcloud"}},
+		{"affix", []string{"spot"}, []string{"getspot", "spothq", "spotify"}},
+		{"affix", []string{"happy"}, []string{"happify", "happly"}},
+		{"portmanteau", []string{"breakfast", "lunch"}, []string{"lunchfast"}},
+		{"portmanteau", []string{"motor", "hotel"}, []string{"motel"}},
+		{"vowel_drop", []string{"flicker", "tumbler"}, []string{"flickr", "tmblr"}},
+		{"letter_double", []string{"dig", "flip"}, []string{"digg", "flipp"}},
+		{"tld_hack", []string{"delicious"}, []string{"delicio.us", "cio.us"}},
+		{"tld_hack", []string{"bit", "ly"}, []string{"bit.ly"}},
+	}
+	for _, tt := range tests {
+		got := labels(strategies[tt.strategy].Generate(tt.keywords))
+		if missing := contains(got, tt.want...); len(missing) > 0 {
+			t.Errorf("%s(%v) missing %v, got %v", tt.strategy, tt.keywords, missing, got)
+		}
+	}
+}
+
+func TestStrategyCandidatesValid(t *testing.T) {
+	keywords := []string{"cloud", "studio", "happy"}
+	for _, s := range Strategies() {
+		if s.Description() == "" {
+			t.Errorf("%s: empty description", s.Name())
+		}
+		for _, c := range s.Generate(keywords) {
+			if c.Label == "" || c.Reason == "" || c.Memorability <= 0 || c.Memorability > 1 {
+				t.Errorf("%s: invalid candidate %+v", s.Name(), c)
+			}
+			if c.TLD != "" && !strings.HasPrefix(c.TLD, ".") {
+				t.Errorf("%s: TLD %q without leading dot", s.Name(), c.TLD)
+			}
+		}
+	}
+}
+
+func TestStrategiesSingleKeyword(t *testing.T) {
+	// 只有一个或没有关键词时组合类策略不应出错
+	for _, keywords := range [][]string{nil, {"a"}, {"go"}} {
+		for _, s := range Strategies() {
+			s.Generate(keywords)
+		}
+	}
+	if got := strategies["permutation"].Generate([]string{"cloud"}); len(got) != 0 {
+		t.Errorf("permutation of one keyword = %v", labels(got))
+	}
+}
+
+func TestValidateStrategies(t *testing.T) {
+	if err := ValidateStrategies([]string{"affix", "tld_hack"}); err != nil {
+		t.Errorf("ValidateStrategies(known) = %v", err)
+	}
+	err := ValidateStrategies([]string{"affix", "rhyme"})
+	if err == nil || !strings.Contains(err.Error(), "rhyme") || strings.Contains(err.Error(), "unknown strategies: affix") {
+		t.Errorf("ValidateStrategies(unknown) = %v", err)
+	}
+
+	if got := selectStrategies([]string{"tld_hack", "rhyme", "keyword"}); len(got) != 2 || got[0].Name() != "tld_hack" {
+		t.Errorf("selectStrategies = %v", got)
+	}
+	if got := selectStrategies(nil); len(got) != len(strategyOrder) {
+		t.Errorf("selectStrategies(nil) returned %d, want all %d", len(got), len(strategyOrder))
+	}
+}
+
+func TestSyllables(t *testing.T) {
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"breakfast", []string{"break", "fast"}},
+		{"hotel", []string{"ho", "tel"}},
+		{"kit", []string{"kit"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := syllables(tt.word)
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("syllables(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestPinyinBonusOnlyForHanInput(t *testing.T) {
+	// ten、man 也能切分为拼音，但来自英文输入时不加分
+	for _, s := range GenerateSuggestions(types.SuggestDomainsRequest{Keywords: []string{"ten", "man"}, TLDs: []string{".cn"}, Strategies: []string{"keyword"}}) {
+		if want := calculateScore(s.Domain); s.Score != want {
+			t.Errorf("%s: score %v, want %v without pinyin bonus", s.Domain, s.Score, want)
+		}
+	}
+
+	suggestions := GenerateSuggestions(types.SuggestDomainsRequest{Keywords: []string{"阿里巴巴"}, TLDs: []string{".cn"}, Strategies: []string{"pinyin"}})
+	found := false
+	for _, s := range suggestions {
+		if s.Domain == "alibaba.cn" {
+			found = true
+			if want := calculateScore(s.Domain) + pinyinBonus(s.Domain); s.Score != want || pinyinBonus(s.Domain) == 0 {
+				t.Errorf("alibaba.cn: score %v, want %v with pinyin bonus", s.Score, want)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("pinyin strategy did not generate alibaba.cn: %+v", suggestions)
+	}
+}
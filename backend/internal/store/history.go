@@ -0,0 +1,282 @@
+package store
+
+import (
+	"container/list"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"domain-agent/backend/internal/types"
+)
+
+// MaxSnapshots 每个域名最多保留的快照数，超过时删除最早的
+const MaxSnapshots = 200
+
+// ErrSnapshotNotFound 要更新的快照不存在
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// HistoryStore 注册信息快照存储
+//
+// 存储只负责保存和读取，是否与上一份快照相同由调用方判断（见 history 包）：
+// 内容相同时只更新最近一次看到的时间，不同时追加新快照。时间按毫秒精度保存，
+// 调用方应传入截断到毫秒的时间。
+type HistoryStore interface {
+	AddSnapshot(snapshot types.RegistrationSnapshot) error
+	// TouchSnapshot 更新某个快照最近一次看到的时间
+	TouchSnapshot(domain, source string, capturedAt, seenAt time.Time) error
+	// Snapshots 域名的全部快照，按采集时间从早到晚排列
+	Snapshots(domain string) ([]types.RegistrationSnapshot, error)
+	Close() error
+}
+
+// OpenHistory 根据 DATABASE_URL 打开快照存储，地址格式与 Open 相同
+func OpenHistory(databaseURL string) (HistoryStore, error) {
+	backend, dsn, err := parseURL(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	switch backend {
+	case backendSQLite:
+		return NewSQLiteHistory(dsn)
+	case backendPostgres:
+		return NewPostgresHistory(dsn)
+	default:
+		return NewMemoryHistory(), nil
+	}
+}
+
+// MaxMemoryDomains 内存存储最多保留的域名数，超过时删除最久没有写入的域名
+const MaxMemoryDomains = 10000
+
+// MemoryHistory 内存快照存储，进程重启后数据丢失
+type MemoryHistory struct {
+	mu         sync.RWMutex
+	domains    map[string]*list.Element // 值为 *memoryDomain
+	recent     *list.List               // 按最近一次写入排列，最新的在前
+	maxDomains int
+}
+
+// memoryDomain 一个域名的快照
+type memoryDomain struct {
+	domain    string
+	snapshots []types.RegistrationSnapshot
+}
+
+// NewMemoryHistory 创建内存快照存储
+func NewMemoryHistory() *MemoryHistory {
+	return &MemoryHistory{domains: map[string]*list.Element{}, recent: list.New(), maxDomains: MaxMemoryDomains}
+}
+
+// AddSnapshot 追加快照
+func (h *MemoryHistory) AddSnapshot(snapshot types.RegistrationSnapshot) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e, ok := h.domains[snapshot.Domain]
+	if ok {
+		h.recent.MoveToFront(e)
+	} else {
+		e = h.recent.PushFront(&memoryDomain{domain: snapshot.Domain})
+		h.domains[snapshot.Domain] = e
+		for h.recent.Len() > h.maxDomains {
+			oldest := h.recent.Back()
+			delete(h.domains, h.recent.Remove(oldest).(*memoryDomain).domain)
+		}
+	}
+	d := e.Value.(*memoryDomain)
+	d.snapshots = append(d.snapshots, cloneSnapshot(snapshot))
+	if len(d.snapshots) > MaxSnapshots {
+		d.snapshots = d.snapshots[len(d.snapshots)-MaxSnapshots:]
+	}
+	return nil
+}
+
+// TouchSnapshot 更新快照最近一次看到的时间
+func (h *MemoryHistory) TouchSnapshot(domain, source string, capturedAt, seenAt time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e, ok := h.domains[domain]
+	if !ok {
+		return ErrSnapshotNotFound
+	}
+	d := e.Value.(*memoryDomain)
+	for i := range d.snapshots {
+		s := &d.snapshots[i]
+		if s.Source == source && s.CapturedAt.Equal(capturedAt) {
+			s.LastSeenAt = seenAt
+			h.recent.MoveToFront(e)
+			return nil
+		}
+	}
+	return ErrSnapshotNotFound
+}
+
+// Snapshots 域名的全部快照
+func (h *MemoryHistory) Snapshots(domain string) ([]types.RegistrationSnapshot, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	e, ok := h.domains[domain]
+	if !ok {
+		return []types.RegistrationSnapshot{}, nil
+	}
+	d := e.Value.(*memoryDomain)
+	snapshots := make([]types.RegistrationSnapshot, len(d.snapshots))
+	for i, s := range d.snapshots {
+		snapshots[i] = cloneSnapshot(s)
+	}
+	return snapshots, nil
+}
+
+// Close 内存存储无需关闭
+func (h *MemoryHistory) Close() error { return nil }
+
+// cloneSnapshot 复制切片字段，避免调用方修改存储中的数据
+func cloneSnapshot(s types.RegistrationSnapshot) types.RegistrationSnapshot {
+	s.NameServers = append([]string(nil), s.NameServers...)
+	s.Status = append([]string(nil), s.Status...)
+	return s
+}
+
+// SQLHistory 基于 database/sql 的快照存储，SQLite 和 Postgres 共用
+//
+// 快照以 JSON 保存在 data 列，时间以 Unix 毫秒保存。
+type SQLHistory struct {
+	db       *sql.DB
+	postgres bool
+}
+
+const createSnapshotsTable = `CREATE TABLE IF NOT EXISTS registration_snapshots (
+	domain       TEXT NOT NULL,
+	source       TEXT NOT NULL,
+	captured_at  BIGINT NOT NULL,
+	last_seen_at BIGINT NOT NULL,
+	data         TEXT NOT NULL,
+	PRIMARY KEY (domain, source, captured_at)
+)`
+
+// NewSQLiteHistory 打开 SQLite 快照存储
+//
+// 会话存储可能同时打开同一个文件，设置等待锁的时间，避免并发写入时直接失败。
+func NewSQLiteHistory(path string) (*SQLHistory, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA busy_timeout = 5000"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to configure sqlite: %w", err)
+	}
+	return newSQLHistory(db, false)
+}
+
+// NewPostgresHistory 打开 Postgres 快照存储
+func NewPostgresHistory(dsn string) (*SQLHistory, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+	return newSQLHistory(db, true)
+}
+
+func newSQLHistory(db *sql.DB, postgres bool) (*SQLHistory, error) {
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect database: %w", err)
+	}
+	if _, err := db.Exec(createSnapshotsTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate registration_snapshots table: %w", err)
+	}
+	return &SQLHistory{db: db, postgres: postgres}, nil
+}
+
+// AddSnapshot 追加快照，并删除超出保留数量的最早快照
+func (h *SQLHistory) AddSnapshot(snapshot types.RegistrationSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if _, err := h.db.Exec(h.rebind(`INSERT INTO registration_snapshots (domain, source, captured_at, last_seen_at, data)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (domain, source, captured_at) DO UPDATE SET last_seen_at = excluded.last_seen_at, data = excluded.data`),
+		snapshot.Domain, snapshot.Source, snapshot.CapturedAt.UnixMilli(), snapshot.LastSeenAt.UnixMilli(), string(data),
+	); err != nil {
+		return err
+	}
+
+	_, err = h.db.Exec(h.rebind(`DELETE FROM registration_snapshots WHERE domain = ? AND captured_at < (
+	SELECT MIN(captured_at) FROM (
+		SELECT captured_at FROM registration_snapshots WHERE domain = ? ORDER BY captured_at DESC LIMIT ?
+	) AS kept
+)`), snapshot.Domain, snapshot.Domain, MaxSnapshots)
+	return err
+}
+
+// TouchSnapshot 更新快照最近一次看到的时间
+func (h *SQLHistory) TouchSnapshot(domain, source string, capturedAt, seenAt time.Time) error {
+	var data string
+	err := h.db.QueryRow(
+		h.rebind("SELECT data FROM registration_snapshots WHERE domain = ? AND source = ? AND captured_at = ?"),
+		domain, source, capturedAt.UnixMilli(),
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSnapshotNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var snapshot types.RegistrationSnapshot
+	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+		return err
+	}
+	snapshot.LastSeenAt = seenAt
+	updated, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	_, err = h.db.Exec(
+		h.rebind("UPDATE registration_snapshots SET last_seen_at = ?, data = ? WHERE domain = ? AND source = ? AND captured_at = ?"),
+		seenAt.UnixMilli(), string(updated), domain, source, capturedAt.UnixMilli(),
+	)
+	return err
+}
+
+// Snapshots 域名的全部快照，按采集时间排列
+func (h *SQLHistory) Snapshots(domain string) ([]types.RegistrationSnapshot, error) {
+	rows, err := h.db.Query(
+		h.rebind("SELECT data FROM registration_snapshots WHERE domain = ? ORDER BY captured_at, source"),
+		domain,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []types.RegistrationSnapshot{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var snapshot types.RegistrationSnapshot
+		if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// Close 关闭连接
+func (h *SQLHistory) Close() error {
+	return h.db.Close()
+}
+
+// rebind 把 ? 占位符转换为 Postgres 的 $n 形式
+func (h *SQLHistory) rebind(query string) string {
+	return rebind(query, h.postgres)
+}
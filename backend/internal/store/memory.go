@@ -0,0 +1,142 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"domain-agent/backend/internal/types"
+)
+
+// MemoryStore 内存会话存储，进程重启后数据丢失
+type MemoryStore struct {
+	mu       sync.RWMutex
+	ttl      time.Duration
+	sessions map[string][]byte
+	stop     chan struct{}
+}
+
+// NewMemoryStore 创建内存存储，并启动后台过期清理
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		ttl:      ttl,
+		sessions: make(map[string][]byte),
+		stop:     make(chan struct{}),
+	}
+	go runJanitor(ttl, s.stop, s.purge)
+	return s
+}
+
+// Get 获取会话副本
+func (s *MemoryStore) Get(id string) (*types.Session, error) {
+	s.mu.RLock()
+	data, exists := s.sessions[id]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	var session types.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	if s.expired(&session) {
+		return nil, ErrNotFound
+	}
+	return &session, nil
+}
+
+// Save 保存会话
+func (s *MemoryStore) Save(session *types.Session) error {
+	// 以序列化形式保存，避免调用方持有的指针被并发修改
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.sessions[session.ID] = data
+	s.mu.Unlock()
+	return nil
+}
+
+// Delete 删除会话
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.sessions[id]; !exists {
+		return ErrNotFound
+	}
+	delete(s.sessions, id)
+	return nil
+}
+
+// List 列出未过期的会话，按更新时间倒序
+func (s *MemoryStore) List() ([]types.SessionSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summaries := make([]types.SessionSummary, 0, len(s.sessions))
+	for _, data := range s.sessions {
+		var session types.Session
+		if err := json.Unmarshal(data, &session); err != nil {
+			return nil, err
+		}
+		if !s.expired(&session) {
+			summaries = append(summaries, summarize(&session))
+		}
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
+	})
+	return summaries, nil
+}
+
+// Close 停止后台清理
+func (s *MemoryStore) Close() error {
+	close(s.stop)
+	return nil
+}
+
+func (s *MemoryStore) expired(session *types.Session) bool {
+	return time.Since(session.UpdatedAt) > s.ttl
+}
+
+// purge 清理过期会话
+func (s *MemoryStore) purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, data := range s.sessions {
+		var session types.Session
+		if err := json.Unmarshal(data, &session); err != nil || s.expired(&session) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// runJanitor 定期执行清理，间隔为 TTL 的十分之一（1 分钟到 1 小时之间）
+func runJanitor(ttl time.Duration, stop <-chan struct{}, purge func()) {
+	interval := ttl / 10
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	if interval > time.Hour {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			purge()
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"domain-agent/backend/internal/types"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// SQLStore 基于 database/sql 的会话存储，SQLite 和 Postgres 共用
+//
+// 会话整体以 JSON 保存在 data 列，时间以 Unix 毫秒保存，避免两种方言的时间类型差异。
+type SQLStore struct {
+	db       *sql.DB
+	ttl      time.Duration
+	postgres bool
+	stop     chan struct{}
+}
+
+const createSessionsTable = `CREATE TABLE IF NOT EXISTS sessions (
+	id         TEXT PRIMARY KEY,
+	data       TEXT NOT NULL,
+	created_at BIGINT NOT NULL,
+	updated_at BIGINT NOT NULL,
+	expires_at BIGINT NOT NULL
+)`
+
+const createSessionsExpiryIndex = `CREATE INDEX IF NOT EXISTS sessions_expires_at ON sessions (expires_at)`
+
+// NewSQLiteStore 打开 SQLite 存储
+func NewSQLiteStore(path string, ttl time.Duration) (*SQLStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+	// SQLite 同一时间只允许一个写入者
+	db.SetMaxOpenConns(1)
+	return newSQLStore(db, ttl, false)
+}
+
+// NewPostgresStore 打开 Postgres 存储
+func NewPostgresStore(dsn string, ttl time.Duration) (*SQLStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+	return newSQLStore(db, ttl, true)
+}
+
+func newSQLStore(db *sql.DB, ttl time.Duration, postgres bool) (*SQLStore, error) {
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect database: %w", err)
+	}
+
+	for _, stmt := range []string{createSessionsTable, createSessionsExpiryIndex} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate sessions table: %w", err)
+		}
+	}
+
+	s := &SQLStore{db: db, ttl: ttl, postgres: postgres, stop: make(chan struct{})}
+	go runJanitor(ttl, s.stop, s.purge)
+	return s, nil
+}
+
+// Get 获取会话
+func (s *SQLStore) Get(id string) (*types.Session, error) {
+	var data string
+	err := s.db.QueryRow(
+		s.rebind("SELECT data FROM sessions WHERE id = ? AND expires_at > ?"),
+		id, time.Now().UnixMilli(),
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session types.Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Save 保存会话，并刷新过期时间
+func (s *SQLStore) Save(session *types.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(s.rebind(`INSERT INTO sessions (id, data, created_at, updated_at, expires_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at, expires_at = excluded.expires_at`),
+		session.ID,
+		string(data),
+		session.CreatedAt.UnixMilli(),
+		session.UpdatedAt.UnixMilli(),
+		session.UpdatedAt.Add(s.ttl).UnixMilli(),
+	)
+	return err
+}
+
+// Delete 删除会话
+func (s *SQLStore) Delete(id string) error {
+	res, err := s.db.Exec(s.rebind("DELETE FROM sessions WHERE id = ?"), id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List 列出未过期的会话，按更新时间倒序
+func (s *SQLStore) List() ([]types.SessionSummary, error) {
+	rows, err := s.db.Query(
+		s.rebind("SELECT data FROM sessions WHERE expires_at > ? ORDER BY updated_at DESC"),
+		time.Now().UnixMilli(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []types.SessionSummary{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var session types.Session
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summarize(&session))
+	}
+	return summaries, rows.Err()
+}
+
+// Close 停止后台清理并关闭连接
+func (s *SQLStore) Close() error {
+	close(s.stop)
+	return s.db.Close()
+}
+
+// purge 删除过期会话
+func (s *SQLStore) purge() {
+	if _, err := s.db.Exec(s.rebind("DELETE FROM sessions WHERE expires_at <= ?"), time.Now().UnixMilli()); err != nil {
+		fmt.Printf("Session purge failed: %v\n", err)
+	}
+}
+
+// rebind 把 ? 占位符转换为 Postgres 的 $n 形式
+func (s *SQLStore) rebind(query string) string {
+	return rebind(query, s.postgres)
+}
+
+// rebind 在 Postgres 上把 ? 占位符转换为 $n 形式
+func rebind(query string, postgres bool) string {
+	if !postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, ch := range query {
+		if ch == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"domain-agent/backend/internal/types"
+)
+
+// ErrNotFound 会话不存在或已过期
+var ErrNotFound = errors.New("session not found")
+
+// DefaultTTL 会话默认保留时长
+const DefaultTTL = 24 * time.Hour
+
+// SessionStore 会话存储
+//
+// 实现需要并发安全；Get 返回的是副本，修改后需调用 Save 才会持久化。
+// 超过 TTL 未更新的会话视为过期，Get/List 不再返回，并由后台定期清理。
+type SessionStore interface {
+	Get(id string) (*types.Session, error)
+	Save(session *types.Session) error
+	Delete(id string) error
+	List() ([]types.SessionSummary, error)
+	Close() error
+}
+
+// 存储后端
+const (
+	backendMemory   = "memory"
+	backendSQLite   = "sqlite"
+	backendPostgres = "postgres"
+)
+
+// parseURL 解析 DATABASE_URL，返回存储后端和交给驱动的地址；会话和快照存储共用
+//
+//	""                         内存存储
+//	sqlite://path/to/file.db   SQLite（也接受 sqlite:path 和 file: 前缀）
+//	postgres://... 或 postgresql://...  Postgres
+func parseURL(databaseURL string) (backend, dsn string, err error) {
+	switch {
+	case databaseURL == "" || databaseURL == "memory":
+		return backendMemory, "", nil
+	case strings.HasPrefix(databaseURL, "sqlite://"):
+		return backendSQLite, strings.TrimPrefix(databaseURL, "sqlite://"), nil
+	case strings.HasPrefix(databaseURL, "sqlite:"):
+		return backendSQLite, strings.TrimPrefix(databaseURL, "sqlite:"), nil
+	case strings.HasPrefix(databaseURL, "file:"):
+		return backendSQLite, databaseURL, nil
+	case strings.HasPrefix(databaseURL, "postgres://"), strings.HasPrefix(databaseURL, "postgresql://"):
+		return backendPostgres, databaseURL, nil
+	default:
+		return "", "", fmt.Errorf("unsupported DATABASE_URL scheme: %s", databaseURL)
+	}
+}
+
+// Open 根据 DATABASE_URL 打开会话存储，地址格式见 parseURL
+func Open(databaseURL string, ttl time.Duration) (SessionStore, error) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	backend, dsn, err := parseURL(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	switch backend {
+	case backendSQLite:
+		return NewSQLiteStore(dsn, ttl)
+	case backendPostgres:
+		return NewPostgresStore(dsn, ttl)
+	default:
+		return NewMemoryStore(ttl), nil
+	}
+}
+
+// summarize 生成会话摘要
+func summarize(session *types.Session) types.SessionSummary {
+	summary := types.SessionSummary{
+		ID:           session.ID,
+		MessageCount: len(session.Messages),
+		CreatedAt:    session.CreatedAt,
+		UpdatedAt:    session.UpdatedAt,
+	}
+	for i := len(session.Messages) - 1; i >= 0; i-- {
+		if session.Messages[i].Role == "user" {
+			summary.LastMessage = session.Messages[i].Content
+			break
+		}
+	}
+	return summary
+}
@@ -0,0 +1,58 @@
+package tldwhois
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// limiter 令牌桶：容量为 n，每 per/n 补充一个，允许短时间内连续查询 n 次
+type limiter struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	interval time.Duration // 补充一个令牌的时间
+	last     time.Time
+}
+
+func newLimiter(n int, per time.Duration) *limiter {
+	return &limiter{
+		capacity: float64(n),
+		tokens:   float64(n),
+		interval: per / time.Duration(n),
+		last:     time.Now(),
+	}
+}
+
+// wait 取得一个令牌，没有时等待补充；等待会超过 ctx 的期限时不占用令牌，返回 ErrRateLimited
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.capacity, l.tokens+float64(now.Sub(l.last))/float64(l.interval))
+	l.last = now
+
+	var delay time.Duration
+	if l.tokens < 1 {
+		delay = time.Duration((1 - l.tokens) * float64(l.interval))
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		l.mu.Unlock()
+		return fmt.Errorf("%w: next query in %s", ErrRateLimited, delay.Round(time.Millisecond))
+	}
+	// 先扣除令牌（可以为负），后来的查询排在这次之后
+	l.tokens--
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
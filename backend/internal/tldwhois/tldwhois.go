@@ -0,0 +1,375 @@
+// Package tldwhois 按后缀配置 WHOIS 服务器、查询格式、字符集和判断规则
+//
+// likexian/whois 自己向 IANA 查找服务器，扫描器又用同一组英文字样判断所有后缀的回答，
+// .cn、.de、.jp 等注册局的措辞和编码不同（DENIC 的已注册回答只有 "Status: connect"，
+// JPRS 默认返回 ISO-2022-JP 编码的日文），容易误判。这里按后缀配置服务器、查询格式、
+// 字符集、可注册/已注册/限流的正则和查询频率。内置配置见 servers.json，
+// 可以用 WHOIS_SERVERS 指定的文件按字段覆盖或追加后缀；没有配置的后缀向 IANA
+// 查找服务器，并使用 default 中的通用规则。
+package tldwhois
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"domain-agent/backend/internal/evidence"
+
+	"github.com/likexian/whois"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+//go:embed servers.json
+var builtinConfig []byte
+
+// ErrRateLimited 等待查询配额会超过调用方的期限
+var ErrRateLimited = errors.New("whois rate limit reached")
+
+// Server 一个后缀的配置，留空的字段沿用 default
+type Server struct {
+	Server     string   `json:"server,omitempty"`     // host 或 host:port，为空时向 IANA 查找
+	Query      string   `json:"query,omitempty"`      // 发送的查询，%s 替换为域名，默认 "%s"
+	Charset    string   `json:"charset,omitempty"`    // 回答的字符集（如 iso-2022-jp、euc-kr），默认 UTF-8
+	Available  []string `json:"available,omitempty"`  // 可注册的正则（不区分大小写）
+	Registered []string `json:"registered,omitempty"` // 已注册或保留、不可注册的正则
+	Limited    []string `json:"limited,omitempty"`    // 限流或拒绝服务的正则，先于其余规则判断
+	RateLimit  string   `json:"rate_limit,omitempty"` // 查询频率上限，如 "30/m"、"1/s"，同一服务器共用
+}
+
+// Config 配置文件
+//
+//	{"default": {"available": ["no match"], ...},
+//	 "tlds": {"de": {"server": "whois.denic.de", "query": "-T dn,ace %s", "rate_limit": "60/m", ...}}}
+type Config struct {
+	Default Server            `json:"default"`
+	TLDs    map[string]Server `json:"tlds"`
+}
+
+// merge 用 o 中给出的字段覆盖 s
+func (s Server) merge(o Server) Server {
+	if o.Server != "" {
+		s.Server = o.Server
+	}
+	if o.Query != "" {
+		s.Query = o.Query
+	}
+	if o.Charset != "" {
+		s.Charset = o.Charset
+	}
+	if o.Available != nil {
+		s.Available = o.Available
+	}
+	if o.Registered != nil {
+		s.Registered = o.Registered
+	}
+	if o.Limited != nil {
+		s.Limited = o.Limited
+	}
+	if o.RateLimit != "" {
+		s.RateLimit = o.RateLimit
+	}
+	return s
+}
+
+// Rule 编译后的后缀规则
+type Rule struct {
+	TLD    string // 配置中的后缀，使用 default 时为空
+	Server string
+	Query  string
+
+	charset    encoding.Encoding // UTF-8 时为空
+	available  []*regexp.Regexp
+	registered []*regexp.Regexp
+	limited    []*regexp.Regexp
+	limiter    *limiter // 未限制频率时为空
+}
+
+// Registry 按后缀查找规则
+type Registry struct {
+	fallback *Rule
+	rules    map[string]*Rule
+}
+
+// New 编译配置：每个后缀的规则为 default 与该后缀字段的合并，同一服务器共用频率限制
+func New(cfg Config) (*Registry, error) {
+	limiters := map[string]*limiter{}
+	fallback, err := compile("", cfg.Default, limiters)
+	if err != nil {
+		return nil, fmt.Errorf("default: %w", err)
+	}
+	r := &Registry{fallback: fallback, rules: map[string]*Rule{}}
+
+	for tld, s := range cfg.TLDs {
+		tld = strings.ToLower(strings.Trim(tld, "."))
+		rule, err := compile(tld, cfg.Default.merge(s), limiters)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tld, err)
+		}
+		r.rules[tld] = rule
+	}
+	return r, nil
+}
+
+func compile(tld string, s Server, limiters map[string]*limiter) (*Rule, error) {
+	rule := &Rule{TLD: tld, Server: strings.ToLower(s.Server), Query: s.Query}
+	if rule.Query == "" {
+		rule.Query = "%s"
+	}
+	if !strings.Contains(rule.Query, "%s") {
+		return nil, fmt.Errorf("query %q has no %%s", rule.Query)
+	}
+
+	if cs := strings.ToLower(s.Charset); cs != "" && cs != "utf-8" && cs != "utf8" {
+		enc, err := htmlindex.Get(cs)
+		if err != nil {
+			return nil, fmt.Errorf("charset %q: %w", s.Charset, err)
+		}
+		rule.charset = enc
+	}
+
+	var err error
+	if rule.available, err = compilePatterns(s.Available); err != nil {
+		return nil, err
+	}
+	if rule.registered, err = compilePatterns(s.Registered); err != nil {
+		return nil, err
+	}
+	if rule.limited, err = compilePatterns(s.Limited); err != nil {
+		return nil, err
+	}
+
+	if s.RateLimit != "" {
+		n, per, err := parseRateLimit(s.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+		// 同一服务器服务多个后缀（如 com 和 net）时共用配额；向 IANA 查找服务器的按后缀计
+		key := rule.Server
+		if key == "" {
+			key = "." + tld
+		}
+		if limiters[key] == nil {
+			limiters[key] = newLimiter(n, per)
+		}
+		rule.limiter = limiters[key]
+	}
+	return rule, nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// parseRateLimit 解析 "次数/单位"，单位为 s、m、h
+func parseRateLimit(s string) (int, time.Duration, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if !ok || err != nil || n <= 0 {
+		return 0, 0, fmt.Errorf("rate_limit %q: want count/unit such as 30/m", s)
+	}
+	switch strings.TrimSpace(unit) {
+	case "s":
+		return n, time.Second, nil
+	case "m":
+		return n, time.Minute, nil
+	case "h":
+		return n, time.Hour, nil
+	}
+	return 0, 0, fmt.Errorf("rate_limit %q: unit must be s, m or h", s)
+}
+
+// Builtin 内置配置
+func Builtin() Config {
+	var cfg Config
+	if err := json.Unmarshal(builtinConfig, &cfg); err != nil {
+		panic("tldwhois: invalid servers.json: " + err.Error())
+	}
+	return cfg
+}
+
+// Load 读取覆盖文件（格式同 Config），按字段覆盖内置配置中的 default 和同名后缀，
+// 新的后缀直接追加；path 为空时只使用内置配置
+func Load(path string) (*Registry, error) {
+	cfg := Builtin()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var override Config
+		if err := json.Unmarshal(data, &override); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		cfg.Default = cfg.Default.merge(override.Default)
+		for tld, s := range override.TLDs {
+			tld = strings.ToLower(strings.Trim(tld, "."))
+			cfg.TLDs[tld] = cfg.TLDs[tld].merge(s)
+		}
+	}
+	return New(cfg)
+}
+
+var (
+	defaultRegistry *Registry
+	defaultOnce     sync.Once
+	defaultMu       sync.RWMutex
+)
+
+// Default 默认配置，配置了 WHOIS_SERVERS 时用该文件覆盖内置配置
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		r, err := Load(os.Getenv("WHOIS_SERVERS"))
+		if err != nil {
+			fmt.Printf("WHOIS servers unavailable, using built-in configuration: %v\n", err)
+			r, _ = Load("")
+		}
+		defaultMu.Lock()
+		if defaultRegistry == nil {
+			defaultRegistry = r
+		}
+		defaultMu.Unlock()
+	})
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultRegistry
+}
+
+// SetDefault 替换默认配置，仅应在启动时、处理请求之前调用
+func SetDefault(r *Registry) {
+	defaultMu.Lock()
+	defaultRegistry = r
+	defaultMu.Unlock()
+}
+
+// TLDs 配置了规则的后缀
+func (r *Registry) TLDs() []string {
+	tlds := make([]string, 0, len(r.rules))
+	for tld := range r.rules {
+		tlds = append(tlds, tld)
+	}
+	sort.Strings(tlds)
+	return tlds
+}
+
+// For 域名适用的规则：先找完整后缀（com.cn），再找顶级后缀（cn），都没有时使用 default
+func (r *Registry) For(domain string) *Rule {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	i := strings.IndexByte(domain, '.')
+	if i < 0 {
+		return r.fallback
+	}
+	for t := domain[i+1:]; t != ""; {
+		if rule, ok := r.rules[t]; ok {
+			return rule
+		}
+		j := strings.IndexByte(t, '.')
+		if j < 0 {
+			break
+		}
+		t = t[j+1:]
+	}
+	return r.fallback
+}
+
+// noReferral 不跟随转介的客户端
+var noReferral = whois.NewClient().SetDisableReferral(true)
+
+// Query 按规则查询 WHOIS，返回解码为 UTF-8 的回答
+//
+// 配置了频率上限时先等待配额，等待会超过 ctx 的期限时返回 ErrRateLimited。
+// 没有配置服务器时由 likexian/whois 向 IANA 查找；注册商的转介（如 .com 的
+// Registrar WHOIS Server）会继续查询并附在后面。自定义的查询格式（如 DENIC 的
+// -T dn,ace）只有该后缀的服务器认识，likexian/whois 转介时会原样转发，所以这类
+// 规则不跟随转介。
+func (r *Registry) Query(ctx context.Context, domain string) (string, error) {
+	rule := r.For(domain)
+	if rule.limiter != nil {
+		if err := rule.limiter.wait(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	var raw string
+	var err error
+	switch {
+	case rule.Server == "":
+		raw, err = whois.Whois(domain)
+	case rule.Query == "%s":
+		raw, err = whois.Whois(domain, rule.Server)
+	default:
+		raw, err = noReferral.Whois(strings.ReplaceAll(rule.Query, "%s", domain), rule.Server)
+	}
+	if err != nil {
+		return "", err
+	}
+	return rule.Decode([]byte(raw)), nil
+}
+
+// Decode 按规则的字符集把回答转换为 UTF-8，转换失败时原样返回
+func (rule *Rule) Decode(raw []byte) string {
+	if rule.charset == nil {
+		return string(raw)
+	}
+	decoded, err := rule.charset.NewDecoder().Bytes(raw)
+	if err != nil {
+		return string(raw)
+	}
+	return string(bytes.ToValidUTF8(decoded, []byte("�")))
+}
+
+// Classify 根据回答判断：taken / available / unknown，并返回匹配到的文字
+//
+// 依次检查限流、可注册、已注册的规则；限流页面里的 "not found" 等字样不能当作未注册。
+func (rule *Rule) Classify(text string) (string, string) {
+	if strings.TrimSpace(text) == "" {
+		return evidence.Unknown, "empty response"
+	}
+	if m := match(rule.limited, text); m != "" {
+		return evidence.Unknown, "rate limited: " + m
+	}
+	if m := match(rule.available, text); m != "" {
+		return evidence.Available, m
+	}
+	if m := match(rule.registered, text); m != "" {
+		return evidence.Taken, m
+	}
+	return evidence.Unknown, "no known indicators"
+}
+
+// Classify 按域名后缀的规则判断回答
+func (r *Registry) Classify(domain, text string) (string, string) {
+	return r.For(domain).Classify(text)
+}
+
+// match 返回第一个命中的正则匹配到的文字（小写、合并空白、去掉结尾的冒号）
+func match(patterns []*regexp.Regexp, text string) string {
+	for _, re := range patterns {
+		if m := re.FindString(text); m != "" {
+			m = strings.Join(strings.Fields(strings.ToLower(m)), " ")
+			if m = strings.TrimSuffix(m, ":"); m == "" {
+				m = re.String()
+			}
+			return m
+		}
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+package tldwhois
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/korean"
+)
+
+// TestCorpus 用 testdata/<后缀>/ 下录制的回答验证各后缀的判断规则
+//
+// 文件名以期望的结论开头（available、taken、unknown）。设置 WHOIS_SERVERS 时
+// 连同覆盖文件一起验证。
+func TestCorpus(t *testing.T) {
+	registry, err := Load(os.Getenv("WHOIS_SERVERS"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	generic, err := New(Config{Default: Builtin().Default})
+	if err != nil {
+		t.Fatalf("generic: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join("testdata", "*", "*.txt"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no corpus files: %v", err)
+	}
+	improved := 0
+	for _, path := range files {
+		suffix := filepath.Base(filepath.Dir(path))
+		name := strings.TrimSuffix(filepath.Base(path), ".txt")
+		t.Run(suffix+"/"+name, func(t *testing.T) {
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _, _ := strings.Cut(name, "-")
+			domain := "example." + suffix
+
+			rule := registry.For(domain)
+			got, detail := rule.Classify(rule.Decode(raw))
+			if got != want {
+				t.Errorf("classified %s (%s), want %s", got, detail, want)
+			}
+			if before, _ := generic.Classify(domain, string(raw)); before != got && got == want {
+				improved++
+			}
+		})
+	}
+	t.Logf("%d recorded response(s) misjudged by the generic rules alone", improved)
+}
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "whois.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadOverride(t *testing.T) {
+	// 按字段合并，新后缀直接追加
+	merged, err := Load(writeConfig(t, `{
+  "tlds": {
+    "de": {"rate_limit": "10/m"},
+    "example": {"server": "whois.nic.example", "available": ["^free$"]}
+  }
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	de, ex := merged.For("shop.de"), merged.For("shop.example")
+	if de.Server != "whois.denic.de" || !strings.HasPrefix(de.Query, "-T dn,ace") {
+		t.Errorf("de = %s %q, want the builtin server and query kept", de.Server, de.Query)
+	}
+	free, _ := ex.Classify("free")
+	taken, _ := ex.Classify("Registrar: Example Registrar")
+	if ex.Server != "whois.nic.example" || free != "available" || taken != "taken" {
+		t.Errorf("example = %s, free=%s taken=%s", ex.Server, free, taken)
+	}
+
+	for _, bad := range []string{
+		`{"tlds": {"de": {"available": ["(unclosed"]}}}`,
+		`{"tlds": {"de": {"charset": "no-such-charset"}}}`,
+		`{"tlds": {"de": {"rate_limit": "fast"}}}`,
+		`{"tlds": {"de": {"query": "-T dn"}}}`,
+	} {
+		if _, err := Load(writeConfig(t, bad)); err == nil {
+			t.Errorf("Load accepted %s", bad)
+		}
+	}
+}
+
+// whoisStandIn 启动本地 WHOIS 服务器，对所有查询给出同一个回答，返回地址和收到的查询
+func whoisStandIn(t *testing.T, answer string) (string, func() []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	var mu sync.Mutex
+	var queries []string
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				q, _ := bufio.NewReader(conn).ReadString('\n')
+				mu.Lock()
+				queries = append(queries, strings.TrimSpace(q))
+				mu.Unlock()
+				fmt.Fprint(conn, answer)
+			}()
+		}
+	}()
+	return ln.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), queries...)
+	}
+}
+
+// TestQuery 通过本地 WHOIS 替身检查发送的查询、EUC-KR 解码和频率限制
+func TestQuery(t *testing.T) {
+	answer, _ := korean.EUCKR.NewEncoder().String("도메인이름 : 한국.test\nStatus: active\n")
+	addr, queries := whoisStandIn(t, answer)
+
+	local, err := New(Config{
+		Default: Builtin().Default,
+		TLDs: map[string]Server{"test": {
+			Server:     addr,
+			Query:      "-T dn %s",
+			Charset:    "euc-kr",
+			Registered: []string{`(?m)^도메인이름\s*:\s*\S+`},
+			RateLimit:  "2/s",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	text, err := local.Query(ctx, "shop.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	indicates, detail := local.Classify("shop.test", text)
+	if sent := strings.Join(queries(), "; "); sent != "-T dn shop.test" || indicates != "taken" || !strings.Contains(text, "한국.test") {
+		t.Errorf("sent %q, %s (%s), text %q", sent, indicates, detail, text)
+	}
+
+	// 桶容量为 2：第二次立即发出，第三次等待约 0.5 秒
+	start := time.Now()
+	local.Query(ctx, "shop.test")
+	local.Query(ctx, "shop.test")
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("rate limit waited %s, want about 500ms", elapsed)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := local.Query(short, "shop.test"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("query past the deadline = %v, want ErrRateLimited", err)
+	}
+}
+
+// TestCustomQueryNotReferred 自定义查询格式不能随转介发给注册商的服务器
+func TestCustomQueryNotReferred(t *testing.T) {
+	referral, referred := whoisStandIn(t, "Domain Name: SHOP.TEST\n")
+	registry, _ := whoisStandIn(t, "Domain: shop.test\nRegistrar WHOIS Server: "+referral+"\n")
+
+	local, err := New(Config{
+		Default: Builtin().Default,
+		TLDs:    map[string]Server{"test": {Server: registry, Query: "-T dn,ace %s"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := local.Query(context.Background(), "shop.test"); err != nil {
+		t.Fatal(err)
+	}
+	if got := referred(); len(got) != 0 {
+		t.Errorf("referral server received %q", got)
+	}
+}
+
+// TestDefaultRateLimit default 中的 rate_limit（覆盖文件可以设置）同样生效
+func TestDefaultRateLimit(t *testing.T) {
+	registry, err := Load(writeConfig(t, `{"default": {"rate_limit": "1/h"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := registry.For("example.unconfigured")
+	if rule.TLD != "" || rule.limiter == nil {
+		t.Fatalf("rule for an unconfigured suffix = %+v, want the rate-limited default", rule)
+	}
+	// 配额用完后在期限内等不到，直接返回 ErrRateLimited，不会发出查询
+	rule.limiter.wait(context.Background())
+	short, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := registry.Query(short, "example.unconfigured"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("query past the deadline = %v, want ErrRateLimited", err)
+	}
+}
@@ -0,0 +1,218 @@
+package trademark
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 数据来源
+const (
+	OfficeUSPTO = "USPTO"
+	OfficeEUIPO = "EUIPO"
+	OfficeCNIPA = "CNIPA"
+)
+
+// format 商标局导出文件的列名映射，每个字段按顺序匹配第一个存在的列
+type format struct {
+	office  string
+	name    []string
+	number  []string
+	owner   []string
+	classes []string
+	status  []string
+}
+
+// formats 支持的导出格式
+//
+//   - USPTO：Trademark Case Files Dataset 的 case_file.csv（可附加 owner_name、class_code 列）
+//   - EUIPO：开放数据的商标 CSV（分号分隔）
+//   - CNIPA：商标公告数据整理后的 CSV（中文列名）
+var formats = []format{
+	{
+		office:  OfficeUSPTO,
+		name:    []string{"mark_id_char"},
+		number:  []string{"registration_no", "serial_no"},
+		owner:   []string{"owner_name", "own_name"},
+		classes: []string{"class_code", "intl_class_cd"},
+		status:  []string{"status", "cfh_status_cd"},
+	},
+	{
+		office:  OfficeEUIPO,
+		name:    []string{"MarkVerbalElementText", "WordMarkSpecification"},
+		number:  []string{"ApplicationNumber"},
+		owner:   []string{"ApplicantName", "ApplicantIdentifier"},
+		classes: []string{"NiceClass", "ClassNumber"},
+		status:  []string{"MarkCurrentStatusCode", "MarkStatus"},
+	},
+	{
+		office:  OfficeCNIPA,
+		name:    []string{"商标名称", "商标"},
+		number:  []string{"注册号", "申请号"},
+		owner:   []string{"申请人名称", "申请人", "注册人"},
+		classes: []string{"国际分类", "类别"},
+		status:  []string{"商标状态", "当前状态", "状态"},
+	},
+}
+
+// deadStatuses 表示商标已失效的状态关键词，这类商标不参与筛查
+var deadStatuses = []string{"dead", "abandoned", "cancelled", "canceled", "expired", "withdrawn", "refused", "surrendered", "无效", "注销", "撤销", "驳回"}
+
+// usptoDeadCodes USPTO case_file.csv 中 cfh_status_cd 表示已失效的数字状态码范围（含两端）
+//
+// 600-629 为各类放弃（abandoned），710-714 为注册被撤销（Section 8/7/37/18/24），9xx 为过期。
+// 630-699 是审查、公告和异议中的申请，其余 7xx 是注册及续展，800 是已续展的注册，都仍有效。
+var usptoDeadCodes = [][2]int{
+	{600, 629},
+	{710, 714},
+	{900, 999},
+}
+
+// LoadFile 读取商标局导出的 CSV 文件，格式按表头自动识别
+func LoadFile(path string) ([]Mark, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	marks, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return marks, nil
+}
+
+// Load 读取 CSV 格式的商标数据，分隔符（逗号、分号、制表符）和格式按表头自动识别
+//
+// 已失效的商标和没有文字部分的商标（纯图形商标）会被跳过。
+func Load(r io.Reader) ([]Mark, error) {
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil && header == "" {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	header = strings.TrimPrefix(header, "\ufeff") // Excel 导出的 UTF-8 BOM
+
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(header), br))
+	reader.Comma = detectDelimiter(header)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	columns, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	index := map[string]int{}
+	for i, c := range columns {
+		index[strings.TrimSpace(c)] = i
+	}
+
+	f, ok := detectFormat(index)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized trademark export format (columns: %s)", strings.Join(columns, ", "))
+	}
+	col := func(names []string) int {
+		for _, n := range names {
+			if i, exists := index[n]; exists {
+				return i
+			}
+		}
+		return -1
+	}
+	nameCol, numberCol, ownerCol, classesCol, statusCol := col(f.name), col(f.number), col(f.owner), col(f.classes), col(f.status)
+
+	var marks []Mark
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		m := Mark{
+			Name:    field(nameCol),
+			Number:  field(numberCol),
+			Owner:   field(ownerCol),
+			Office:  f.office,
+			Classes: splitClasses(field(classesCol)),
+			Status:  field(statusCol),
+		}
+		if m.Name == "" || isDead(f.office, m.Status) {
+			continue
+		}
+		marks = append(marks, m)
+	}
+	return marks, nil
+}
+
+// detectDelimiter 按表头中出现最多的分隔符判断
+func detectDelimiter(header string) rune {
+	best, count := ',', strings.Count(header, ",")
+	for _, d := range []rune{';', '\t', '|'} {
+		if n := strings.Count(header, string(d)); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+// detectFormat 找到商标名称列存在的格式
+func detectFormat(columns map[string]int) (format, bool) {
+	for _, f := range formats {
+		for _, n := range f.name {
+			if _, ok := columns[n]; ok {
+				return f, true
+			}
+		}
+	}
+	return format{}, false
+}
+
+// splitClasses 拆分尼斯分类（“9,42”“009;042”“第9类”等写法）
+func splitClasses(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '、' || r == '|'
+	})
+	var classes []string
+	for _, f := range fields {
+		f = strings.TrimSuffix(strings.TrimPrefix(f, "第"), "类")
+		f = strings.TrimLeft(f, "0")
+		if f != "" {
+			classes = append(classes, f)
+		}
+	}
+	return classes
+}
+
+// isDead 状态是否表示商标已失效，USPTO 的数字状态码按 usptoDeadCodes 判断
+func isDead(office, status string) bool {
+	if code, err := strconv.Atoi(status); err == nil && office == OfficeUSPTO {
+		for _, r := range usptoDeadCodes {
+			if code >= r[0] && code <= r[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	s := strings.ToLower(status)
+	for _, d := range deadStatuses {
+		if strings.Contains(s, d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package trademark
+
+import "strings"
+
+// soundexCodes 字母对应的 Soundex 数字，元音和 h、w、y 为 0
+var soundexCodes = [26]byte{
+	'0', '1', '2', '3', '0', '1', '2', '0', '0', '2', '2', '4', '5',
+	'5', '0', '1', '2', '6', '2', '3', '0', '1', '0', '2', '0', '2',
+}
+
+// Soundex 美式 Soundex 编码（Robert → R163），只处理 a-z，其余字符被忽略
+func Soundex(word string) string {
+	letters := lettersOnly(word)
+	if letters == "" {
+		return ""
+	}
+
+	code := []byte{letters[0] - 'a' + 'A'}
+	last := soundexCodes[letters[0]-'a']
+	for i := 1; i < len(letters) && len(code) < 4; i++ {
+		c := letters[i]
+		d := soundexCodes[c-'a']
+		if d != '0' && d != last {
+			code = append(code, d)
+		}
+		// h、w 不打断相同编码的合并，元音会打断
+		if c != 'h' && c != 'w' {
+			last = d
+		}
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+// Metaphone 简化的 Metaphone 编码，按英文发音规则把拼写归并为辅音骨架
+//
+// 覆盖常见规则（ph → F、ck → K、sh → X、c 在 e/i/y 前读 S 等），足以发现
+// “kwik / quick”“fone / phone”这类读音相同的商标，不追求与原始算法逐字一致。
+func Metaphone(word string) string {
+	w := lettersOnly(word)
+	if w == "" {
+		return ""
+	}
+
+	// 词首特殊组合
+	for _, p := range [][2]string{{"kn", "n"}, {"gn", "n"}, {"pn", "n"}, {"ae", "e"}, {"wr", "r"}, {"x", "s"}, {"wh", "w"}} {
+		if strings.HasPrefix(w, p[0]) {
+			w = p[1] + w[len(p[0]):]
+			break
+		}
+	}
+
+	at := func(i int) byte {
+		if i < 0 || i >= len(w) {
+			return 0
+		}
+		return w[i]
+	}
+	isVowel := func(c byte) bool { return c != 0 && strings.IndexByte("aeiou", c) >= 0 }
+	frontVowel := func(c byte) bool { return c == 'e' || c == 'i' || c == 'y' }
+
+	var b strings.Builder
+	for i := 0; i < len(w); i++ {
+		c := w[i]
+		// 相同字母连写只算一次（c 除外，如 accept）
+		if c == at(i-1) && c != 'c' {
+			continue
+		}
+
+		switch c {
+		case 'a', 'e', 'i', 'o', 'u':
+			if i == 0 {
+				b.WriteByte(c - 'a' + 'A')
+			}
+		case 'b':
+			// 词尾的 mb 不发音
+			if !(at(i-1) == 'm' && i == len(w)-1) {
+				b.WriteByte('B')
+			}
+		case 'c':
+			switch {
+			case at(i+1) == 'i' && at(i+2) == 'a', at(i+1) == 'h':
+				b.WriteByte('X')
+				i++
+			case frontVowel(at(i + 1)):
+				if at(i-1) != 's' {
+					b.WriteByte('S')
+				}
+			case at(i+1) == 'k':
+				b.WriteByte('K')
+				i++
+			default:
+				b.WriteByte('K')
+			}
+		case 'd':
+			if at(i+1) == 'g' && frontVowel(at(i+2)) {
+				b.WriteByte('J')
+				i += 2
+			} else {
+				b.WriteByte('T')
+			}
+		case 'g':
+			switch {
+			case at(i+1) == 'h' && !isVowel(at(i+2)):
+				// night、light 中的 gh 不发音
+				i++
+			case at(i+1) == 'n' && (i+2 == len(w) || (at(i+2) == 'e' && at(i+3) == 'd' && i+4 == len(w))):
+				// sign、signed
+			case frontVowel(at(i + 1)):
+				b.WriteByte('J')
+			default:
+				b.WriteByte('K')
+			}
+		case 'h':
+			if isVowel(at(i+1)) && !strings.ContainsRune("csptg", rune(at(i-1))) {
+				b.WriteByte('H')
+			}
+		case 'k':
+			if at(i-1) != 'c' {
+				b.WriteByte('K')
+			}
+		case 'p':
+			if at(i+1) == 'h' {
+				b.WriteByte('F')
+				i++
+			} else {
+				b.WriteByte('P')
+			}
+		case 'q':
+			// qu 读作 kw，与 kwik 之类的拼法对齐
+			if at(i+1) == 'u' {
+				b.WriteString("KW")
+			} else {
+				b.WriteByte('K')
+			}
+		case 's':
+			switch {
+			case at(i+1) == 'h':
+				b.WriteByte('X')
+				i++
+			case at(i+1) == 'i' && (at(i+2) == 'o' || at(i+2) == 'a'):
+				b.WriteByte('X')
+			default:
+				b.WriteByte('S')
+			}
+		case 't':
+			switch {
+			case at(i+1) == 'i' && (at(i+2) == 'o' || at(i+2) == 'a'):
+				b.WriteByte('X')
+			case at(i+1) == 'h':
+				b.WriteByte('0')
+				i++
+			case at(i+1) == 'c' && at(i+2) == 'h':
+				// tch 只保留 ch
+			default:
+				b.WriteByte('T')
+			}
+		case 'v':
+			b.WriteByte('F')
+		case 'w', 'y':
+			if isVowel(at(i + 1)) {
+				b.WriteByte(c - 'a' + 'A')
+			}
+		case 'x':
+			b.WriteString("KS")
+		case 'z':
+			b.WriteByte('S')
+		default:
+			// f、j、l、m、n、r 原样保留
+			b.WriteByte(c - 'a' + 'A')
+		}
+	}
+	return b.String()
+}
+
+// lettersOnly 转小写并只保留 a-z
+func lettersOnly(word string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(word) {
+		if r >= 'a' && r <= 'z' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
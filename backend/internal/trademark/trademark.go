@@ -0,0 +1,303 @@
+// Package trademark 商标冲突筛查
+//
+// 从商标局（USPTO、EUIPO、CNIPA）导出的批量数据建立索引，对域名的名称部分做
+// 精确匹配、读音匹配（Soundex / Metaphone）和编辑距离匹配，给出冲突风险。
+// 中文商标按拼音参与匹配。筛查结果只是提示，不能代替正式的商标检索。
+package trademark
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"domain-agent/backend/internal/pinyin"
+	"domain-agent/backend/internal/types"
+)
+
+// 匹配方式
+const (
+	MatchExact    = "exact"    // 名称相同
+	MatchPhonetic = "phonetic" // 读音相同
+	MatchSimilar  = "similar"  // 拼写相近
+)
+
+// 冲突风险
+const (
+	RiskNone   = "none"
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+// maxMatches 每个名称最多返回的匹配商标数
+const maxMatches = 5
+
+// minNameLen 参与匹配的商标名称最短长度，过短的名称（如单字母）误报太多
+const minNameLen = 3
+
+// Mark 注册商标
+type Mark struct {
+	Name    string
+	Number  string
+	Owner   string
+	Office  string
+	Classes []string
+	Status  string
+}
+
+// Index 商标索引
+type Index struct {
+	marks     []Mark
+	keys      []string         // 每个商标规范化后的名称
+	exact     map[string][]int // 规范化名称 → 商标
+	soundex   map[string][]int
+	metaphone map[string][]int
+	byLen     map[int][]int // 名称长度 → 商标，用于编辑距离匹配
+}
+
+// NewIndex 为商标建立索引
+func NewIndex(marks []Mark) *Index {
+	idx := &Index{
+		exact:     map[string][]int{},
+		soundex:   map[string][]int{},
+		metaphone: map[string][]int{},
+		byLen:     map[int][]int{},
+	}
+	for _, m := range marks {
+		key := Normalize(m.Name)
+		if len(key) < minNameLen {
+			continue
+		}
+		i := len(idx.marks)
+		idx.marks = append(idx.marks, m)
+		idx.keys = append(idx.keys, key)
+		idx.exact[key] = append(idx.exact[key], i)
+		idx.soundex[Soundex(key)] = append(idx.soundex[Soundex(key)], i)
+		idx.metaphone[Metaphone(key)] = append(idx.metaphone[Metaphone(key)], i)
+		idx.byLen[len(key)] = append(idx.byLen[len(key)], i)
+	}
+	return idx
+}
+
+// Len 索引中的商标数
+func (idx *Index) Len() int {
+	return len(idx.marks)
+}
+
+// Normalize 把商标名称规范化为可与域名比较的形式：小写、只保留字母和数字，中文转为全拼
+func Normalize(name string) string {
+	if pinyin.HasHan(name) {
+		name = pinyin.Full(name)
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Screen 筛查名称（域名去掉后缀的部分）与已注册商标的冲突
+//
+// 精确匹配为高风险；Soundex 和 Metaphone 都相同为高风险，只有一个相同为中风险；
+// 编辑距离相似度不低于 0.8 为中风险，其余相近的为低风险。
+func (idx *Index) Screen(label string) (string, []types.TrademarkMatch) {
+	key := Normalize(label)
+	if len(key) < minNameLen {
+		return RiskNone, nil
+	}
+
+	type hit struct {
+		kind       string
+		risk       string
+		similarity float64
+	}
+	hits := map[int]hit{}
+	add := func(i int, kind, risk string, similarity float64) {
+		if h, exists := hits[i]; exists && riskLevel(h.risk) >= riskLevel(risk) {
+			return
+		}
+		hits[i] = hit{kind: kind, risk: risk, similarity: similarity}
+	}
+
+	for _, i := range idx.exact[key] {
+		add(i, MatchExact, RiskHigh, 1)
+	}
+
+	soundexHits := map[int]bool{}
+	for _, i := range idx.soundex[Soundex(key)] {
+		soundexHits[i] = true
+	}
+	for _, i := range idx.metaphone[Metaphone(key)] {
+		risk := RiskMedium
+		if soundexHits[i] {
+			risk = RiskHigh
+		}
+		add(i, MatchPhonetic, risk, similarity(key, idx.keys[i]))
+		delete(soundexHits, i)
+	}
+	for i := range soundexHits {
+		add(i, MatchPhonetic, RiskMedium, similarity(key, idx.keys[i]))
+	}
+
+	// 编辑距离：短名称允许差 1 个字符，长名称允许差 2 个
+	maxDist := 1
+	if len(key) > 6 {
+		maxDist = 2
+	}
+	for n := len(key) - maxDist; n <= len(key)+maxDist; n++ {
+		for _, i := range idx.byLen[n] {
+			d := levenshtein(key, idx.keys[i], maxDist)
+			if d == 0 || d > maxDist {
+				continue
+			}
+			s := similarity(key, idx.keys[i])
+			risk := RiskLow
+			if s >= 0.8 {
+				risk = RiskMedium
+			}
+			add(i, MatchSimilar, risk, s)
+		}
+	}
+
+	if len(hits) == 0 {
+		return RiskNone, nil
+	}
+
+	matches := make([]types.TrademarkMatch, 0, len(hits))
+	risk := RiskNone
+	for i, h := range hits {
+		m := idx.marks[i]
+		matches = append(matches, types.TrademarkMatch{
+			Mark:       m.Name,
+			Number:     m.Number,
+			Owner:      m.Owner,
+			Office:     m.Office,
+			Classes:    m.Classes,
+			Status:     m.Status,
+			MatchType:  h.kind,
+			Risk:       h.risk,
+			Similarity: h.similarity,
+		})
+		if riskLevel(h.risk) > riskLevel(risk) {
+			risk = h.risk
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if a, b := riskLevel(matches[i].Risk), riskLevel(matches[j].Risk); a != b {
+			return a > b
+		}
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return matches[i].Mark < matches[j].Mark
+	})
+	if len(matches) > maxMatches {
+		matches = matches[:maxMatches]
+	}
+	return risk, matches
+}
+
+// riskLevel 风险等级的大小，用于比较
+func riskLevel(risk string) int {
+	switch risk {
+	case RiskHigh:
+		return 3
+	case RiskMedium:
+		return 2
+	case RiskLow:
+		return 1
+	}
+	return 0
+}
+
+// similarity 基于编辑距离的相似度（0-1）
+func similarity(a, b string) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b, longest))/float64(longest)
+}
+
+// levenshtein 编辑距离，超过 limit 时提前返回 limit+1
+func levenshtein(a, b string, limit int) int {
+	if d := len(a) - len(b); d > limit || -d > limit {
+		return limit + 1
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+var (
+	defaultIndex *Index
+	defaultOnce  sync.Once
+	defaultMu    sync.RWMutex
+)
+
+// Default 默认索引，首次调用时从 TRADEMARK_DATA 加载
+//
+// TRADEMARK_DATA 为逗号分隔的 CSV 文件路径；未配置或全部加载失败时返回空索引。
+func Default() *Index {
+	defaultOnce.Do(func() {
+		idx := loadFromEnv(os.Getenv("TRADEMARK_DATA"))
+		defaultMu.Lock()
+		if defaultIndex == nil {
+			defaultIndex = idx
+		}
+		defaultMu.Unlock()
+	})
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultIndex
+}
+
+// SetDefault 替换默认索引，仅应在启动时、处理请求之前调用
+func SetDefault(idx *Index) {
+	defaultMu.Lock()
+	defaultIndex = idx
+	defaultMu.Unlock()
+}
+
+// loadFromEnv 加载逗号分隔的多个数据文件，单个文件失败时跳过
+func loadFromEnv(paths string) *Index {
+	var marks []Mark
+	for _, path := range strings.Split(paths, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		m, err := LoadFile(path)
+		if err != nil {
+			fmt.Printf("Trademark data unavailable: %v\n", err)
+			continue
+		}
+		fmt.Printf("Loaded %d trademarks from %s\n", len(m), path)
+		marks = append(marks, m...)
+	}
+	return NewIndex(marks)
+}
@@ -0,0 +1,155 @@
+package trademark
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadUSPTO(t *testing.T) {
+	csv := "serial_no,registration_no,mark_id_char,cfh_status_cd,owner_name,class_code\n" +
+		"1,1001,KITLEAF,700,Kitleaf Inc.,\"9,42\"\n" + // 已注册
+		"2,1002,SPROUTLY,800,Sproutly LLC,035\n" + // 已续展
+		"3,,MINTLEAF,630,Mint Co.,9\n" + // 审查中
+		"4,,BREWBOX,602,Brew Co.,30\n" + // 放弃
+		"5,1005,TEALEAF,710,Tea Co.,30\n" + // Section 8 撤销
+		"6,1006,ROASTR,900,Roast Co.,30\n" + // 过期
+		"7,1007,,700,Logo Only Co.,30\n" // 纯图形商标
+	marks, err := Load(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, m := range marks {
+		names = append(names, m.Name)
+	}
+	if want := []string{"KITLEAF", "SPROUTLY", "MINTLEAF"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("loaded %v, want %v", names, want)
+	}
+	want := Mark{Name: "KITLEAF", Number: "1001", Owner: "Kitleaf Inc.", Office: OfficeUSPTO, Classes: []string{"9", "42"}, Status: "700"}
+	if !reflect.DeepEqual(marks[0], want) {
+		t.Errorf("marks[0] = %+v, want %+v", marks[0], want)
+	}
+	if !reflect.DeepEqual(marks[1].Classes, []string{"35"}) {
+		t.Errorf("classes = %v, want [35]", marks[1].Classes)
+	}
+}
+
+func TestLoadFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		office string
+		want   []string
+	}{
+		{
+			"EUIPO",
+			"ApplicationNumber;MarkVerbalElementText;ApplicantName;NiceClass;MarkCurrentStatusCode\n" +
+				"018000001;Kitleaf;Kitleaf GmbH;9;Registered\n" +
+				"018000002;Brewbox;Brew GmbH;30;Expired\n" +
+				"018000003;Tealeaf;Tea GmbH;30;Surrendered\n",
+			OfficeEUIPO,
+			[]string{"Kitleaf"},
+		},
+		{
+			"CNIPA",
+			"\ufeff注册号\t商标名称\t申请人名称\t国际分类\t商标状态\n" +
+				"1001\t叶子茶\t叶子公司\t第30类\t已注册\n" +
+				"1002\t小米\t小米公司\t9\t注册商标无效\n",
+			OfficeCNIPA,
+			[]string{"叶子茶"},
+		},
+	}
+	for _, tt := range tests {
+		marks, err := Load(strings.NewReader(tt.data))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var names []string
+		for _, m := range marks {
+			names = append(names, m.Name)
+			if m.Office != tt.office {
+				t.Errorf("%s: office %q", tt.name, m.Office)
+			}
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("%s: loaded %v, want %v", tt.name, names, tt.want)
+		}
+	}
+
+	if _, err := Load(strings.NewReader("a,b,c\n1,2,3\n")); err == nil {
+		t.Error("Load(unknown format) succeeded")
+	}
+}
+
+func TestIsDead(t *testing.T) {
+	tests := []struct {
+		office, status string
+		want           bool
+	}{
+		{OfficeUSPTO, "600", true},
+		{OfficeUSPTO, "629", true},
+		{OfficeUSPTO, "630", false},
+		{OfficeUSPTO, "686", false},
+		{OfficeUSPTO, "700", false},
+		{OfficeUSPTO, "710", true},
+		{OfficeUSPTO, "714", true},
+		{OfficeUSPTO, "715", false},
+		{OfficeUSPTO, "730", false},
+		{OfficeUSPTO, "800", false},
+		{OfficeUSPTO, "900", true},
+		{OfficeUSPTO, "Abandoned", true},
+		{OfficeUSPTO, "", false},
+		{OfficeEUIPO, "Withdrawn", true},
+		{OfficeCNIPA, "已注册", false},
+		{OfficeCNIPA, "注销", true},
+	}
+	for _, tt := range tests {
+		if got := isDead(tt.office, tt.status); got != tt.want {
+			t.Errorf("isDead(%s, %q) = %v, want %v", tt.office, tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestScreen(t *testing.T) {
+	idx := NewIndex([]Mark{
+		{Name: "KITLEAF", Office: OfficeUSPTO},
+		{Name: "Sproutly", Office: OfficeEUIPO},
+		{Name: "阿里巴巴", Office: OfficeCNIPA},
+		{Name: "AB", Office: OfficeUSPTO}, // 过短，不参与匹配
+	})
+	if idx.Len() != 3 {
+		t.Fatalf("Len = %d, want 3", idx.Len())
+	}
+
+	tests := []struct {
+		label     string
+		risk      string
+		mark      string
+		matchType string
+	}{
+		{"kitleaf", RiskHigh, "KITLEAF", MatchExact},
+		{"Kit-Leaf", RiskHigh, "KITLEAF", MatchExact},
+		{"alibaba", RiskHigh, "阿里巴巴", MatchExact},
+		{"sproutlee", RiskHigh, "Sproutly", MatchPhonetic},
+		{"kitlead", RiskMedium, "KITLEAF", MatchSimilar},
+		{"ab", RiskNone, "", ""},
+		{"mintbrew", RiskNone, "", ""},
+	}
+	for _, tt := range tests {
+		risk, matches := idx.Screen(tt.label)
+		if risk != tt.risk {
+			t.Errorf("Screen(%q) risk = %s, want %s (%+v)", tt.label, risk, tt.risk, matches)
+			continue
+		}
+		if tt.mark == "" {
+			if len(matches) != 0 {
+				t.Errorf("Screen(%q) = %+v, want no matches", tt.label, matches)
+			}
+			continue
+		}
+		if len(matches) == 0 || matches[0].Mark != tt.mark || matches[0].MatchType != tt.matchType {
+			t.Errorf("Screen(%q) = %+v, want %s via %s", tt.label, matches, tt.mark, tt.matchType)
+		}
+	}
+}
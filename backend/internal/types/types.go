@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ChatRequest 对话请求
 type ChatRequest struct {
@@ -17,6 +20,10 @@ type ChatResponse struct {
 	Action    string                 `json:"action"`
 	Data      map[string]interface{} `json:"data"`
 	Timestamp time.Time              `json:"timestamp"`
+
+	Confidence   float64            `json:"confidence"`              // 意图置信度
+	IntentScores map[string]float64 `json:"intent_scores,omitempty"` // 本地分类器对各意图的打分
+	IntentSource string             `json:"intent_source"`           // rule / model / llm
 }
 
 // Session 会话信息
@@ -24,10 +31,27 @@ type Session struct {
 	ID        string                 `json:"id"`
 	Messages  []Message              `json:"messages"`
 	Context   map[string]interface{} `json:"context"`
+	State     SessionState           `json:"state"`
 	CreatedAt time.Time              `json:"created_at"`
 	UpdatedAt time.Time              `json:"updated_at"`
 }
 
+// SessionSummary 会话摘要，用于会话列表
+type SessionSummary struct {
+	ID           string    `json:"id"`
+	MessageCount int       `json:"message_count"`
+	LastMessage  string    `json:"last_message"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SessionState 会话级状态，用于多轮对话中解析“第三个”“这些”等指代
+type SessionState struct {
+	Keywords        []string `json:"keywords"`         // 当前关键词
+	PreferredTLDs   []string `json:"preferred_tlds"`   // 用户偏好的后缀
+	LastSuggestions []string `json:"last_suggestions"` // 最近一次给出的域名列表
+}
+
 // Message 消息
 type Message struct {
 	Role      string    `json:"role"` // user, assistant, system
@@ -38,6 +62,25 @@ type Message struct {
 // CheckDomainsRequest 检查域名请求
 type CheckDomainsRequest struct {
 	Domains []string `json:"domains" binding:"required"`
+
+	// Handles 是否同时检查域名名称对应的社交账号
+	Handles bool `json:"handles"`
+	// Platforms 检查的平台，为空时检查全部；见 GET /api/domains/platforms
+	Platforms []string `json:"platforms"`
+
+	// Ecosystems 是否同时检查域名名称在包仓库中是否已被使用
+	Ecosystems bool `json:"ecosystems"`
+	// Registries 检查的包仓库，为空时检查全部；见 GET /api/domains/registries
+	Registries []string `json:"registries"`
+
+	// CT 是否为已注册的域名查询证书透明度日志
+	CT bool `json:"ct"`
+	// Website 是否为已注册的域名探测首页，识别停放页和售卖页
+	Website bool `json:"website"`
+	// Aftermarket 是否为已注册的域名查询交易平台挂牌（会同时探测首页）
+	Aftermarket bool `json:"aftermarket"`
+	// Marketplaces 查询的交易平台，为空时查询全部；见 GET /api/domains/marketplaces
+	Marketplaces []string `json:"marketplaces"`
 }
 
 // DomainResult 域名检查结果
@@ -47,6 +90,260 @@ type DomainResult struct {
 	Signatures []string `json:"signatures"`
 	Score      float64  `json:"score"`
 	Price      string   `json:"price"`
+	// EstimatedValue 估算的市场价格区间，Score 只用于排序
+	EstimatedValue *Valuation `json:"estimated_value,omitempty"`
+	// Confidence 对 available 结论的置信度（0.5–1），由 Evidence 中各信号按权重合并得出
+	Confidence float64    `json:"confidence"`
+	Evidence   []Evidence `json:"evidence,omitempty"`
+	DNSSEC     string     `json:"dnssec,omitempty"` // secure / insecure / bogus / indeterminate，未开启验证时为空
+
+	// 后缀权威服务器对委派的回答，查询失败时为空
+	Delegation *DelegationEvidence `json:"delegation,omitempty"`
+	// 443 端口的证书，没有 TLS 服务时为空
+	Certificate *CertificateInfo `json:"certificate,omitempty"`
+	// 证书透明度日志中的记录汇总，仅在请求时为已注册的域名查询
+	CT *CTSummary `json:"ct,omitempty"`
+	// 首页的分类（正常网站、停放页、售卖页、跳转），仅在请求时为已注册的域名探测
+	Website *WebsiteInfo `json:"website,omitempty"`
+	// 二级市场的出售信息：查询了 WHOIS 时记录其中的出售信号，平台挂牌仅在请求时查询
+	Aftermarket *AftermarketInfo `json:"aftermarket,omitempty"`
+
+	// 商标筛查结果，未加载商标数据时为空
+	TrademarkRisk    string           `json:"trademark_risk,omitempty"` // none / low / medium / high
+	TrademarkMatches []TrademarkMatch `json:"trademark_matches,omitempty"`
+
+	// 同名社交账号和包名，仅在请求时检查
+	Handles []HandleResult `json:"handles,omitempty"`
+	// 同名的包仓库名称（npm、PyPI、crates.io、Go、Docker Hub），仅在请求时检查
+	Ecosystems []EcosystemResult `json:"ecosystems,omitempty"`
+}
+
+// Evidence 一项检查给出的证据
+type Evidence struct {
+	Signal    string  `json:"signal"`    // zone / delegation / rdap / dns_ns / dns_a / dns_mx / tls / whois
+	Indicates string  `json:"indicates"` // taken / available / unknown
+	Weight    float64 `json:"weight"`    // 对可注册对数几率的贡献，指向已注册时为负
+	Detail    string  `json:"detail,omitempty"`
+}
+
+// CertificateInfo 域名 443 端口返回的叶子证书
+type CertificateInfo struct {
+	Subject     string   `json:"subject"`
+	SANs        []string `json:"sans,omitempty"`
+	Issuer      string   `json:"issuer"`
+	NotBefore   string   `json:"not_before"`
+	NotAfter    string   `json:"not_after"`
+	Serial      string   `json:"serial"`
+	Matches     bool     `json:"matches"`                // 证书名称包含该域名
+	Trusted     bool     `json:"trusted"`                // 能链到受信任的根证书且在有效期内（不看名称，见 Matches）
+	VerifyError string   `json:"verify_error,omitempty"` // 验证失败的原因
+}
+
+// WebsiteInfo 域名首页的探测结果
+type WebsiteInfo struct {
+	Status     string   `json:"status"`                // active / parked / for_sale / redirect / unreachable
+	URL        string   `json:"url,omitempty"`         // 跳转后的最终地址
+	HTTPStatus int      `json:"http_status,omitempty"` // 最终页面的状态码
+	Title      string   `json:"title,omitempty"`
+	Redirects  []string `json:"redirects,omitempty"` // 依次经过的地址，含页面内的 meta refresh
+	Provider   string   `json:"provider,omitempty"`  // 识别出的停放或售卖服务商
+	Markers    []string `json:"markers,omitempty"`   // 命中的规则依据
+	Error      string   `json:"error,omitempty"`
+}
+
+// Valuation 域名的估价区间
+type Valuation struct {
+	Estimate    float64          `json:"estimate"` // 区间内最可能的价格
+	Low         float64          `json:"low"`
+	High        float64          `json:"high"`
+	Currency    string           `json:"currency"`    // 目前总是 USD
+	Explanation []string         `json:"explanation"` // 各项系数的说明，按计算顺序
+	Comparables []ComparableSale `json:"comparables,omitempty"`
+}
+
+// ComparableSale 参考的历史成交
+type ComparableSale struct {
+	Domain string  `json:"domain"`
+	Price  float64 `json:"price"`
+	Date   string  `json:"date,omitempty"`
+}
+
+// AftermarketInfo 已注册域名在二级市场的出售情况
+type AftermarketInfo struct {
+	ForSale  bool      `json:"for_sale"`           // 有平台挂牌，或有出售信号
+	Venue    string    `json:"venue,omitempty"`    // 标价最低的挂牌平台
+	Price    float64   `json:"price,omitempty"`    // 标价，0 表示未标价（议价）
+	Currency string    `json:"currency,omitempty"` // ISO 4217 货币代码
+	URL      string    `json:"url,omitempty"`      // 购买页面
+	Listings []Listing `json:"listings,omitempty"` // 各平台的查询结果
+	Signals  []string  `json:"signals,omitempty"`  // WHOIS 和首页中的出售信号
+}
+
+// Listing 一个交易平台的挂牌查询结果
+type Listing struct {
+	Venue     string  `json:"venue"`
+	Status    string  `json:"status"` // listed / not_listed / unknown
+	URL       string  `json:"url,omitempty"`
+	Price     float64 `json:"price,omitempty"`
+	Currency  string  `json:"currency,omitempty"`
+	MakeOffer bool    `json:"make_offer,omitempty"` // 挂牌但只接受报价
+	Error     string  `json:"error,omitempty"`
+}
+
+// CTSummary 证书透明度日志中域名及其子域名的证书汇总
+type CTSummary struct {
+	Certificates int      `json:"certificates"` // 去重后的证书数量
+	Active       int      `json:"active"`       // 尚未过期的证书
+	Last90Days   int      `json:"last_90_days"` // 最近 90 天签发的证书
+	LastYear     int      `json:"last_year"`
+	FirstSeen    string   `json:"first_seen,omitempty"` // 最早的证书生效时间
+	LastIssued   string   `json:"last_issued,omitempty"`
+	Issuers      []string `json:"issuers,omitempty"`   // 按证书数量排序
+	Names        []string `json:"names,omitempty"`     // 证书中出现的名称（子域名）
+	Truncated    bool     `json:"truncated,omitempty"` // 记录过多，只汇总了前一部分
+	Error        string   `json:"error,omitempty"`
+}
+
+// DelegationEvidence 后缀权威服务器（不经过递归解析器）对域名委派的回答
+type DelegationEvidence struct {
+	Zone        string   `json:"zone"`   // 被询问的区域，如 com
+	Server      string   `json:"server"` // 给出回答的权威服务器
+	Rcode       string   `json:"rcode"`  // NOERROR / NXDOMAIN
+	Referral    bool     `json:"referral"`
+	NameServers []string `json:"name_servers,omitempty"`
+}
+
+// EcosystemResult 包仓库名称的检查结果
+type EcosystemResult struct {
+	Registry  string `json:"registry"` // npm / pypi / crates / go / dockerhub
+	Name      string `json:"name"`
+	Status    string `json:"status"` // available / taken / invalid / unknown
+	Available bool   `json:"available"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HandleResult 社交账号的检查结果
+type HandleResult struct {
+	Platform  string `json:"platform"` // github / x / instagram
+	Handle    string `json:"handle"`
+	Status    string `json:"status"` // available / taken / invalid / unknown
+	Available bool   `json:"available"`
+	Error     string `json:"error,omitempty"`
+}
+
+// TrademarkMatch 与域名名称冲突的注册商标
+type TrademarkMatch struct {
+	Mark       string   `json:"mark"`
+	Number     string   `json:"number,omitempty"` // 注册号或申请号
+	Owner      string   `json:"owner,omitempty"`
+	Office     string   `json:"office"` // USPTO / EUIPO / CNIPA
+	Classes    []string `json:"classes,omitempty"`
+	Status     string   `json:"status,omitempty"`
+	MatchType  string   `json:"match_type"` // exact / phonetic / similar
+	Risk       string   `json:"risk"`
+	Similarity float64  `json:"similarity"`
+}
+
+// Registration WHOIS 注册信息
+type Registration struct {
+	Domain      string   `json:"domain"`
+	Registered  bool     `json:"registered"`
+	Registrar   string   `json:"registrar,omitempty"`
+	Registrant  string   `json:"registrant,omitempty"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
+	ExpiresAt   string   `json:"expires_at,omitempty"`
+	NameServers []string `json:"name_servers,omitempty"`
+	Status      []string `json:"status,omitempty"`
+}
+
+// RegistrationSnapshot 某一时刻规范化后的注册信息
+//
+// 同一来源内容不变时只更新 LastSeenAt，内容变化时记为新快照。日期规范化为
+// YYYY-MM-DD，名称服务器和状态小写并排序，避免格式差异被当作变化。
+type RegistrationSnapshot struct {
+	Domain      string    `json:"domain"`
+	Source      string    `json:"source"` // whois / rdap / delegation
+	Registered  bool      `json:"registered"`
+	Registrar   string    `json:"registrar,omitempty"`
+	Registrant  string    `json:"registrant,omitempty"`
+	CreatedAt   string    `json:"created_at,omitempty"`
+	ExpiresAt   string    `json:"expires_at,omitempty"`
+	NameServers []string  `json:"name_servers,omitempty"`
+	Status      []string  `json:"status,omitempty"`
+	CapturedAt  time.Time `json:"captured_at"`  // 首次看到这份内容的时间
+	LastSeenAt  time.Time `json:"last_seen_at"` // 最近一次看到这份内容的时间
+}
+
+// RegistrationChange 相邻两份快照之间的一项变化
+type RegistrationChange struct {
+	At     time.Time `json:"at"`
+	Source string    `json:"source"`
+	Kind   string    `json:"kind"` // registered / released / registrar_changed / registrant_changed / nameservers_changed / expiry_extended / expiry_changed / status_changed
+	From   string    `json:"from,omitempty"`
+	To     string    `json:"to,omitempty"`
+}
+
+// WHOISLookup WHOIS 的原始回答，以及检查域名时对它的判断和解析结果
+type WHOISLookup struct {
+	Domain       string        `json:"domain"`
+	Rule         string        `json:"rule"`             // 适用的后缀配置，没有时为 default
+	Server       string        `json:"server,omitempty"` // 配置的服务器，为空表示向 IANA 查找
+	Raw          string        `json:"raw"`              // 已按配置的字符集转换为 UTF-8
+	Evidence     Evidence      `json:"evidence"`
+	Registration *Registration `json:"registration,omitempty"` // 查询失败时为空
+	// 出售字样、域名投资商持有者等挂牌信号
+	AftermarketSignals []string  `json:"aftermarket_signals,omitempty"`
+	FetchedAt          time.Time `json:"fetched_at"`
+	Cached             bool      `json:"cached"`
+}
+
+// RDAPLookup RDAP 的原始响应，以及检查域名时对它的判断和解析结果
+type RDAPLookup struct {
+	Domain       string          `json:"domain"`
+	Server       string          `json:"server,omitempty"` // 查询的 RDAP 服务地址
+	Raw          json.RawMessage `json:"raw,omitempty"`    // 未注册或查询失败时为空
+	Evidence     Evidence        `json:"evidence"`
+	Registration *Registration   `json:"registration,omitempty"`
+	FetchedAt    time.Time       `json:"fetched_at"`
+	Cached       bool            `json:"cached"`
+}
+
+// DNSLookup 后缀服务器的委派回答和上游解析器的记录，以及检查域名时得到的证据
+type DNSLookup struct {
+	Domain     string              `json:"domain"`
+	Delegation *DelegationEvidence `json:"delegation,omitempty"` // 后缀服务器不可达时为空
+	Answers    []DNSAnswer         `json:"answers"`
+	DNSSEC     string              `json:"dnssec,omitempty"` // NS 记录的验证状态，未开启验证时为空
+	Evidence   []Evidence          `json:"evidence"`
+	FetchedAt  time.Time           `json:"fetched_at"`
+	Cached     bool                `json:"cached"`
+}
+
+// DNSAnswer 一种记录类型的查询结果
+type DNSAnswer struct {
+	Type    string   `json:"type"`              // NS / A / AAAA / MX
+	Rcode   string   `json:"rcode,omitempty"`   // NOERROR / NXDOMAIN
+	Records []string `json:"records,omitempty"` // 区域文件格式的记录
+	DNSSEC  string   `json:"dnssec,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// AlternativesRequest 替代域名请求
+type AlternativesRequest struct {
+	Domain string   `json:"domain" binding:"required"`
+	TLDs   []string `json:"tlds"`
+	Count  int      `json:"count" binding:"min=0,max=50"` // 0 为默认的 10 个
+}
+
+// AlternativeDomain 替代域名
+type AlternativeDomain struct {
+	Domain     string  `json:"domain"`
+	Variant    string  `json:"variant"`    // tld_swap / prefix / suffix / plural / hyphen / synonym
+	Similarity float64 `json:"similarity"` // 与原域名的相似度（0-1）
+	Score      float64 `json:"score"`
+	Rank       float64 `json:"rank"` // 相似度与评分的综合排序分
+	Available  bool    `json:"available"`
 }
 
 // SuggestDomainsRequest 域名建议请求
@@ -55,14 +352,32 @@ type SuggestDomainsRequest struct {
 	TLDs     []string `json:"tlds"`
 	MaxLen   int      `json:"max_len"`
 	MinLen   int      `json:"min_len"`
-	Count    int      `json:"count"`
+	Count    int      `json:"count" binding:"min=0,max=100"` // 0 为默认的 20 个
+
+	// Strategies 使用的生成策略，为空时使用全部；见 GET /api/domains/strategies
+	Strategies []string `json:"strategies"`
+
+	// Expand 是否用近义词扩展关键词；中文关键词总会被翻译为英文和拼音
+	Expand bool `json:"expand"`
+
+	// OnlyAvailable 是否检查可用性并只返回可注册的域名
+	OnlyAvailable bool `json:"only_available"`
 }
 
 // DomainSuggestion 域名建议
 type DomainSuggestion struct {
 	Domain       string  `json:"domain"`
 	Score        float64 `json:"score"`
+	Rank         float64 `json:"rank"` // 评分与易记程度的综合排序分
 	Reason       string  `json:"reason"`
 	Length       int     `json:"length"`
 	Memorability float64 `json:"memorability"`
+	Strategy     string  `json:"strategy"`
+	Available    bool    `json:"available,omitempty"` // 仅 only_available 时检查
+	// 估算的市场价格区间
+	EstimatedValue *Valuation `json:"estimated_value,omitempty"`
+
+	// 商标筛查结果，未加载商标数据时为空
+	TrademarkRisk    string           `json:"trademark_risk,omitempty"`
+	TrademarkMatches []TrademarkMatch `json:"trademark_matches,omitempty"`
 }
@@ -0,0 +1,159 @@
+package valuation
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Sale 一条历史成交记录
+type Sale struct {
+	Domain string
+	Label  string // 名称部分
+	TLD    string // 后缀，带点
+	Price  float64
+	Date   string
+	Venue  string
+}
+
+// 成交记录 CSV 的列名，按顺序匹配第一个存在的列（NameBio、DNJournal 导出及自整理的表格）
+var (
+	domainColumns = []string{"domain", "Domain", "name", "Name"}
+	priceColumns  = []string{"price", "Price", "price_usd", "Price (USD)"}
+	dateColumns   = []string{"date", "Date", "sale_date"}
+	venueColumns  = []string{"venue", "Venue", "marketplace"}
+)
+
+// LoadSalesFile 读取历史成交记录 CSV，至少需要域名和价格两列，价格按美元计
+func LoadSalesFile(path string) ([]Sale, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sales, err := LoadSales(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sales, nil
+}
+
+// LoadSales 读取 CSV 格式的成交记录，价格可以带 $ 和千位分隔符；无法解析的行被跳过
+func LoadSales(r io.Reader) ([]Sale, error) {
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil && header == "" {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	header = strings.TrimPrefix(header, "\ufeff") // Excel 导出的 UTF-8 BOM
+
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(header), br))
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	columns, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	index := map[string]int{}
+	for i, c := range columns {
+		index[strings.TrimSpace(c)] = i
+	}
+	col := func(names []string) int {
+		for _, n := range names {
+			if i, exists := index[n]; exists {
+				return i
+			}
+		}
+		return -1
+	}
+	domainCol, priceCol, dateCol, venueCol := col(domainColumns), col(priceColumns), col(dateColumns), col(venueColumns)
+	if domainCol < 0 || priceCol < 0 {
+		return nil, fmt.Errorf("missing domain or price column (columns: %s)", strings.Join(columns, ", "))
+	}
+
+	var sales []Sale
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		domain := strings.ToLower(field(domainCol))
+		price := parsePrice(field(priceCol))
+		dot := strings.Index(domain, ".")
+		if dot <= 0 || price <= 0 {
+			continue
+		}
+		sales = append(sales, Sale{
+			Domain: domain,
+			Label:  domain[:dot],
+			TLD:    domain[dot:],
+			Price:  price,
+			Date:   field(dateCol),
+			Venue:  field(venueCol),
+		})
+	}
+	return sales, nil
+}
+
+// parsePrice 解析 "$2,400"、"2400 USD" 这样的价格
+func parsePrice(s string) float64 {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "USD"))
+	s = strings.NewReplacer("$", "", ",", "", " ", "").Replace(s)
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// LoadKeywordsFile 读取关键词价值表（词<TAB>倍数，# 开头为注释）
+func LoadKeywordsFile(path string) (map[string]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keywords, err := parseKeywords(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return keywords, nil
+}
+
+func parseKeywords(data string) (map[string]float64, error) {
+	keywords := map[string]float64{}
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: want word and multiplier", n+1)
+		}
+		v, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("line %d: invalid multiplier %q", n+1, fields[1])
+		}
+		keywords[strings.ToLower(fields[0])] = v
+	}
+	return keywords, nil
+}
@@ -0,0 +1,443 @@
+// Package valuation 估算域名的市场价格区间
+//
+// DomainResult.Score 只是长度和数字的启发式评分，没有金额含义。估价按名称长度给出
+// 基准价，再乘以词典词、后缀、关键词商业价值和可读性的系数，最后与历史成交记录中
+// 的可比成交混合，给出美元价格区间和每一步的说明。结果只用于比较和决策参考，
+// 不是正式的评估。
+package valuation
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"domain-agent/backend/internal/lexicon"
+	"domain-agent/backend/internal/pinyin"
+	"domain-agent/backend/internal/types"
+)
+
+//go:embed keywords.txt
+var keywordsData string
+
+// DefaultKeywords 内置的关键词价值表
+var DefaultKeywords = func() map[string]float64 {
+	keywords, err := parseKeywords(keywordsData)
+	if err != nil {
+		panic("valuation: " + err.Error())
+	}
+	return keywords
+}()
+
+// DefaultTLDWeights 后缀相对 .com 的价格系数，未列出的后缀按 otherTLDWeight 计算
+var DefaultTLDWeights = map[string]float64{
+	".com":    1,
+	".ai":     0.6,
+	".io":     0.45,
+	".net":    0.3,
+	".org":    0.3,
+	".co":     0.3,
+	".cn":     0.3,
+	".com.cn": 0.2,
+	".app":    0.25,
+	".dev":    0.2,
+	".me":     0.15,
+	".tech":   0.12,
+	".xyz":    0.08,
+}
+
+const (
+	otherTLDWeight = 0.1
+	minValue       = 10    // 估价下限，约等于注册费
+	maxComparables = 3     // 说明中列出的可比成交数
+	maxCompWeight  = 0.6   // 可比成交在最终估价中的最大权重
+	rangeLow       = 0.6   // 区间下沿相对估价的比例
+	rangeHigh      = 1.7   // 区间上沿相对估价的比例
+	currency       = "USD" // 成交记录和估价都按美元计
+)
+
+// Estimator 估价器
+type Estimator struct {
+	Keywords   map[string]float64
+	TLDWeights map[string]float64
+	Sales      []Sale
+}
+
+// New 创建估价器，使用内置的关键词价值表和后缀系数
+func New(sales []Sale) *Estimator {
+	return &Estimator{Keywords: DefaultKeywords, TLDWeights: DefaultTLDWeights, Sales: sales}
+}
+
+var (
+	defaultEstimator *Estimator
+	defaultOnce      sync.Once
+	defaultMu        sync.RWMutex
+)
+
+// Default 默认估价器，首次调用时从 VALUATION_SALES 加载成交记录
+//
+// VALUATION_SALES 为逗号分隔的 CSV 文件路径；VALUATION_KEYWORDS 为追加的关键词价值表，
+// 同名词覆盖内置的倍数。加载失败时只打印提示，估价不使用可比成交。
+func Default() *Estimator {
+	defaultOnce.Do(func() {
+		var sales []Sale
+		for _, path := range strings.Split(os.Getenv("VALUATION_SALES"), ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			s, err := LoadSalesFile(path)
+			if err != nil {
+				fmt.Printf("Valuation sales unavailable: %v\n", err)
+				continue
+			}
+			fmt.Printf("Loaded %d domain sales from %s\n", len(s), path)
+			sales = append(sales, s...)
+		}
+		e := New(sales)
+		if path := os.Getenv("VALUATION_KEYWORDS"); path != "" {
+			if extra, err := LoadKeywordsFile(path); err != nil {
+				fmt.Printf("Valuation keywords unavailable, using defaults: %v\n", err)
+			} else {
+				e.Keywords = mergeKeywords(DefaultKeywords, extra)
+			}
+		}
+
+		defaultMu.Lock()
+		if defaultEstimator == nil {
+			defaultEstimator = e
+		}
+		defaultMu.Unlock()
+	})
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultEstimator
+}
+
+// SetDefault 替换默认估价器，仅应在启动时、处理请求之前调用
+func SetDefault(e *Estimator) {
+	defaultMu.Lock()
+	defaultEstimator = e
+	defaultMu.Unlock()
+}
+
+func mergeKeywords(base, extra map[string]float64) map[string]float64 {
+	merged := make(map[string]float64, len(base)+len(extra))
+	for w, v := range base {
+		merged[w] = v
+	}
+	for w, v := range extra {
+		merged[w] = v
+	}
+	return merged
+}
+
+// Estimate 估算域名的价格区间
+func (e *Estimator) Estimate(domain string) *types.Valuation {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	label, tld := domain, ".com"
+	if i := strings.Index(domain, "."); i >= 0 {
+		label, tld = domain[:i], domain[i:]
+	}
+
+	v := &types.Valuation{Currency: currency}
+	explain := func(format string, args ...any) {
+		v.Explanation = append(v.Explanation, fmt.Sprintf(format, args...))
+	}
+
+	value := baseValue(len(label))
+	explain("%d 个字符的名称基准价 %s", len(label), dollars(value))
+
+	words := e.segment(label)
+	switch {
+	case len(words) == 1:
+		value *= 3
+		explain("是完整的词典词 %s（×3）", words[0])
+	case len(words) == 2:
+		value *= 1.5
+		explain("由两个词组成：%s（×1.5）", strings.Join(words, " + "))
+	case len(words) == 3:
+		value *= 1.1
+		explain("由三个词组成：%s（×1.1）", strings.Join(words, " + "))
+	}
+
+	if m, word := e.keywordMultiplier(label, words); m > 1 {
+		value *= m
+		explain("包含高价值关键词 %s（×%.1f）", word, m)
+	}
+
+	if strings.Contains(label, "-") {
+		value *= 0.4
+		explain("含连字符（×0.4）")
+	}
+	if hasDigit(label) && !allDigits(label) {
+		value *= 0.5
+		explain("字母和数字混合（×0.5）")
+	}
+
+	if len(words) == 0 {
+		p := Pronounceability(label)
+		value *= 0.5 + p
+		explain("可读性 %.0f%%（×%.2f）", p*100, 0.5+p)
+	}
+
+	weight, ok := e.TLDWeights[tld]
+	if !ok {
+		weight = otherTLDWeight
+	}
+	value *= weight
+	if weight != 1 {
+		explain("后缀 %s 相对 .com 的系数 ×%.2f", tld, weight)
+	}
+
+	if comps := e.comparables(label, tld, words); len(comps) > 0 {
+		prices := make([]float64, len(comps))
+		for i, c := range comps {
+			prices[i] = c.adjusted
+		}
+		median := medianOf(prices)
+		w := math.Min(maxCompWeight, 0.15*float64(len(comps)))
+		// 几何加权：价格跨数量级，按对数混合
+		value = math.Exp((1-w)*math.Log(value) + w*math.Log(median))
+		explain("%d 笔可比成交（按长度和后缀折算）中位数 %s，占 %.0f%% 权重", len(comps), dollars(median), w*100)
+		for i, c := range comps {
+			if i >= maxComparables {
+				break
+			}
+			v.Comparables = append(v.Comparables, types.ComparableSale{Domain: c.Domain, Price: c.Price, Date: c.Date})
+		}
+	}
+
+	value = math.Max(value, minValue)
+	v.Estimate = round(value)
+	v.Low, v.High = round(math.Max(value*rangeLow, minValue)), round(value*rangeHigh)
+	return v
+}
+
+// baseValue 按名称长度的 .com 基准价：越短越稀缺，8 个字符之后每多一个字符降 20%
+func baseValue(length int) float64 {
+	switch {
+	case length <= 2:
+		return 50000
+	case length == 3:
+		return 15000
+	case length == 4:
+		return 4000
+	case length == 5:
+		return 1500
+	case length == 6:
+		return 800
+	case length == 7:
+		return 500
+	}
+	return math.Max(350*math.Pow(0.8, float64(length-8)), 50)
+}
+
+// segment 把名称切分为最多三个已知词，无法完整切分时返回空
+//
+// 已知词来自近义词表和关键词价值表，单个字母不算词。优先选择词数最少的切分。
+func (e *Estimator) segment(label string) []string {
+	if e.isWord(label) {
+		return []string{label}
+	}
+	best := []string(nil)
+	for i := 2; i <= len(label)-2; i++ {
+		left := label[:i]
+		if !e.isWord(left) {
+			continue
+		}
+		right := label[i:]
+		if e.isWord(right) {
+			return []string{left, right}
+		}
+		if best != nil {
+			continue
+		}
+		for j := 2; j <= len(right)-2; j++ {
+			if e.isWord(right[:j]) && e.isWord(right[j:]) {
+				best = []string{left, right[:j], right[j:]}
+				break
+			}
+		}
+	}
+	return best
+}
+
+func (e *Estimator) isWord(w string) bool {
+	if len(w) < 2 {
+		return false
+	}
+	if _, ok := e.Keywords[w]; ok {
+		return true
+	}
+	return lexicon.IsWord(w)
+}
+
+// keywordMultiplier 名称中价值最高的关键词及其倍数；名称没有完整切分时也接受包含的关键词，
+// 但只取倍数的一半溢价
+func (e *Estimator) keywordMultiplier(label string, words []string) (float64, string) {
+	best, bestWord := 1.0, ""
+	for _, w := range words {
+		if m := e.Keywords[w]; m > best {
+			best, bestWord = m, w
+		}
+	}
+	if bestWord != "" {
+		return best, bestWord
+	}
+	for w, m := range e.Keywords {
+		if len(w) >= 4 && strings.Contains(label, w) {
+			if discounted := 1 + (m-1)/2; discounted > best || (discounted == best && w < bestWord) {
+				best, bestWord = discounted, w
+			}
+		}
+	}
+	return best, bestWord
+}
+
+// compSale 一笔可比成交及按长度、后缀折算到目标域名的价格
+type compSale struct {
+	Sale
+	adjusted float64
+}
+
+// comparables 找出可比成交：同名不同后缀、包含相同的词，或同后缀且长度相同的纯字母名称
+//
+// 价格按长度基准价和后缀系数的比例折算，按相关程度排序。
+func (e *Estimator) comparables(label, tld string, words []string) []compSale {
+	type scored struct {
+		compSale
+		relevance int
+	}
+	var found []scored
+	for _, s := range e.Sales {
+		if s.Label == label && s.TLD == tld {
+			continue // 同一个域名的成交不算可比，避免自证
+		}
+		relevance := 0
+		switch {
+		case s.Label == label:
+			relevance = 3
+		case sharesWord(s.Label, words):
+			relevance = 2
+		case s.TLD == tld && len(s.Label) == len(label) && isLetters(s.Label) == isLetters(label):
+			relevance = 1
+		default:
+			continue
+		}
+		adjusted := s.Price * baseValue(len(label)) / baseValue(len(s.Label)) * e.tldWeight(tld) / e.tldWeight(s.TLD)
+		found = append(found, scored{compSale{s, adjusted}, relevance})
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].relevance != found[j].relevance {
+			return found[i].relevance > found[j].relevance
+		}
+		return found[i].Date > found[j].Date
+	})
+	comps := make([]compSale, len(found))
+	for i, f := range found {
+		comps[i] = f.compSale
+	}
+	return comps
+}
+
+func (e *Estimator) tldWeight(tld string) float64 {
+	if w, ok := e.TLDWeights[tld]; ok {
+		return w
+	}
+	return otherTLDWeight
+}
+
+func sharesWord(label string, words []string) bool {
+	for _, w := range words {
+		if len(w) >= 3 && strings.Contains(label, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pronounceability 名称的可读性（0-1）：能切分为拼音的按拼音自然度，否则按辅音、元音的连续长度扣分
+func Pronounceability(label string) float64 {
+	label = strings.ToLower(label)
+	if n := pinyin.Naturalness(label); n > 0 {
+		return n
+	}
+	letters := 0
+	penalty := 0.0
+	consonants, vowels := 0, 0
+	for _, r := range label {
+		if r < 'a' || r > 'z' {
+			consonants, vowels = 0, 0
+			continue
+		}
+		letters++
+		if strings.ContainsRune("aeiouy", r) {
+			vowels++
+			consonants = 0
+			if vowels > 2 {
+				penalty += 0.5
+			}
+		} else {
+			consonants++
+			vowels = 0
+			if consonants > 2 {
+				penalty++
+			}
+		}
+	}
+	if letters == 0 {
+		return 0
+	}
+	if !strings.ContainsAny(label, "aeiouy") {
+		return 0.1
+	}
+	return math.Max(0, 1-penalty*2/float64(letters))
+}
+
+func hasDigit(s string) bool {
+	return strings.ContainsAny(s, "0123456789")
+}
+
+func allDigits(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+func isLetters(s string) bool {
+	return strings.Trim(s, "abcdefghijklmnopqrstuvwxyz") == ""
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// dollars 格式化为 $2,400 这样的金额
+func dollars(v float64) string {
+	digits := fmt.Sprintf("%.0f", round(v))
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return "$" + b.String()
+}
+
+// round 保留两位有效数字，估价本身不精确，避免给出 $1,237 这种虚假的精度
+func round(v float64) float64 {
+	if v <= 0 {
+		return 0
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(v))-1)
+	return math.Round(v/magnitude) * magnitude
+}
@@ -0,0 +1,103 @@
+package valuation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"domain-agent/backend/internal/types"
+)
+
+const salesCSV = `domain,price,date,venue
+coffee.io,"$3,200",2024-03-02,Sedo
+coffeebar.com,"$8,500",2023-11-20,Dan
+brewlab.com,2100 USD,2024-06-11,Afternic
+qzvt.com,900,2022-01-05,NameJet
+insurance.com,"$35,600,000",2010-01-01,
+bad-row.com,n/a,2024-01-01,
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func loadSales(t *testing.T) []Sale {
+	sales, err := LoadSalesFile(writeFile(t, "sales.csv", salesCSV))
+	if err != nil {
+		t.Fatalf("load sales: %v", err)
+	}
+	return sales
+}
+
+func TestLoadSalesFile(t *testing.T) {
+	// 无法解析价格的行被跳过
+	sales := loadSales(t)
+	if len(sales) != 5 || sales[0].Price != 3200 || sales[2].Price != 2100 {
+		t.Errorf("sales = %+v, want 5 with the bad row skipped", sales)
+	}
+	if _, err := LoadSalesFile(writeFile(t, "sales.csv", "name,amount\nfoo.com,100\n")); err == nil {
+		t.Error("LoadSalesFile accepted a file without a price column")
+	}
+}
+
+func TestEstimateOrdering(t *testing.T) {
+	e := New(loadSales(t))
+	tests := []struct {
+		higher, lower string
+	}{
+		{"lumo.com", "lumovantix.com"},        // 短名称
+		{"coffee.com", "coffee.io"},           // .com
+		{"coffee.io", "coffee.xyz"},           // 后缀
+		{"insurance.com", "bakery.com"},       // 高价值关键词
+		{"coffeeshop.com", "coffee-shop.com"}, // 连字符
+		{"brewlab.com", "brew4lab.com"},       // 数字
+		{"lumo.com", "xqzt.com"},              // 难读
+	}
+	for _, tt := range tests {
+		a, b := e.Estimate(tt.higher), e.Estimate(tt.lower)
+		if a.Estimate <= b.Estimate {
+			t.Errorf("%s = %v, want above %s = %v", tt.higher, a.Estimate, tt.lower, b.Estimate)
+		}
+		for domain, v := range map[string]*types.Valuation{tt.higher: a, tt.lower: b} {
+			if !(v.Low <= v.Estimate && v.Estimate <= v.High) {
+				t.Errorf("%s: range %v–%v does not contain %v", domain, v.Low, v.High, v.Estimate)
+			}
+		}
+		if a.Currency != "USD" || len(a.Explanation) == 0 {
+			t.Errorf("%s: currency %q, explanation %v", tt.higher, a.Currency, a.Explanation)
+		}
+	}
+}
+
+func TestComparables(t *testing.T) {
+	e := New(loadSales(t))
+	if v := e.Estimate("coffee.com"); len(v.Comparables) == 0 || v.Comparables[0].Domain != "coffee.io" {
+		t.Errorf("coffee.com comparables = %+v, want coffee.io first", v.Comparables)
+	}
+	// 自己的成交不算可比成交
+	for _, c := range e.Estimate("coffeebar.com").Comparables {
+		if c.Domain == "coffeebar.com" {
+			t.Errorf("coffeebar.com is its own comparable: %+v", c)
+		}
+	}
+}
+
+func TestPronounceability(t *testing.T) {
+	if lumo, xqzt := Pronounceability("lumo"), Pronounceability("xqzt"); lumo <= xqzt {
+		t.Errorf("Pronounceability: lumo=%.2f, want above xqzt=%.2f", lumo, xqzt)
+	}
+}
+
+func TestLoadKeywordsFile(t *testing.T) {
+	keywords, err := LoadKeywordsFile(writeFile(t, "keywords.txt", "# 自定义\nbakery\t2\n"))
+	if err != nil || keywords["bakery"] != 2 {
+		t.Errorf("LoadKeywordsFile = %v, %v", keywords, err)
+	}
+	if _, err := LoadKeywordsFile(writeFile(t, "keywords.txt", "bakery\tlots\n")); err == nil {
+		t.Error("LoadKeywordsFile accepted an invalid multiplier")
+	}
+}
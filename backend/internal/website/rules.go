@@ -0,0 +1,94 @@
+package website
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Rule 一个停放或售卖服务商的识别规则，任一条件命中即判为该类
+//
+// 主机、脚本和表单的条件写作主机名（含子域名），也可以带路径前缀，如 "img1.wsimg.com/parking-lander"。
+// 只匹配页面结构，不在源码中查找文字：正常网站提到服务商的名字不算命中。
+type Rule struct {
+	Provider    string   `json:"provider"`
+	Kind        string   `json:"kind"`                   // parked / for_sale
+	Hosts       []string `json:"hosts,omitempty"`        // 跳转经过的地址、最终地址和 meta refresh 的目标
+	Scripts     []string `json:"scripts,omitempty"`      // 页面脚本 <script src> 的地址
+	Forms       []string `json:"forms,omitempty"`        // 表单 <form action> 提交的地址
+	NameServers []string `json:"name_servers,omitempty"` // 域名委派到的服务器（含子域名）
+}
+
+// DefaultRules 常见停放和售卖服务商
+var DefaultRules = []Rule{
+	{Provider: "Sedo", Kind: StatusParked, Hosts: []string{"sedoparking.com"}, Scripts: []string{"sedoparking.com"}, NameServers: []string{"sedoparking.com"}},
+	{Provider: "ParkingCrew", Kind: StatusParked, Hosts: []string{"parkingcrew.net"}, Scripts: []string{"parkingcrew.net"}, NameServers: []string{"parkingcrew.net"}},
+	{Provider: "Bodis", Kind: StatusParked, Hosts: []string{"bodis.com"}, Scripts: []string{"bodis.com"}, NameServers: []string{"bodis.com"}},
+	{Provider: "Above", Kind: StatusParked, Hosts: []string{"above.com"}, Scripts: []string{"above.com"}, NameServers: []string{"above.com", "abovedomains.com"}},
+	{Provider: "ParkLogic", Kind: StatusParked, Hosts: []string{"parklogic.com"}, Scripts: []string{"parklogic.com"}, NameServers: []string{"parklogic.com"}},
+	{Provider: "GoDaddy Parking", Kind: StatusParked, Scripts: []string{"img1.wsimg.com/parking-lander"}},
+	{Provider: "Namecheap Parking", Kind: StatusParked, Hosts: []string{"parkingpage.namecheap.com"}, Scripts: []string{"parkingpage.namecheap.com"}},
+	{Provider: "Dan.com", Kind: StatusForSale, Hosts: []string{"dan.com", "undeveloped.com"}, Forms: []string{"dan.com"}, NameServers: []string{"undeveloped.com"}},
+	{Provider: "Afternic", Kind: StatusForSale, Hosts: []string{"afternic.com"}, Forms: []string{"afternic.com"}, NameServers: []string{"afternic.com"}},
+	{Provider: "Sedo", Kind: StatusForSale, Hosts: []string{"sedo.com"}, Forms: []string{"sedo.com"}},
+	{Provider: "HugeDomains", Kind: StatusForSale, Hosts: []string{"hugedomains.com"}, Forms: []string{"hugedomains.com"}},
+	{Provider: "Atom", Kind: StatusForSale, Hosts: []string{"atom.com", "squadhelp.com"}, Forms: []string{"atom.com", "squadhelp.com"}},
+	{Provider: "BuyDomains", Kind: StatusForSale, Hosts: []string{"buydomains.com"}, Forms: []string{"buydomains.com"}},
+}
+
+// 没有服务商特征时使用的通用文字
+//
+// 只收录页面在说明这个域名本身的句子。“make an offer”“related searches”“domain parking”
+// 之类的字样在普通商店、交易市场和注册商首页上同样常见，不作为依据。
+var (
+	genericForSaleMarkers = []string{
+		"this domain is for sale", "this domain may be for sale", "buy this domain",
+		"inquire about this domain", "此域名出售", "本域名出售",
+	}
+	genericParkedMarkers = []string{
+		"this domain is parked", "this web page is parked", "is parked free", "此域名停放",
+	}
+)
+
+// LoadRules 读取 JSON 规则文件（Rule 数组），追加在默认规则之后
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var extra []Rule
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&extra); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for i, r := range extra {
+		if r.Kind != StatusParked && r.Kind != StatusForSale {
+			return nil, fmt.Errorf("rule %d (%s): kind must be %q or %q", i, r.Provider, StatusParked, StatusForSale)
+		}
+		if len(r.Hosts)+len(r.Scripts)+len(r.Forms)+len(r.NameServers) == 0 {
+			return nil, fmt.Errorf("rule %d (%s): needs hosts, scripts, forms or name_servers", i, r.Provider)
+		}
+	}
+	rules := append([]Rule{}, DefaultRules...)
+	return append(rules, extra...), nil
+}
+
+// urlMatches 地址的主机是 pattern 中的主机或其子域名，pattern 带路径时路径还要以它开头
+func urlMatches(u *url.URL, pattern string) bool {
+	host, path, hasPath := strings.Cut(pattern, "/")
+	if !hostMatches(u.Hostname(), host) {
+		return false
+	}
+	return !hasPath || strings.HasPrefix(u.Path, "/"+path)
+}
+
+// hostMatches host 是 pattern 本身或它的子域名
+func hostMatches(host, pattern string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	pattern = strings.ToLower(pattern)
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
@@ -0,0 +1,413 @@
+// Package website 访问域名的首页，判断它是正常网站、停放页、售卖页还是跳转
+//
+// 同样有 A 记录和证书的域名，可能是在经营的业务，也可能是等待出售的停放页，
+// 对是否值得联系持有者购买影响很大。判断依据是页面结构指向的已知停放和售卖服务商：
+// 跳转到的主机、停放脚本的地址、报价表单提交的地址，以及名称服务器（见 DefaultRules），
+// 没有命中时再看可见文字中通用的"出售""停放"字样。
+//
+// 域名由用户给出，解析结果和跳转都不可信：探测器只连接公网地址，每一跳跳转都重新检查。
+package website
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"domain-agent/backend/internal/types"
+)
+
+// 分类结果
+const (
+	StatusActive      = "active"      // 正常网站
+	StatusParked      = "parked"      // 停放页（广告、相关搜索）
+	StatusForSale     = "for_sale"    // 售卖落地页
+	StatusRedirect    = "redirect"    // 跳转到其它网站
+	StatusUnreachable = "unreachable" // 无法访问或服务端错误
+)
+
+// 访问限制
+const (
+	defaultTimeout = 10 * time.Second
+	maxBodySize    = 512 << 10
+	maxRedirects   = 10
+	maxMarkers     = 5
+)
+
+// Prober 首页探测器
+type Prober struct {
+	Rules []Rule
+	HTTP  *http.Client
+}
+
+// New 创建探测器，rules 为空时使用 DefaultRules
+//
+// 连接不经过代理，拨号时拒绝非公网地址（见 guardedDialer）。
+func New(rules []Rule) *Prober {
+	if len(rules) == 0 {
+		rules = DefaultRules
+	}
+	return &Prober{
+		Rules: rules,
+		HTTP: &http.Client{
+			Timeout: defaultTimeout,
+			Transport: &http.Transport{
+				DialContext:         guardedDialer().DialContext,
+				TLSHandshakeTimeout: defaultTimeout,
+				MaxIdleConns:        10,
+				IdleConnTimeout:     30 * time.Second,
+			},
+		},
+	}
+}
+
+// guardedDialer 只连接公网地址的拨号器
+//
+// Control 在域名解析之后、建立连接之前对实际地址调用，解析到内网地址的域名和
+// 跳转到内网的地址都会被拒绝，不受 DNS 重绑定影响。
+func guardedDialer() *net.Dialer {
+	return &net.Dialer{Timeout: defaultTimeout, Control: refuseNonPublic}
+}
+
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !addressAllowed(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", ip)
+	}
+	return nil
+}
+
+// addressAllowed 是否允许连接该地址，测试中替换以连接本地替身
+var addressAllowed = publicAddr
+
+// nonPublicPrefixes 全局单播范围内、但不应从公网访问的地址段
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"),   // 运营商级 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF 协议分配
+	netip.MustParsePrefix("192.0.2.0/24"),    // 文档
+	netip.MustParsePrefix("198.18.0.0/15"),   // 基准测试
+	netip.MustParsePrefix("198.51.100.0/24"), // 文档
+	netip.MustParsePrefix("203.0.113.0/24"),  // 文档
+	netip.MustParsePrefix("240.0.0.0/4"),     // 保留
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // 本地 NAT64
+	netip.MustParsePrefix("100::/64"),        // 丢弃
+	netip.MustParsePrefix("2001:db8::/32"),   // 文档
+}
+
+// nat64Prefix 公共 NAT64 前缀，按内嵌的 IPv4 地址判断
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// publicAddr 地址是否是公网单播地址：排除回环、私有、链路本地、组播、未指定和保留地址
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if nat64Prefix.Contains(ip) {
+		b := ip.As16()
+		return publicAddr(netip.AddrFrom4([4]byte{b[12], b[13], b[14], b[15]}))
+	}
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkTarget 检查起始地址和每一跳跳转：只允许 HTTP(S)，直接写 IP 的地址必须是公网地址
+//
+// 写域名的地址在拨号时按解析结果检查。
+func checkTarget(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("refusing %s URL %s", u.Scheme, u)
+	}
+	if ip, err := netip.ParseAddr(strings.Trim(u.Hostname(), "[]")); err == nil && !addressAllowed(ip) {
+		return fmt.Errorf("refusing non-public address %s", ip)
+	}
+	return nil
+}
+
+var (
+	defaultProber *Prober
+	defaultOnce   sync.Once
+	defaultMu     sync.RWMutex
+)
+
+// Default 默认探测器，配置了 WEBSITE_RULES 时追加该文件中的规则
+func Default() *Prober {
+	defaultOnce.Do(func() {
+		rules := DefaultRules
+		if path := os.Getenv("WEBSITE_RULES"); path != "" {
+			if loaded, err := LoadRules(path); err != nil {
+				fmt.Printf("Website rules unavailable, using defaults: %v\n", err)
+			} else {
+				rules = loaded
+			}
+		}
+		p := New(rules)
+		defaultMu.Lock()
+		if defaultProber == nil {
+			defaultProber = p
+		}
+		defaultMu.Unlock()
+	})
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultProber
+}
+
+// SetDefault 替换默认探测器，仅应在启动时、处理请求之前调用
+func SetDefault(p *Prober) {
+	defaultMu.Lock()
+	defaultProber = p
+	defaultMu.Unlock()
+}
+
+// page 一次访问的结果
+type page struct {
+	url       *url.URL // 最终地址
+	status    int
+	body      string
+	redirects []string // 依次经过的地址（不含起始地址）
+}
+
+// Probe 访问域名首页并分类，先试 HTTPS，失败时改用 HTTP
+//
+// nameServers 为域名委派到的服务器，用于识别停放服务商，可以为空。
+func (p *Prober) Probe(ctx context.Context, domain string, nameServers []string) *types.WebsiteInfo {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	var pg *page
+	var errs []error
+	for _, scheme := range []string{"https", "http"} {
+		got, err := p.fetch(ctx, scheme+"://"+domain+"/")
+		if err == nil {
+			pg = got
+			break
+		}
+		errs = append(errs, err)
+	}
+
+	if pg == nil {
+		// 打不开首页，但名称服务器仍可能说明是停放域名
+		info := &types.WebsiteInfo{Status: StatusUnreachable, Error: errors.Join(errs...).Error()}
+		if rule, reason, ok := p.matchNameServers(nameServers); ok {
+			info.Status, info.Provider, info.Markers = rule.Kind, rule.Provider, []string{reason}
+		}
+		return info
+	}
+	return p.classify(domain, nameServers, pg)
+}
+
+// fetch 访问地址，记录跳转，读取最多 maxBodySize 的正文
+func (p *Prober) fetch(ctx context.Context, target string) (*page, error) {
+	pg := &page{}
+	client := *p.HTTP
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		if err := checkTarget(req.URL); err != nil {
+			return err
+		}
+		pg.redirects = append(pg.redirects, req.URL.String())
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTarget(req.URL); err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; domain-agent/1.0)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil && len(body) == 0 {
+		return nil, err
+	}
+	pg.url = resp.Request.URL
+	pg.status = resp.StatusCode
+	pg.body = string(body)
+	return pg, nil
+}
+
+var (
+	titlePattern       = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	metaRefreshPattern = regexp.MustCompile(`(?is)<meta[^>]+http-equiv=["']?refresh["']?[^>]+content=["'][^"']*url=([^"'>\s]+)`)
+	scriptPattern      = regexp.MustCompile(`(?is)<script[^>]+src=["']?([^"'>\s]+)`)
+	formPattern        = regexp.MustCompile(`(?is)<form[^>]+action=["']?([^"'>\s]+)`)
+	tagPattern         = regexp.MustCompile(`(?s)<[^>]*>`)
+	spacePattern       = regexp.MustCompile(`\s+`)
+)
+
+// structure 页面中用来匹配规则的结构
+type structure struct {
+	visited     []*url.URL // 跳转经过的地址、最终地址和 meta refresh 的目标
+	scripts     []*url.URL // <script src>
+	forms       []*url.URL // <form action>
+	nameServers []string
+}
+
+// classify 按规则给页面分类：售卖优先于停放，其次是跳到其它网站，最后是正常网站
+func (p *Prober) classify(domain string, nameServers []string, pg *page) *types.WebsiteInfo {
+	info := &types.WebsiteInfo{
+		URL:        pg.url.String(),
+		HTTPStatus: pg.status,
+		Redirects:  pg.redirects,
+	}
+	if m := titlePattern.FindStringSubmatch(pg.body); m != nil {
+		info.Title = strings.TrimSpace(spacePattern.ReplaceAllString(html.UnescapeString(m[1]), " "))
+	}
+
+	// 页面内的 meta refresh 跳转也算作跳转
+	st := structure{nameServers: nameServers}
+	for _, r := range pg.redirects {
+		if u, err := url.Parse(r); err == nil {
+			st.visited = append(st.visited, u)
+		}
+	}
+	st.visited = append(st.visited, pg.url)
+	if m := metaRefreshPattern.FindStringSubmatch(pg.body); m != nil {
+		if u, err := pg.url.Parse(html.UnescapeString(m[1])); err == nil {
+			info.Redirects = append(info.Redirects, u.String())
+			st.visited = append(st.visited, u)
+		}
+	}
+	st.scripts = pageURLs(pg, scriptPattern)
+	st.forms = pageURLs(pg, formPattern)
+
+	var matched []Rule
+	for _, rule := range p.Rules {
+		if reason, ok := ruleMatches(rule, st); ok {
+			matched = append(matched, rule)
+			info.Markers = appendMarker(info.Markers, reason)
+		}
+	}
+
+	// 通用字样只在可见文字中查找，不看脚本和属性
+	text := strings.ToLower(html.UnescapeString(spacePattern.ReplaceAllString(tagPattern.ReplaceAllString(pg.body, " "), " ")))
+	for _, kind := range []string{StatusForSale, StatusParked} {
+		for _, rule := range matched {
+			if rule.Kind == kind {
+				info.Status, info.Provider = kind, rule.Provider
+				return info
+			}
+		}
+		generic := genericParkedMarkers
+		if kind == StatusForSale {
+			generic = genericForSaleMarkers
+		}
+		for _, marker := range generic {
+			if strings.Contains(text, marker) {
+				info.Status = kind
+				info.Markers = appendMarker(info.Markers, marker)
+				return info
+			}
+		}
+	}
+
+	last := st.visited[len(st.visited)-1]
+	switch {
+	case pg.status >= 500:
+		info.Status = StatusUnreachable
+	case !sameSite(domain, last.Hostname()) || !sameSite(domain, pg.url.Hostname()):
+		info.Status = StatusRedirect
+	default:
+		info.Status = StatusActive
+	}
+	return info
+}
+
+// pageURLs 按 pattern 提取页面中的地址，相对地址按页面地址补全
+func pageURLs(pg *page, pattern *regexp.Regexp) []*url.URL {
+	var urls []*url.URL
+	for _, m := range pattern.FindAllStringSubmatch(pg.body, -1) {
+		if u, err := pg.url.Parse(html.UnescapeString(m[1])); err == nil {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// matchNameServers 只按名称服务器匹配规则
+func (p *Prober) matchNameServers(nameServers []string) (Rule, string, bool) {
+	for _, rule := range p.Rules {
+		if reason, ok := ruleMatches(rule, structure{nameServers: nameServers}); ok {
+			return rule, reason, true
+		}
+	}
+	return Rule{}, "", false
+}
+
+// ruleMatches 规则是否命中，返回命中的原因
+func ruleMatches(rule Rule, st structure) (string, bool) {
+	for _, c := range []struct {
+		label    string
+		patterns []string
+		urls     []*url.URL
+	}{
+		{"host", rule.Hosts, st.visited},
+		{"script", rule.Scripts, st.scripts},
+		{"form", rule.Forms, st.forms},
+	} {
+		for _, pattern := range c.patterns {
+			for _, u := range c.urls {
+				if urlMatches(u, pattern) {
+					return c.label + " " + u.Hostname() + u.Path, true
+				}
+			}
+		}
+	}
+	for _, pattern := range rule.NameServers {
+		for _, ns := range st.nameServers {
+			if hostMatches(ns, pattern) {
+				return "nameserver " + ns, true
+			}
+		}
+	}
+	return "", false
+}
+
+// sameSite host 是域名本身或它的子域名（如 www）
+func sameSite(domain, host string) bool {
+	return hostMatches(host, domain)
+}
+
+func appendMarker(markers []string, m string) []string {
+	if len(markers) >= maxMarkers {
+		return markers
+	}
+	for _, existing := range markers {
+		if existing == m {
+			return markers
+		}
+	}
+	return append(markers, m)
+}
@@ -0,0 +1,210 @@
+package website
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pages 按 Host 返回的页面
+var pages = map[string]string{
+	"active.test":     `<html><head><title>Acme &amp; Co Tools</title></head><body><h1>Welcome to Acme</h1><p>Hand tools since 1921.</p></body></html>`,
+	"www.brand.test":  `<html><head><title>Brand</title></head><body>Our products</body></html>`,
+	"other.test":      `<html><head><title>Other</title></head><body>Another business</body></html>`,
+	"parked.test":     `<html><head><title>parked.test</title></head><body><div>This domain is parked free of charge.</div><h2>Related Searches</h2></body></html>`,
+	"forsale.test":    `<html><head><title>forsale.test is for sale</title></head><body><h1>This domain is for sale!</h1><button>Make an offer</button></body></html>`,
+	"dan.test":        `<html><head><meta http-equiv="refresh" content="0;url=https://dan.com/buy-domain/dan.test"></head><body></body></html>`,
+	"offer.test":      `<html><body><form method="post" action="https://dan.com/offer/offer.test"><input name="amount"></form></body></html>`,
+	"sedoparking.com": `<html><head><title>sedo landing</title></head><body><script src="/js/park.js"></script></body></html>`,
+	"lander.test":     `<html><head><script src="https://img1.wsimg.com/parking-lander/static/js/main.js"></script></head><body></body></html>`,
+	"custom.test":     `<html><head><script src="//cdn.localpark.test/widget.js"></script></head><body><div>ads</div></body></html>`,
+	// 商店、交易市场和注册商首页上的常见字样不算售卖或停放
+	"shop.test": `<html><head><title>Vintage Market</title></head><body><button>Make an offer</button><h2>Related searches</h2>` +
+		`<p>Domain parking, 域名出售 and transfers from $9.</p></body></html>`,
+	// 正常网站提到服务商、用同一 CDN 的其它脚本，都不算停放或售卖
+	"blog.test": `<html><head><title>Domain notes</title><script src="https://img1.wsimg.com/blobby/go/site.js"></script></head>` +
+		`<body><p>We compared Sedoparking, ParkingCrew and Bodis, and bought our name on <a href="https://dan.com/">dan.com</a>.</p>` +
+		`<form action="/subscribe"><input name="email"></form></body></html>`,
+}
+
+// standIn 启动按 Host 返回页面的本地网站，返回连接都转到它的探测器
+//
+// down.test 模拟无法连接，private.test 解析到内网地址；HTTPS 握手会失败，探测器改用 HTTP。
+// 拨号仍经过地址检查，只是放行回环地址以便连接本地替身。
+func standIn(t *testing.T, rules []Rule) *Prober {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := strings.Split(r.Host, ":")[0]
+		switch host {
+		case "brand.test":
+			http.Redirect(w, r, "http://www.brand.test/", http.StatusMovedPermanently)
+		case "moved.test":
+			http.Redirect(w, r, "http://other.test/", http.StatusMovedPermanently)
+		case "sedo.test":
+			http.Redirect(w, r, "http://sedoparking.com/search?domain=sedo.test", http.StatusFound)
+		case "metadata.test":
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+		case "internal.test":
+			http.Redirect(w, r, "http://private.test/admin", http.StatusFound)
+		case "file.test":
+			http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+		case "broken.test":
+			http.Error(w, "upstream error", http.StatusServiceUnavailable)
+		default:
+			body, ok := pages[host]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, body)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	allowed := addressAllowed
+	addressAllowed = func(ip netip.Addr) bool { return ip.IsLoopback() || allowed(ip) }
+	t.Cleanup(func() { addressAllowed = allowed })
+
+	addr := srv.Listener.Addr().String()
+	p := New(rules)
+	p.HTTP.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, target string) (net.Conn, error) {
+			switch {
+			case strings.HasPrefix(target, "down.test:"):
+				return nil, errors.New("connection refused")
+			case strings.HasPrefix(target, "private.test:"):
+				return guardedDialer().DialContext(ctx, network, "10.0.0.7:80")
+			}
+			return guardedDialer().DialContext(ctx, network, addr)
+		},
+	}
+	return p
+}
+
+func writeRules(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestProbe(t *testing.T) {
+	rules, err := LoadRules(writeRules(t, `[{"provider": "LocalPark", "kind": "parked", "scripts": ["localpark.test"]}]`))
+	if err != nil {
+		t.Fatalf("load rules: %v", err)
+	}
+	p := standIn(t, rules)
+
+	tests := []struct {
+		domain, status, provider string
+		nameServers              []string
+		url                      string   // 为空时不检查
+		redirects                []string // 为空时不检查
+	}{
+		{domain: "active.test", status: StatusActive, url: "http://active.test/"},
+		{domain: "brand.test", status: StatusActive, url: "http://www.brand.test/", redirects: []string{"http://www.brand.test/"}},
+		{domain: "moved.test", status: StatusRedirect, url: "http://other.test/"},
+		{domain: "parked.test", status: StatusParked},
+		{domain: "forsale.test", status: StatusForSale},
+		{domain: "dan.test", status: StatusForSale, provider: "Dan.com", redirects: []string{"https://dan.com/buy-domain/dan.test"}},
+		{domain: "offer.test", status: StatusForSale, provider: "Dan.com"},
+		{domain: "sedo.test", status: StatusParked, provider: "Sedo"},
+		{domain: "lander.test", status: StatusParked, provider: "GoDaddy Parking"},
+		{domain: "custom.test", status: StatusParked, provider: "LocalPark"},
+		{domain: "blog.test", status: StatusActive},
+		{domain: "shop.test", status: StatusActive},
+		{domain: "broken.test", status: StatusUnreachable},
+		{domain: "down.test", status: StatusUnreachable},
+		{domain: "down.test", status: StatusParked, provider: "ParkingCrew", nameServers: []string{"ns1.parkingcrew.net", "ns2.parkingcrew.net"}},
+	}
+	for _, tt := range tests {
+		info := p.Probe(context.Background(), tt.domain, tt.nameServers)
+		if info.Status != tt.status || info.Provider != tt.provider ||
+			(tt.url != "" && info.URL != tt.url) ||
+			(tt.redirects != nil && strings.Join(info.Redirects, " ") != strings.Join(tt.redirects, " ")) {
+			t.Errorf("%s: status=%s provider=%q url=%s redirects=%v markers=%v, want %s %q",
+				tt.domain, info.Status, info.Provider, info.URL, info.Redirects, info.Markers, tt.status, tt.provider)
+		}
+	}
+
+	if info := p.Probe(context.Background(), "active.test", nil); info.Title != "Acme & Co Tools" {
+		t.Errorf("active.test: title = %q", info.Title)
+	}
+}
+
+func TestProbeRefusesNonPublicTargets(t *testing.T) {
+	p := standIn(t, nil)
+	for _, domain := range []string{"metadata.test", "internal.test", "file.test"} {
+		info := p.Probe(context.Background(), domain, nil)
+		if info.Status != StatusUnreachable || !strings.Contains(info.Error, "refusing") {
+			t.Errorf("%s: status=%s error=%q, want unreachable and refused", domain, info.Status, info.Error)
+		}
+	}
+}
+
+func TestDefaultTransportRefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("stand-in reached: %s", r.URL)
+	}))
+	t.Cleanup(srv.Close)
+
+	p := New(nil)
+	for _, domain := range []string{"localhost", srv.Listener.Addr().String()} {
+		info := p.Probe(context.Background(), domain, nil)
+		if info.Status != StatusUnreachable || !strings.Contains(info.Error, "non-public") {
+			t.Errorf("%s: status=%s error=%q, want refused", domain, info.Status, info.Error)
+		}
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1::1", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+		{"64:ff9b::5db8:d822", true},
+	}
+	for _, tt := range tests {
+		if got := publicAddr(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("publicAddr(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	for _, content := range []string{
+		`[{"provider": "Bad", "kind": "expired", "hosts": ["bad.test"]}]`,
+		`[{"provider": "Empty", "kind": "parked"}]`,
+		// 不再支持按源码文字匹配
+		`[{"provider": "Old", "kind": "parked", "markers": ["localpark-widget"]}]`,
+	} {
+		if _, err := LoadRules(writeRules(t, content)); err == nil {
+			t.Errorf("LoadRules accepted %s", content)
+		}
+	}
+}
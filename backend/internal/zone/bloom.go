@@ -0,0 +1,182 @@
+package zone
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// bloomMagic 过滤器文件头
+const bloomMagic = "DABLOOM1"
+
+// Bloom 布隆过滤器：不在过滤器中的域名一定不在区域文件中，
+// 在过滤器中的域名有 FalsePositiveRate 的概率是误判
+type Bloom struct {
+	bits   []uint64
+	m      uint64   // 位数
+	k      uint32   // 哈希函数个数
+	n      uint64   // 写入的域名数
+	rate   float64  // 构建时的目标误判率
+	suffix []string // 覆盖的后缀（不带点，如 com）
+}
+
+// NewBloom 按预计的域名数和目标误判率创建过滤器
+func NewBloom(n uint64, rate float64) *Bloom {
+	if n == 0 {
+		n = 1
+	}
+	if rate <= 0 || rate >= 1 {
+		rate = DefaultFalsePositiveRate
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(rate) / (math.Ln2 * math.Ln2)))
+	m = max((m+63)/64*64, 64)
+	k := uint32(max(math.Round(float64(m)/float64(n)*math.Ln2), 1))
+	return &Bloom{bits: make([]uint64, m/64), m: m, k: k, rate: rate}
+}
+
+// DefaultFalsePositiveRate 默认的目标误判率
+const DefaultFalsePositiveRate = 1e-6
+
+// Add 写入域名
+func (b *Bloom) Add(domain string) {
+	h1, h2 := bloomHash(domain)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		bit := (h1 + i*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+	b.n++
+	b.addSuffix(domain)
+}
+
+// Contains 域名是否可能在过滤器中
+func (b *Bloom) Contains(domain string) bool {
+	h1, h2 := bloomHash(domain)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		bit := (h1 + i*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Covers 过滤器是否包含该后缀的区域数据
+func (b *Bloom) Covers(suffix string) bool {
+	for _, s := range b.suffix {
+		if s == suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// Len 写入的域名数
+func (b *Bloom) Len() uint64 { return b.n }
+
+// Suffixes 覆盖的后缀
+func (b *Bloom) Suffixes() []string { return append([]string(nil), b.suffix...) }
+
+// SizeBytes 位数组占用的字节数
+func (b *Bloom) SizeBytes() uint64 { return b.m / 8 }
+
+// FalsePositiveRate 按实际写入数量估算的误判率
+func (b *Bloom) FalsePositiveRate() float64 {
+	return math.Pow(1-math.Exp(-float64(b.k)*float64(b.n)/float64(b.m)), float64(b.k))
+}
+
+func (b *Bloom) addSuffix(domain string) {
+	suffix := domain[strings.LastIndexByte(domain, '.')+1:]
+	if !b.Covers(suffix) {
+		b.suffix = append(b.suffix, suffix)
+	}
+}
+
+// bloomHash 双重哈希（Kirsch-Mitzenmacher）：第 i 个位置为 h1 + i*h2
+func bloomHash(s string) (uint64, uint64) {
+	a := fnv.New64a()
+	a.Write([]byte(s))
+	b := fnv.New64()
+	b.Write([]byte(s))
+	return a.Sum64(), b.Sum64() | 1
+}
+
+// WriteFile 把过滤器写入文件（先写临时文件再改名，刷新时读者不会看到写了一半的文件）
+func (b *Bloom) WriteFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := b.write(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// 文件格式：magic | m | k | n | rate | 后缀（逗号分隔，带长度）| 位数组，整数为小端序
+func (b *Bloom) write(w io.Writer) error {
+	suffix := strings.Join(b.suffix, ",")
+	header := []any{[]byte(bloomMagic), b.m, b.k, b.n, b.rate, uint32(len(suffix)), []byte(suffix)}
+	for _, v := range header {
+		if err := binary.Write(w, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+	return binary.Write(w, binary.LittleEndian, b.bits)
+}
+
+// ReadBloom 读取 WriteFile 写入的过滤器
+func ReadBloom(path string) (*Bloom, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	magic := make([]byte, len(bloomMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != bloomMagic {
+		return nil, fmt.Errorf("%s: not a bloom filter file", path)
+	}
+
+	b := &Bloom{}
+	var suffixLen uint32
+	for _, v := range []any{&b.m, &b.k, &b.n, &b.rate, &suffixLen} {
+		if err := binary.Read(r, binary.LittleEndian, v); err != nil {
+			return nil, fmt.Errorf("%s: read header: %w", path, err)
+		}
+	}
+	if b.m == 0 || b.m%64 != 0 || b.k == 0 {
+		return nil, fmt.Errorf("%s: %w", path, errors.New("corrupt header"))
+	}
+	suffix := make([]byte, suffixLen)
+	if _, err := io.ReadFull(r, suffix); err != nil {
+		return nil, fmt.Errorf("%s: read suffixes: %w", path, err)
+	}
+	if len(suffix) > 0 {
+		b.suffix = strings.Split(string(suffix), ",")
+	}
+
+	b.bits = make([]uint64, b.m/64)
+	if err := binary.Read(r, binary.LittleEndian, b.bits); err != nil {
+		return nil, fmt.Errorf("%s: read bits: %w", path, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,86 @@
+package zone
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+)
+
+// EachDelegation 逐个读取区域文件中有 NS 记录的域名（即已注册并完成委派的域名）
+//
+// 支持 CZDS 格式（每行“名称 TTL 类别 类型 数据”，名称为完整域名），.gz 文件自动解压。
+// 区域顶点（后缀本身，如 com.）不包括在内。区域文件按名称排序，同一名称的
+// 多条 NS 记录只回调一次。
+func EachDelegation(path string, fn func(domain string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return eachDelegation(r, fn)
+}
+
+func eachDelegation(r io.Reader, fn func(domain string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	origin := ""
+	last := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, ';'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			if len(fields) >= 2 && strings.EqualFold(fields[0], "$ORIGIN") {
+				origin = strings.ToLower(strings.TrimSuffix(fields[1], "."))
+			}
+			continue
+		}
+		if strings.HasPrefix(fields[0], "$") {
+			continue
+		}
+
+		// 类型字段的位置取决于是否省略了 TTL 和类别，在前几列中查找
+		isNS := false
+		for _, f := range fields[1:min(len(fields)-1, 4)] {
+			if strings.EqualFold(f, "ns") {
+				isNS = true
+				break
+			}
+		}
+		if !isNS {
+			continue
+		}
+
+		name := strings.ToLower(fields[0])
+		if strings.HasSuffix(name, ".") {
+			name = strings.TrimSuffix(name, ".")
+		} else if origin != "" && name != "@" {
+			name += "." + origin
+		}
+		// 顶点的 NS 记录和没有点的名称（后缀本身）不是注册的域名
+		if name == "@" || name == origin || !strings.Contains(name, ".") {
+			continue
+		}
+		if name == last {
+			continue
+		}
+		last = name
+		fn(name)
+	}
+	return scanner.Err()
+}
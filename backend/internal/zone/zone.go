@@ -0,0 +1,225 @@
+// Package zone 基于区域文件的离线可用性预筛
+//
+// 从 ICANN CZDS 下载的区域文件列出了某个后缀下所有已委派（有 NS 记录）的域名。
+// 区域文件是某一时刻的快照，布隆过滤器还可能误判，因此命中只是已注册的先验证据，
+// 仍需由委派、RDAP 和 DNS 实时确认；不在区域文件中的名称（包括已注册但没有委派的）
+// 同样实时检查。区域文件可以直接加载，也可以用 cmd/zonefilter 构建为紧凑的布隆过滤器。
+package zone
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Set 已注册域名的集合
+type Set interface {
+	Contains(domain string) bool // 域名是否在区域中（布隆过滤器可能误判为在）
+	Covers(suffix string) bool   // 是否包含该后缀（不带点，如 com）的区域数据
+}
+
+// exactSet 直接由区域文件加载的精确集合，适合较小的区域
+type exactSet struct {
+	names  map[string]struct{}
+	suffix map[string]bool
+}
+
+func (s *exactSet) Contains(domain string) bool {
+	_, ok := s.names[domain]
+	return ok
+}
+
+func (s *exactSet) Covers(suffix string) bool { return s.suffix[suffix] }
+
+// LoadZone 把区域文件加载为精确集合
+func LoadZone(paths ...string) (Set, error) {
+	s := &exactSet{names: map[string]struct{}{}, suffix: map[string]bool{}}
+	for _, path := range paths {
+		err := EachDelegation(path, func(domain string) {
+			s.names[domain] = struct{}{}
+			s.suffix[domain[strings.LastIndexByte(domain, '.')+1:]] = true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// multiSet 多个集合的并集
+type multiSet []Set
+
+func (m multiSet) Contains(domain string) bool {
+	for _, s := range m {
+		if s.Contains(domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiSet) Covers(suffix string) bool {
+	for _, s := range m {
+		if s.Covers(suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Registered 域名是否在区域数据中
+//
+// 只看域名本身（二级域名加后缀）：区域数据不覆盖该后缀，或名称不在其中时返回 false。
+// 返回 true 时域名在快照时刻有委派（布隆过滤器还可能误判），调用方应作为先验证据使用。
+func Registered(s Set, domain string) bool {
+	if s == nil {
+		return false
+	}
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	i := strings.LastIndexByte(domain, '.')
+	if i < 0 || !s.Covers(domain[i+1:]) {
+		return false
+	}
+	return s.Contains(domain)
+}
+
+// reloadInterval 检查 ZONE_FILTER 中的文件是否被更新的最短间隔
+var reloadInterval = time.Minute
+
+var (
+	defaultSet  Set
+	defaultOnce sync.Once
+	defaultMu   sync.RWMutex
+
+	// 从 ZONE_FILTER 加载时监视的文件、加载时的修改时间和上次检查的时间；
+	// SetDefault 之后不再监视
+	watched   []string
+	modTimes  []time.Time
+	checkedAt time.Time
+	reloadMu  sync.Mutex
+)
+
+// Default 默认的区域数据，首次调用时从 ZONE_FILTER 加载
+//
+// ZONE_FILTER 为逗号分隔的文件路径：.bloom 结尾的按布隆过滤器读取，其余按区域文件
+// （可以是 .gz）加载。未配置或全部加载失败时返回 nil，扫描器会对所有域名做实时检查。
+// cmd/zonefilter refresh 会原子地替换文件，Default 至多每 reloadInterval 检查一次
+// 文件的修改时间，有变化时在调用方的协程中重新加载，其余调用继续使用旧数据。
+func Default() Set {
+	defaultOnce.Do(func() {
+		paths := splitPaths(os.Getenv("ZONE_FILTER"))
+		if len(paths) == 0 {
+			return
+		}
+		times := statPaths(paths)
+		s := loadPaths(paths)
+		defaultMu.Lock()
+		if defaultSet == nil {
+			defaultSet = s
+			watched, modTimes, checkedAt = paths, times, time.Now()
+		}
+		defaultMu.Unlock()
+	})
+	reloadIfChanged()
+
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultSet
+}
+
+// reloadIfChanged 到了检查间隔且监视的文件修改时间有变化时重新加载；
+// 已有其他协程在检查时直接返回
+func reloadIfChanged() {
+	defaultMu.RLock()
+	due := len(watched) > 0 && time.Since(checkedAt) >= reloadInterval
+	defaultMu.RUnlock()
+	if !due || !reloadMu.TryLock() {
+		return
+	}
+	defer reloadMu.Unlock()
+
+	defaultMu.Lock()
+	paths, previous := watched, modTimes
+	checkedAt = time.Now()
+	defaultMu.Unlock()
+	if len(paths) == 0 {
+		return
+	}
+
+	// 先取修改时间再加载：加载期间文件再次被替换时，下一次检查仍会发现变化
+	times := statPaths(paths)
+	if slices.EqualFunc(times, previous, time.Time.Equal) {
+		return
+	}
+	s := loadPaths(paths)
+
+	defaultMu.Lock()
+	if slices.Equal(watched, paths) {
+		defaultSet, modTimes = s, times
+	}
+	defaultMu.Unlock()
+}
+
+// SetDefault 替换默认的区域数据并停止监视 ZONE_FILTER 中的文件，传入 nil 关闭预筛
+func SetDefault(s Set) {
+	defaultOnce.Do(func() {})
+	defaultMu.Lock()
+	defaultSet = s
+	watched, modTimes = nil, nil
+	defaultMu.Unlock()
+}
+
+// Open 按文件类型加载区域数据
+func Open(path string) (Set, error) {
+	if strings.HasSuffix(path, ".bloom") {
+		return ReadBloom(path)
+	}
+	return LoadZone(path)
+}
+
+// splitPaths 拆分逗号分隔的文件路径，忽略空项
+func splitPaths(paths string) []string {
+	var list []string
+	for _, path := range strings.Split(paths, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			list = append(list, path)
+		}
+	}
+	return list
+}
+
+// statPaths 文件的修改时间，无法读取的文件为零值
+func statPaths(paths []string) []time.Time {
+	times := make([]time.Time, len(paths))
+	for i, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			times[i] = info.ModTime()
+		}
+	}
+	return times
+}
+
+// loadPaths 加载多个文件，单个文件失败时跳过
+func loadPaths(paths []string) Set {
+	var sets multiSet
+	for _, path := range paths {
+		s, err := Open(path)
+		if err != nil {
+			fmt.Printf("Zone data unavailable: %v\n", err)
+			continue
+		}
+		fmt.Printf("Loaded zone data from %s\n", path)
+		sets = append(sets, s)
+	}
+
+	switch len(sets) {
+	case 0:
+		return nil
+	case 1:
+		return sets[0]
+	}
+	return sets
+}
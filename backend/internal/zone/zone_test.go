@@ -0,0 +1,182 @@
+package zone
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// comZone CZDS 格式的区域文件片段：顶点、带 TTL 和类别的绝对名称、省略 TTL 的相对名称、
+// 同一名称的多条 NS 记录、非 NS 记录和注释
+const comZone = `$ORIGIN com.
+com. 900 IN SOA a.gtld-servers.net. nstld.verisign-grs.com. 1 1800 900 604800 86400
+com. 172800 IN NS a.gtld-servers.net.
+google.com. 172800 in ns ns1.google.com.
+google.com. 172800 in ns ns2.google.com.
+ns1.google.com. 172800 in a 216.239.32.10
+example IN NS a.iana-servers.net. ; 相对名称
+; kitleaf.com. 172800 in ns ns1.kitleaf.com.
+`
+
+// writeFile 在临时目录写入文件，.gz 结尾时压缩
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if filepath.Ext(name) == ".gz" {
+		gz := gzip.NewWriter(f)
+		gz.Write([]byte(content))
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEachDelegation(t *testing.T) {
+	for _, name := range []string{"com.zone", "com.zone.gz"} {
+		var got []string
+		if err := EachDelegation(writeFile(t, name, comZone), func(d string) { got = append(got, d) }); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if want := []string{"google.com", "example.com"}; !slices.Equal(got, want) {
+			t.Errorf("%s: delegations = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	set, err := LoadZone(writeFile(t, "com.zone", comZone))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bloom := NewBloom(2, 1e-9)
+	bloom.Add("github.net")
+
+	tests := []struct {
+		set    Set
+		domain string
+		want   bool
+	}{
+		{set, "google.com", true},
+		{set, " Google.COM. ", true},
+		{set, "kitleaf.com", false},
+		{set, "google.net", false}, // 不覆盖 .net
+		{set, "ns1.google.com", false},
+		{bloom, "github.net", true},
+		{bloom, "kitleaf.net", false},
+		{multiSet{set, bloom}, "github.net", true},
+		{multiSet{set, bloom}, "google.com", true},
+		{nil, "google.com", false},
+	}
+	for _, tt := range tests {
+		if got := Registered(tt.set, tt.domain); got != tt.want {
+			t.Errorf("Registered(%T, %q) = %v, want %v", tt.set, tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestBloomFile(t *testing.T) {
+	bloom := NewBloom(1000, 1e-6)
+	for _, d := range []string{"google.com", "example.com", "github.net"} {
+		bloom.Add(d)
+	}
+
+	path := filepath.Join(t.TempDir(), "zones.bloom")
+	if err := bloom.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadBloom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if read.Len() != 3 || !slices.Equal(read.Suffixes(), bloom.Suffixes()) {
+		t.Errorf("read %d domains, suffixes %v; want 3, %v", read.Len(), read.Suffixes(), bloom.Suffixes())
+	}
+	for _, d := range []string{"google.com", "example.com", "github.net"} {
+		if !read.Contains(d) {
+			t.Errorf("read filter does not contain %s", d)
+		}
+	}
+	if read.Contains("kitleaf.com") {
+		t.Error("read filter contains kitleaf.com")
+	}
+	if rate := read.FalsePositiveRate(); rate <= 0 || rate > 1e-5 {
+		t.Errorf("FalsePositiveRate = %g", rate)
+	}
+
+	if _, err := ReadBloom(writeFile(t, "bad.bloom", "not a filter")); err == nil {
+		t.Error("ReadBloom accepted a file without the header")
+	}
+}
+
+// useEnv 从 ZONE_FILTER 重新初始化默认数据，测试结束时恢复
+func useEnv(t *testing.T, paths string) {
+	t.Setenv("ZONE_FILTER", paths)
+	interval := reloadInterval
+	reloadInterval = 0
+	reset := func() {
+		defaultMu.Lock()
+		defaultSet, watched, modTimes = nil, nil, nil
+		defaultOnce = sync.Once{}
+		defaultMu.Unlock()
+	}
+	reset()
+	t.Cleanup(func() {
+		reloadInterval = interval
+		reset()
+	})
+}
+
+func TestDefaultReloads(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "zones.bloom")
+	write := func(domains ...string) {
+		bloom := NewBloom(10, 1e-9)
+		for _, d := range domains {
+			bloom.Add(d)
+		}
+		if err := bloom.WriteFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("google.com")
+	useEnv(t, path+", ,"+filepath.Join(dir, "missing.zone"))
+
+	if !Registered(Default(), "google.com") || Registered(Default(), "kitleaf.com") {
+		t.Fatal("initial load does not match the filter")
+	}
+
+	// refresh 原子地替换文件；把修改时间设到未来，避免文件系统的时间精度掩盖变化
+	write("google.com", "kitleaf.com")
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if !Registered(Default(), "kitleaf.com") {
+		t.Error("Default did not reload the replaced filter")
+	}
+
+	// SetDefault 之后不再监视文件
+	SetDefault(nil)
+	write("google.com")
+	later := future.Add(time.Hour)
+	os.Chtimes(path, later, later)
+	if Default() != nil {
+		t.Error("Default reloaded the file after SetDefault")
+	}
+}
@@ -1,4 +1,5 @@
 import React from 'react'
+import type { AftermarketInfo, Valuation, WebsiteInfo } from '../services/api'
 
 interface DomainResult {
   domain: string
@@ -6,6 +7,10 @@ interface DomainResult {
   score?: number
   signatures?: string[]
   reason?: string
+  confidence?: number
+  website?: WebsiteInfo
+  aftermarket?: AftermarketInfo
+  estimated_value?: Valuation
 }
 
 interface Props {
@@ -110,6 +115,14 @@ export default function DomainResults({ results }: Props) {
                       }`}>
                         {result.domain}
                       </h3>
+                      {result.estimated_value && (
+                        <div
+                          className="text-xs text-brand-light mt-1"
+                          title={result.estimated_value.explanation.join('\n')}
+                        >
+                          Est. ${result.estimated_value.low.toLocaleString()}–${result.estimated_value.high.toLocaleString()}
+                        </div>
+                      )}
                       {result.reason && (
                         <div className="absolute bottom-full left-0 mb-2 hidden group-hover:block z-10">
                           <div className="bg-gray-900 text-white text-xs rounded-lg p-3 max-w-xs shadow-lg">
@@ -127,6 +140,7 @@ export default function DomainResults({ results }: Props) {
                     {result.available ? (
                       <span className="inline-flex items-center px-2.5 py-1 rounded-full text-xs font-medium text-green-700 bg-green-100">
                         Available
+                        {result.confidence ? ` · ${Math.round(result.confidence * 100)}%` : ''}
                       </span>
                     ) : (
                       <div className="text-right">
@@ -141,6 +155,12 @@ export default function DomainResults({ results }: Props) {
                                 label = 'WHOIS information'
                               } else if (sig === 'SSL') {
                                 label = 'SSL certificate'
+                              } else if (sig === 'ZONE') {
+                                label = 'TLD zone file'
+                              } else if (sig === 'TLD_NS') {
+                                label = 'TLD delegation'
+                              } else if (sig === 'RDAP') {
+                                label = 'RDAP registry record'
                               }
                               return (
                                 <span
@@ -156,6 +176,25 @@ export default function DomainResults({ results }: Props) {
                               Domain registered
                             </span>
                           )}
+                          {(result.website?.status === 'parked' || result.website?.status === 'for_sale') && (
+                            <span className="text-xs px-2 py-0.5 bg-amber-100 text-amber-700 rounded">
+                              {result.website.status === 'for_sale' ? 'For sale' : 'Parked'}
+                              {result.website.provider ? ` (${result.website.provider})` : ''}
+                            </span>
+                          )}
+                          {result.aftermarket?.venue && (
+                            <a
+                              href={result.aftermarket.url}
+                              target="_blank"
+                              rel="noreferrer"
+                              className="text-xs px-2 py-0.5 bg-amber-100 text-amber-700 rounded"
+                            >
+                              Listed on {result.aftermarket.venue}
+                              {result.aftermarket.price
+                                ? ` · ${result.aftermarket.price.toLocaleString()} ${result.aftermarket.currency ?? ''}`
+                                : ' · Make offer'}
+                            </a>
+                          )}
                         </div>
                       </div>
                     )}
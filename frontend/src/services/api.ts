@@ -16,6 +16,9 @@ export interface ChatResponse {
   action: string
   data: any
   timestamp: string
+  confidence: number
+  intent_scores?: Record<string, number>
+  intent_source: string
 }
 
 export interface DomainResult {
@@ -24,6 +27,126 @@ export interface DomainResult {
   signatures: string[]
   score: number
   price: string
+  estimated_value?: Valuation
+  confidence: number
+  evidence?: Evidence[]
+  trademark_risk?: 'none' | 'low' | 'medium' | 'high'
+  trademark_matches?: TrademarkMatch[]
+  handles?: HandleResult[]
+  ecosystems?: EcosystemResult[]
+  dnssec?: 'secure' | 'insecure' | 'bogus' | 'indeterminate'
+  delegation?: DelegationEvidence
+  certificate?: CertificateInfo
+  ct?: CTSummary
+  website?: WebsiteInfo
+  aftermarket?: AftermarketInfo
+}
+
+export interface Valuation {
+  estimate: number
+  low: number
+  high: number
+  currency: string
+  explanation: string[]
+  comparables?: { domain: string; price: number; date?: string }[]
+}
+
+export interface AftermarketInfo {
+  for_sale: boolean
+  venue?: string
+  price?: number
+  currency?: string
+  url?: string
+  listings?: Listing[]
+  signals?: string[]
+}
+
+export interface Listing {
+  venue: string
+  status: 'listed' | 'not_listed' | 'unknown'
+  url?: string
+  price?: number
+  currency?: string
+  make_offer?: boolean
+  error?: string
+}
+
+export interface WebsiteInfo {
+  status: 'active' | 'parked' | 'for_sale' | 'redirect' | 'unreachable'
+  url?: string
+  http_status?: number
+  title?: string
+  redirects?: string[]
+  provider?: string
+  markers?: string[]
+  error?: string
+}
+
+export interface CertificateInfo {
+  subject: string
+  sans?: string[]
+  issuer: string
+  not_before: string
+  not_after: string
+  serial: string
+  matches: boolean
+  trusted: boolean
+  verify_error?: string
+}
+
+export interface CTSummary {
+  certificates: number
+  active: number
+  last_90_days: number
+  last_year: number
+  first_seen?: string
+  last_issued?: string
+  issuers?: string[]
+  names?: string[]
+  error?: string
+}
+
+export interface Evidence {
+  signal: string
+  indicates: 'taken' | 'available' | 'unknown'
+  weight: number
+  detail?: string
+}
+
+export interface DelegationEvidence {
+  zone: string
+  server: string
+  rcode: string
+  referral: boolean
+  name_servers?: string[]
+}
+
+export interface EcosystemResult {
+  registry: string
+  name: string
+  status: 'available' | 'taken' | 'invalid' | 'unknown'
+  available: boolean
+  error?: string
+}
+
+export interface HandleResult {
+  platform: string
+  handle: string
+  status: 'available' | 'taken' | 'invalid' | 'unknown'
+  available: boolean
+  error?: string
+}
+
+export interface TrademarkMatch {
+  mark: string
+  number?: string
+  owner?: string
+  office: string
+  classes?: string[]
+  status?: string
+  match_type: 'exact' | 'phonetic' | 'similar'
+  risk: string
+  similarity: number
 }
 
 export const sendMessage = async (
@@ -37,8 +160,20 @@ export const sendMessage = async (
   return response.data
 }
 
-export const checkDomains = async (domains: string[]): Promise<DomainResult[]> => {
-  const response = await api.post('/domains/check', { domains })
+export const checkDomains = async (
+  domains: string[],
+  options?: {
+    handles?: boolean
+    platforms?: string[]
+    ecosystems?: boolean
+    registries?: string[]
+    ct?: boolean
+    website?: boolean
+    aftermarket?: boolean
+    marketplaces?: string[]
+  }
+): Promise<DomainResult[]> => {
+  const response = await api.post('/domains/check', { domains, ...options })
   return response.data.results
 }
 
@@ -49,6 +184,9 @@ export const suggestDomains = async (
     maxLen?: number
     minLen?: number
     count?: number
+    strategies?: string[]
+    expand?: boolean
+    only_available?: boolean
   }
 ): Promise<any[]> => {
   const response = await api.post('/domains/suggest', {
@@ -58,6 +196,134 @@ export const suggestDomains = async (
   return response.data.suggestions
 }
 
+export interface AlternativeDomain {
+  domain: string
+  variant: string
+  similarity: number
+  score: number
+  rank: number
+  available: boolean
+}
+
+export const findAlternatives = async (
+  domain: string,
+  options?: {
+    tlds?: string[]
+    count?: number
+  }
+): Promise<AlternativeDomain[]> => {
+  const response = await api.post('/domains/alternatives', {
+    domain,
+    ...options,
+  })
+  return response.data.alternatives
+}
+
+export interface RegistrationSnapshot {
+  domain: string
+  source: 'whois' | 'rdap' | 'delegation'
+  registered: boolean
+  registrar?: string
+  registrant?: string
+  created_at?: string
+  expires_at?: string
+  name_servers?: string[]
+  status?: string[]
+  captured_at: string
+  last_seen_at: string
+}
+
+export interface RegistrationChange {
+  at: string
+  source: string
+  kind:
+    | 'registered'
+    | 'released'
+    | 'registrar_changed'
+    | 'registrant_changed'
+    | 'nameservers_changed'
+    | 'expiry_extended'
+    | 'expiry_changed'
+    | 'status_changed'
+  from?: string
+  to?: string
+}
+
+export const getDomainHistory = async (
+  domain: string,
+  refresh = false
+): Promise<{ domain: string; snapshots: RegistrationSnapshot[]; changes: RegistrationChange[]; refresh_error?: string }> => {
+  const response = await api.get(`/domains/${encodeURIComponent(domain)}/history`, {
+    params: refresh ? { refresh: true } : undefined,
+  })
+  return response.data
+}
+
+export interface Registration {
+  domain: string
+  registered: boolean
+  registrar?: string
+  registrant?: string
+  created_at?: string
+  updated_at?: string
+  expires_at?: string
+  name_servers?: string[]
+  status?: string[]
+}
+
+export interface WHOISLookup {
+  domain: string
+  rule: string
+  server?: string
+  raw: string
+  evidence: Evidence
+  registration?: Registration
+  aftermarket_signals?: string[]
+  fetched_at: string
+  cached: boolean
+}
+
+export interface RDAPLookup {
+  domain: string
+  server?: string
+  raw?: unknown
+  evidence: Evidence
+  registration?: Registration
+  fetched_at: string
+  cached: boolean
+}
+
+export interface DNSAnswer {
+  type: string
+  rcode?: string
+  records?: string[]
+  dnssec?: string
+  error?: string
+}
+
+export interface DNSLookup {
+  domain: string
+  delegation?: DelegationEvidence
+  answers: DNSAnswer[]
+  dnssec?: string
+  evidence: Evidence[]
+  fetched_at: string
+  cached: boolean
+}
+
+const lookup = async <T>(domain: string, kind: 'whois' | 'rdap' | 'dns', refresh: boolean): Promise<T> => {
+  const response = await api.get(`/domains/${encodeURIComponent(domain)}/${kind}`, {
+    params: refresh ? { refresh: true } : undefined,
+  })
+  return response.data
+}
+
+export const lookupWHOIS = (domain: string, refresh = false) => lookup<WHOISLookup>(domain, 'whois', refresh)
+
+export const lookupRDAP = (domain: string, refresh = false) => lookup<RDAPLookup>(domain, 'rdap', refresh)
+
+export const lookupDNS = (domain: string, refresh = false) => lookup<DNSLookup>(domain, 'dns', refresh)
+
 export const getSession = async (sessionId: string): Promise<any> => {
   const response = await api.get(`/agent/session/${sessionId}`)
   return response.data